@@ -0,0 +1,83 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"rating/internal/bootstrap"
+	"rating/internal/config"
+	"rating/internal/leaderboard"
+	"rating/internal/scheduler"
+	"time"
+)
+
+// newScheduler registers the maintenance jobs. The count cache is refreshed
+// by each replica on its own ticker instead: the counts live in the memory of
+// every replica, a job run by one of them would leave the others stale.
+func newScheduler(cfg config.Config, storage *bootstrap.Storage, log *slog.Logger) (*scheduler.Scheduler, error) {
+	opts := []scheduler.Option{
+		scheduler.WithRunStore(storage.JobRuns),
+		scheduler.WithInstance(instanceName()),
+	}
+	if storage.JobLocker != nil {
+		opts = append(opts, scheduler.WithLocker(storage.JobLocker))
+	}
+	jobs := scheduler.New(log, opts...)
+
+	if cfg.Leaderboard.SnapshotSchedule != "" {
+		schedule, err := scheduler.Parse(cfg.Leaderboard.SnapshotSchedule)
+		if err != nil {
+			return nil, err
+		}
+		jobs.Add(scheduler.Job{
+			Name:     "leaderboard-snapshot",
+			Schedule: schedule,
+			Run:      leaderboard.NewSnapshotter(storage.Leaderboard, cfg.Leaderboard.SnapshotKeep, log).Run,
+		})
+	}
+
+	// deletes are final, only the nicknames deleted users released outlive
+	// them, to block the nickname until the cooldown has passed
+	purgeSchedule, err := scheduler.Parse("@daily")
+	if err != nil {
+		return nil, err
+	}
+	jobs.Add(scheduler.Job{
+		Name:     "purge-deleted-users",
+		Schedule: purgeSchedule,
+		Run: func(ctx context.Context) error {
+			purged, err := storage.Nicknames.PurgeDeleted(ctx, time.Now().Add(-cfg.Nicknames.Cooldown))
+			if err != nil {
+				return err
+			}
+			log.InfoContext(ctx, "purged deleted users", slog.Int64("released nicknames", purged))
+			return nil
+		},
+	})
+
+	if cfg.Scheduler.HistoryRetention > 0 {
+		schedule, err := scheduler.Parse("@daily")
+		if err != nil {
+			return nil, err
+		}
+		jobs.Add(scheduler.Job{
+			Name:     "prune-job-runs",
+			Schedule: schedule,
+			Run: func(ctx context.Context) error {
+				_, err := storage.JobRuns.PruneRuns(ctx, time.Now().Add(-cfg.Scheduler.HistoryRetention))
+				return err
+			},
+		})
+	}
+
+	return jobs, nil
+}
+
+func instanceName() string {
+	host, err := os.Hostname()
+	if err != nil {
+		host = "unknown"
+	}
+	return fmt.Sprintf("%s-%d", host, os.Getpid())
+}
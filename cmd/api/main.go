@@ -3,72 +3,255 @@ package main
 import (
 	"context"
 	"errors"
+	"flag"
 	"log"
+	"log/slog"
 	"net/http"
 	"os"
 	"os/signal"
-	"rating/internal/db"
+	"rating/internal/bootstrap"
+	"rating/internal/cache"
+	"rating/internal/config"
+	"rating/internal/event"
 	"rating/internal/handler"
 	"rating/internal/logger"
+	"rating/internal/metrics"
 	"rating/internal/middleware"
 	"rating/internal/repo/postgres"
+	"rating/internal/scheduler"
 	"rating/internal/service"
+	"rating/internal/stream"
+	"rating/internal/tenant"
+	"rating/internal/tracing"
+	"rating/internal/webhook"
 	"syscall"
 	"time"
-
-	"github.com/joho/godotenv"
 )
 
 func main() {
-	if err := godotenv.Load(); err != nil {
-		log.Println("No .env file found, relying on system environment variables")
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	printConfig := flag.Bool("print-config", false, "print the effective config and exit")
+	migrateOnStart := flag.Bool("migrate", false, "apply pending migrations before starting the server")
+	flag.Parse()
+
+	cfg, err := config.Load(*configPath)
+	if err != nil {
+		log.Fatalf("invalid configuration:\n%v", err)
+	}
+
+	if *printConfig {
+		if err := cfg.Print(os.Stdout); err != nil {
+			log.Fatalf("failed to print config: %v", err)
+		}
+		return
+	}
+
+	shutdownTracing, err := tracing.Setup(context.Background(), cfg.Tracing.Exporter)
+	if err != nil {
+		log.Fatalf("failed to setup tracing: %v", err)
+	}
+
+	logger := logger.SetupLogger(cfg.Log.Level, cfg.Log.Format)
+
+	storage, err := bootstrap.NewStorage(context.Background(), cfg, logger)
+	if err != nil {
+		log.Fatalf("failed to create storage: %v", err)
+	}
+
+	if flag.Arg(0) == "migrate" {
+		if err := runMigrate(context.Background(), storage, flag.Args()[1:]); err != nil {
+			log.Fatal(err)
+		}
+		return
+	}
+
+	if *migrateOnStart && cfg.Storage.Driver != config.StorageDriverMemory {
+		if err := runMigrate(context.Background(), storage, []string{"up"}); err != nil {
+			log.Fatal(err)
+		}
+	}
+
+	var userStore service.UserStore = storage.Store
+	txManager := storage.Tx
+	var userCache *cache.UserStore
+	if cfg.Cache.Enabled {
+		userCache = cache.NewUserStore(storage.Store, cfg.Cache.Size, cfg.Cache.TTL)
+		userStore = userCache
+		txManager = cache.NewTxManager(storage.Tx, userCache)
+	}
+
+	broker := stream.NewBroker(cfg.Stream.ReplayBuffer, cfg.Stream.ClientBuffer, cfg.Stream.ReplayTTL)
+	feed := stream.NewFeed(broker, userStore, cfg.Stream.TopN, logger)
+
+	serviceOpts, err := bootstrap.UserServiceOptions(cfg, storage, txManager)
+	if err != nil {
+		log.Fatal(err)
+	}
+	if storage.Listener != nil {
+		// changes from every instance arrive through the listener
+		storage.Listener.Subscribe(func(change postgres.UserChange) {
+			if change.Op == postgres.ChangeResync {
+				if userCache != nil {
+					userCache.Purge()
+				}
+				feed.Resync()
+				return
+			}
+			if userCache != nil {
+				userCache.Invalidate(change.Tenant, change.Nicknames()...)
+			}
+			feed.HandleChange(change.ServiceChange())
+		})
+		storage.Listener.Start(context.Background())
+	} else {
+		serviceOpts = append(serviceOpts, service.WithChangeObserver(feed.HandleChange))
 	}
+	feed.Start(context.Background())
 
-	dbUrl := os.Getenv("DB_URL")
-	addr := os.Getenv("SERVER_ADDR")
-	logLevel := os.Getenv("LOG_LEVEL")
-	if dbUrl == "" {
-		log.Fatal("DB_URL environment variable is not set")
+	var relay *event.Relay
+	if storage.Events != nil {
+		publisher, err := newEventPublisher(cfg.Events, logger)
+		if err != nil {
+			log.Fatalf("failed to create event publisher: %v", err)
+		}
+		defer publisher.Close()
+		relay = event.NewRelay(storage.Events, publisher, cfg.Events.PollInterval, cfg.Events.BatchSize, cfg.Events.Retention, logger)
+		relay.Start(context.Background())
 	}
 
-	if addr == "" {
-		addr = ":8080"
+	var jobs *scheduler.Scheduler
+	if cfg.Scheduler.Enabled {
+		jobs, err = newScheduler(cfg, storage, logger)
+		if err != nil {
+			log.Fatalf("failed to create scheduler: %v", err)
+		}
+		jobs.Start(context.Background())
+	}
+
+	userService := service.NewUserService(userStore, serviceOpts...)
+	userHandlers := handler.NewUserHandler(userService, logger,
+		handler.WithDefaultPageSize(cfg.Pagination.DefaultPageSize),
+		handler.WithDefaultCountMode(cfg.Pagination.CountMode),
+		handler.WithNicknameAliases(userService, cfg.Nicknames.Redirect),
+	)
+	milestoneHandlers := handler.NewMilestoneHandler(userService, logger)
+	nicknameHandlers := handler.NewNicknameHandler(userService, logger)
+	moderationHandlers := handler.NewModerationHandler(userService, handler.NewTokenAuth(cfg.Fraud.ModeratorTokens), logger)
+	leaderboardHandlers := handler.NewLeaderboardHandler(service.NewLeaderboardService(storage.Leaderboard, userStore), logger)
+	operatorAuth := handler.NewTokenAuth(cfg.Operator.Tokens)
+	jobHandlers := handler.NewJobHandler(service.NewJobService(storage.JobRuns), operatorAuth, logger)
+	categoryHandlers := handler.NewCategoryHandler(service.NewCategoryService(storage.Categories, userStore), logger, cfg.Pagination.DefaultPageSize)
+	streamHandlers := handler.NewStreamHandler(broker, cfg.Stream.Heartbeat, logger)
+	var watchAuth handler.Authenticator
+	if len(cfg.Websocket.AuthTokens) > 0 {
+		watchAuth = handler.NewTokenAuth(cfg.Websocket.AuthTokens)
 	}
+	watchHandlers := handler.NewWatchHandler(userService, broker, watchAuth, cfg.Websocket.MaxSubscriptions, logger)
+	var webhookHandlers *handler.WebhookHandler
+	var dispatcher *webhook.Dispatcher
+	if storage.Webhooks != nil {
+		webhookService := service.NewWebhookService(storage.Webhooks, cfg.Webhooks.AllowPrivateTargets)
+		webhookHandlers = handler.NewWebhookHandler(webhookService, operatorAuth, logger)
+		dispatcher = webhook.NewDispatcher(storage.Webhooks, webhook.DispatcherConfig{
+			PollInterval: cfg.Webhooks.PollInterval,
+			BatchSize:    cfg.Webhooks.BatchSize,
+			Workers:      cfg.Webhooks.Workers,
+			MaxAttempts:  cfg.Webhooks.MaxAttempts,
+			Timeout:      cfg.Webhooks.Timeout,
+			BackoffBase:  cfg.Webhooks.BackoffBase,
+			BackoffMax:   cfg.Webhooks.BackoffMax,
+			Retention:    cfg.Webhooks.Retention,
 
-	if logLevel == "" {
-		log.Fatal("LOG_LEVEL environment variable is not set")
+			AllowPrivateTargets: cfg.Webhooks.AllowPrivateTargets,
+		}, logger)
+		dispatcher.Start(context.Background())
+	}
+	healthHandlers := handler.NewHealthHandler(storage.Checker, storage.SchemaVersion, logger)
+
+	appMetrics := metrics.NewMetrics()
+	if storage.Pool != nil {
+		if err := appMetrics.RegisterPool(storage.Pool); err != nil {
+			log.Fatalf("failed to register pool metrics: %v", err)
+		}
+	}
+	if err := appMetrics.RegisterUserCounter(storage.Counter, logger); err != nil {
+		log.Fatalf("failed to register user metrics: %v", err)
+	}
+	if userCache != nil {
+		if err := appMetrics.RegisterCache("users", userCache); err != nil {
+			log.Fatalf("failed to register cache metrics: %v", err)
+		}
 	}
 
-	pool, err := db.NewDb(context.Background(), dbUrl)
+	tenantKeys, err := cfg.TenantKeys()
 	if err != nil {
-		log.Fatalf("failed to create pool: %v", err)
+		log.Fatalf("invalid tenant api keys: %v", err)
 	}
-	logger := logger.SetupLogger(logLevel)
+	tenants := tenant.NewResolver(tenantKeys, cfg.Tenancy.Header)
 
-	userRepo := postgres.NewUserRepo(pool)
-	userService := service.NewUserService(userRepo)
-	userHandlers := handler.NewUserHandler(userService, logger)
+	// reads stay on the primary as long as a healthy replica may lag behind
+	var primaryWindow time.Duration
+	if cfg.Database.ReplicaURL != "" {
+		primaryWindow = cfg.Database.ReplicaMaxLag
+	}
 
 	mux := http.NewServeMux()
 	chainedHandler := middleware.Chain(
 		mux,
 		middleware.RecoveryMiddleware(logger),
+		middleware.TracingMiddleware(),
 		middleware.LoggerMiddleware(logger),
+		middleware.ReadYourWritesMiddleware(primaryWindow),
+		middleware.TenantMiddleware(tenants, logger, "/healthz", "/readyz", "/metrics"),
+		middleware.MetricsMiddleware(appMetrics),
 	)
 
 	mux.HandleFunc("POST /users", userHandlers.CreateUserHandler)
 	mux.HandleFunc("GET /users", userHandlers.GetUsers)
+	mux.HandleFunc("GET /users/stream", streamHandlers.Stream)
+	mux.HandleFunc("GET /users/watch", watchHandlers.Watch)
 	mux.HandleFunc("GET /users/{nickname}", userHandlers.GetUser)
 	mux.HandleFunc("PATCH /users/{nickname}", userHandlers.ChangeData)
 	mux.HandleFunc("DELETE /users/{nickname}", userHandlers.Delete)
+	mux.HandleFunc("GET /users/{nickname}/milestones", milestoneHandlers.ListMilestones)
+	mux.HandleFunc("GET /users/{nickname}/nicknames", nicknameHandlers.ListNicknames)
+	mux.HandleFunc("GET /users/{nickname}/rank", leaderboardHandlers.UserRank)
+	mux.HandleFunc("GET /users/{nickname}/categories", categoryHandlers.UserCategories)
+	mux.HandleFunc("POST /categories", categoryHandlers.CreateCategory)
+	mux.HandleFunc("GET /categories", categoryHandlers.ListCategories)
+	mux.HandleFunc("GET /categories/{slug}", categoryHandlers.GetCategory)
+	mux.HandleFunc("PATCH /categories/{slug}", categoryHandlers.UpdateCategory)
+	mux.HandleFunc("DELETE /categories/{slug}", categoryHandlers.DeleteCategory)
+	mux.HandleFunc("GET /categories/{slug}/leaderboard", categoryHandlers.Leaderboard)
+	mux.HandleFunc("PUT /categories/{slug}/members/{nickname}", categoryHandlers.AddMember)
+	mux.HandleFunc("DELETE /categories/{slug}/members/{nickname}", categoryHandlers.RemoveMember)
+	mux.HandleFunc("GET /leaderboard/snapshots", leaderboardHandlers.ListSnapshots)
+	mux.HandleFunc("GET /leaderboard/snapshots/diff", leaderboardHandlers.Diff)
+	mux.HandleFunc("GET /jobs/runs", jobHandlers.ListRuns)
+	if webhookHandlers != nil {
+		mux.HandleFunc("POST /webhooks", webhookHandlers.CreateSubscription)
+		mux.HandleFunc("GET /webhooks", webhookHandlers.ListSubscriptions)
+		mux.HandleFunc("DELETE /webhooks/{id}", webhookHandlers.DeleteSubscription)
+		mux.HandleFunc("GET /webhooks/{id}/deliveries", webhookHandlers.ListDeliveries)
+		mux.HandleFunc("POST /webhooks/deliveries/{id}/replay", webhookHandlers.ReplayDelivery)
+	}
+	if cfg.Fraud.Enabled {
+		mux.HandleFunc("GET /moderation/quarantine", moderationHandlers.ListQuarantined)
+		mux.HandleFunc("POST /moderation/quarantine/{id}/approve", moderationHandlers.Approve)
+		mux.HandleFunc("POST /moderation/quarantine/{id}/reject", moderationHandlers.Reject)
+	}
+	mux.Handle("GET /metrics", appMetrics.Handler())
+	mux.HandleFunc("GET /healthz", healthHandlers.Liveness)
+	mux.HandleFunc("GET /readyz", healthHandlers.Readiness)
 
 	server := &http.Server{
-		Addr:         addr,
+		Addr:         cfg.HTTP.Addr,
 		Handler:      chainedHandler,
-		ReadTimeout:  5 * time.Second,
-		WriteTimeout: 10 * time.Second,
-		IdleTimeout:  10 * time.Second,
+		ReadTimeout:  cfg.HTTP.ReadTimeout,
+		WriteTimeout: cfg.HTTP.WriteTimeout,
+		IdleTimeout:  cfg.HTTP.IdleTimeout,
 	}
+	server.RegisterOnShutdown(broker.Close)
 
 	go func() {
 		if err := server.ListenAndServe(); err != nil && !errors.Is(err, http.ErrServerClosed) {
@@ -82,12 +265,43 @@ func main() {
 
 	<-quit
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	// Fail readiness first so the load balancer stops routing here, then
+	// cancel running jobs so none start or hold locks while draining.
+	healthHandlers.SetShuttingDown()
+	if jobs != nil {
+		jobs.Stop()
+	}
+	time.Sleep(cfg.HTTP.ShutdownDrainDelay)
+
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.HTTP.ShutdownTimeout)
 	defer cancel()
 
 	if err := server.Shutdown(ctx); err != nil {
 		log.Fatalf("Server forced to shutdown: %v", err)
 	}
+	if err := watchHandlers.Shutdown(ctx); err != nil {
+		log.Printf("failed to close websocket connections: %v", err)
+	}
+	feed.Stop()
+	if dispatcher != nil {
+		dispatcher.Stop()
+	}
+	if relay != nil {
+		relay.Stop()
+	}
+
+	if err := shutdownTracing(ctx); err != nil {
+		log.Printf("failed to flush traces: %v", err)
+	}
 
-	defer pool.Close()
+	defer storage.Close()
+}
+
+func newEventPublisher(cfg config.Events, log *slog.Logger) (event.Publisher, error) {
+	switch cfg.Publisher {
+	case config.EventPublisherFile:
+		return event.NewFilePublisher(cfg.FilePath)
+	default:
+		return event.NewLogPublisher(log), nil
+	}
 }
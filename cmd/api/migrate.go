@@ -0,0 +1,44 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"rating/internal/bootstrap"
+	"strconv"
+)
+
+const migrateUsage = "usage: migrate up | down | status | to <version>"
+
+func runMigrate(ctx context.Context, storage *bootstrap.Storage, args []string) error {
+	if len(args) == 0 {
+		return errors.New(migrateUsage)
+	}
+
+	runner, err := storage.Migrator()
+	if err != nil {
+		return err
+	}
+	defer runner.Close()
+
+	switch args[0] {
+	case "up":
+		return runner.Up(ctx)
+	case "down":
+		return runner.Down(ctx)
+	case "status":
+		return runner.Status(ctx, os.Stdout)
+	case "to":
+		if len(args) != 2 {
+			return errors.New(migrateUsage)
+		}
+		version, err := strconv.ParseInt(args[1], 10, 64)
+		if err != nil {
+			return fmt.Errorf("invalid migration version %q: %w", args[1], err)
+		}
+		return runner.To(ctx, version)
+	default:
+		return errors.New(migrateUsage)
+	}
+}
@@ -0,0 +1,244 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strings"
+)
+
+func (a *app) create(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("create", flag.ContinueOnError)
+	var dto request.UserRequestDTO
+	fs.StringVar(&dto.Name, "name", "", "user name")
+	fs.StringVar(&dto.Nickname, "nickname", "", "unique nickname")
+	fs.IntVar(&dto.Likes, "likes", 0, "number of likes")
+	fs.IntVar(&dto.Viewers, "viewers", 0, "number of viewers")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	if err := a.service.CreateUser(ctx, dto); err != nil {
+		return err
+	}
+
+	user, err := a.service.GetUser(ctx, dto.Nickname)
+	if err != nil {
+		return err
+	}
+
+	return a.out.users([]model.User{*user}, 0)
+}
+
+func (a *app) get(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: get NICKNAME")
+	}
+
+	user, err := a.service.GetUser(ctx, args[0])
+	if err != nil {
+		return err
+	}
+
+	return a.out.users([]model.User{*user}, 0)
+}
+
+func (a *app) list(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("list", flag.ContinueOnError)
+	page := fs.Int("page", 1, "page number")
+	size := fs.Int("size", 10, "page size")
+	sort := fs.String("sort", "", "sort by rating: asc or desc")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+
+	params := request.NewPaginationQuery(*size, (*page-1)*(*size), *sort)
+	users, count, err := a.service.GetAll(ctx, params)
+	if err != nil {
+		return err
+	}
+
+	return a.out.users(users, count.Total)
+}
+
+func (a *app) update(ctx context.Context, args []string) error {
+	if len(args) == 0 || strings.HasPrefix(args[0], "-") {
+		return errors.New("usage: update NICKNAME [-name NAME] [-nickname NICK] [-likes N] [-viewers N]")
+	}
+	nickname := args[0]
+
+	fs := flag.NewFlagSet("update", flag.ContinueOnError)
+	name := fs.String("name", "", "new name")
+	newNickname := fs.String("nickname", "", "new nickname")
+	likes := fs.Int("likes", 0, "new number of likes")
+	viewers := fs.Int("viewers", 0, "new number of viewers")
+	if err := fs.Parse(args[1:]); err != nil {
+		return err
+	}
+
+	var dto request.UpdateUserDTO
+	fs.Visit(func(f *flag.Flag) {
+		switch f.Name {
+		case "name":
+			dto.Name = name
+		case "nickname":
+			dto.Nickname = newNickname
+		case "likes":
+			dto.Likes = likes
+		case "viewers":
+			dto.Viewers = viewers
+		}
+	})
+
+	if err := a.service.ChangeData(ctx, nickname, dto); err != nil {
+		return err
+	}
+
+	if dto.Nickname != nil {
+		nickname = *dto.Nickname
+	}
+	user, err := a.service.GetUser(ctx, nickname)
+	if err != nil {
+		return err
+	}
+
+	return a.out.users([]model.User{*user}, 0)
+}
+
+func (a *app) delete(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: delete NICKNAME")
+	}
+
+	if err := a.service.Delete(ctx, args[0]); err != nil {
+		return err
+	}
+
+	return a.out.status(fmt.Sprintf("deleted %s", args[0]))
+}
+
+func (a *app) importUsers(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: import FILE")
+	}
+
+	f, err := os.Open(args[0])
+	if err != nil {
+		return fmt.Errorf("failed to open import file: %w", err)
+	}
+	defer f.Close()
+
+	dtos, err := decodeUsers(f, filepath.Ext(args[0]))
+	if err != nil {
+		return err
+	}
+
+	var imported int
+	var errs []error
+	for i, dto := range dtos {
+		if err := a.service.CreateUser(ctx, dto); err != nil {
+			errs = append(errs, fmt.Errorf("record %d (%s): %w", i+1, dto.Nickname, err))
+			continue
+		}
+		imported++
+	}
+
+	if err := a.out.status(fmt.Sprintf("imported %d of %d users", imported, len(dtos))); err != nil {
+		return err
+	}
+
+	return errors.Join(errs...)
+}
+
+func (a *app) exportUsers(ctx context.Context, args []string) error {
+	if len(args) != 1 {
+		return errors.New("usage: export FILE")
+	}
+
+	var users []model.User
+	for offset := 0; ; offset += a.pageSize {
+		page, count, err := a.service.GetAll(ctx, request.NewPaginationQuery(a.pageSize, offset, ""))
+		if err != nil {
+			return err
+		}
+		users = append(users, page...)
+		if len(page) < a.pageSize || len(users) >= count.Total {
+			break
+		}
+	}
+
+	var w io.Writer = os.Stdout
+	ext := ".json"
+	if args[0] != "-" {
+		f, err := os.Create(args[0])
+		if err != nil {
+			return fmt.Errorf("failed to create export file: %w", err)
+		}
+		defer f.Close()
+		w = f
+		ext = filepath.Ext(args[0])
+	}
+
+	if err := encodeUsers(w, ext, users); err != nil {
+		return err
+	}
+
+	if args[0] == "-" {
+		return nil
+	}
+
+	return a.out.status(fmt.Sprintf("exported %d users to %s", len(users), args[0]))
+}
+
+type ratingRecomputer interface {
+	RecomputeRatings(ctx context.Context) (int64, error)
+}
+
+func (a *app) recompute(ctx context.Context) error {
+	recomputer, ok := a.store.(ratingRecomputer)
+	if !ok {
+		return errors.New("recompute is only supported by the postgres storage driver")
+	}
+
+	updated, err := recomputer.RecomputeRatings(ctx)
+	if err != nil {
+		return err
+	}
+
+	return a.out.status(fmt.Sprintf("recomputed ratings for %d users", updated))
+}
+
+func (a *app) leaderboard(ctx context.Context, args []string) error {
+	fs := flag.NewFlagSet("leaderboard", flag.ContinueOnError)
+	top := fs.Int("top", 10, "number of users to show")
+	if err := fs.Parse(args); err != nil {
+		return err
+	}
+	if *top < 1 {
+		return errors.New("-top must be at least 1")
+	}
+
+	var (
+		users []model.User
+		total int
+	)
+	for {
+		size := min(*top-len(users), a.pageSize)
+		page, count, err := a.service.GetAll(ctx, request.NewPaginationQuery(size, len(users), "desc"))
+		if err != nil {
+			return err
+		}
+		users, total = append(users, page...), count.Total
+		if len(page) < size || len(users) >= *top {
+			break
+		}
+	}
+
+	return a.out.leaderboard(users, total)
+}
@@ -0,0 +1,59 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/repo/memory"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestApp_LeaderboardPages(t *testing.T) {
+	const pageSize = 100
+	ctx := context.Background()
+	repo := memory.NewUserRepo()
+	for i := range pageSize + 50 {
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", fmt.Sprintf("user%d", i), i, i+1)))
+	}
+
+	tests := []struct {
+		top  int
+		want int
+	}{
+		{top: 10, want: 10},
+		{top: pageSize + 20, want: pageSize + 20},
+		{top: 1000, want: pageSize + 50},
+	}
+
+	for _, tt := range tests {
+		t.Run(fmt.Sprint(tt.top), func(t *testing.T) {
+			var buf bytes.Buffer
+			out, err := newPrinter(&buf, outputJSON)
+			require.NoError(t, err)
+			a := &app{service: service.NewUserService(repo, service.WithMaxPageSize(pageSize)), out: out, pageSize: pageSize}
+
+			require.NoError(t, a.leaderboard(ctx, []string{"-top", fmt.Sprint(tt.top)}))
+
+			var resp struct {
+				Data []struct {
+					Rank int `json:"rank"`
+				} `json:"data"`
+				Total int `json:"total_count"`
+			}
+			require.NoError(t, json.Unmarshal(buf.Bytes(), &resp))
+			require.Len(t, resp.Data, tt.want)
+			require.Equal(t, tt.want, resp.Data[tt.want-1].Rank)
+			require.Equal(t, pageSize+50, resp.Total)
+		})
+	}
+
+	for _, top := range []string{"0", "-5"} {
+		a := &app{service: service.NewUserService(repo), pageSize: pageSize}
+		require.ErrorContains(t, a.leaderboard(ctx, []string{"-top", top}), "-top must be at least 1")
+	}
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strconv"
+	"strings"
+)
+
+var csvHeader = []string{"name", "nickname", "likes", "viewers"}
+
+func decodeUsers(r io.Reader, ext string) ([]request.UserRequestDTO, error) {
+	switch strings.ToLower(ext) {
+	case ".json":
+		var dtos []request.UserRequestDTO
+		if err := json.NewDecoder(r).Decode(&dtos); err != nil {
+			return nil, fmt.Errorf("failed to decode json: %w", err)
+		}
+		return dtos, nil
+	case ".csv":
+		return decodeCSV(r)
+	default:
+		return nil, fmt.Errorf("unsupported file extension %q, use .json or .csv", ext)
+	}
+}
+
+func decodeCSV(r io.Reader) ([]request.UserRequestDTO, error) {
+	records, err := csv.NewReader(r).ReadAll()
+	if err != nil {
+		return nil, fmt.Errorf("failed to read csv: %w", err)
+	}
+	if len(records) == 0 {
+		return nil, nil
+	}
+
+	columns := make(map[string]int, len(records[0]))
+	for i, name := range records[0] {
+		columns[strings.ToLower(strings.TrimSpace(name))] = i
+	}
+	for _, name := range csvHeader {
+		if _, ok := columns[name]; !ok {
+			return nil, fmt.Errorf("csv header is missing column %q", name)
+		}
+	}
+
+	dtos := make([]request.UserRequestDTO, 0, len(records)-1)
+	var errs []error
+	for line, record := range records[1:] {
+		likes, likesErr := strconv.Atoi(record[columns["likes"]])
+		viewers, viewersErr := strconv.Atoi(record[columns["viewers"]])
+		if err := errors.Join(likesErr, viewersErr); err != nil {
+			errs = append(errs, fmt.Errorf("line %d: %w", line+2, err))
+			continue
+		}
+		dtos = append(dtos, request.UserRequestDTO{
+			Name:     record[columns["name"]],
+			Nickname: record[columns["nickname"]],
+			Likes:    likes,
+			Viewers:  viewers,
+		})
+	}
+
+	return dtos, errors.Join(errs...)
+}
+
+func encodeUsers(w io.Writer, ext string, users []model.User) error {
+	switch strings.ToLower(ext) {
+	case ".json":
+		enc := json.NewEncoder(w)
+		enc.SetIndent("", "  ")
+		return enc.Encode(users)
+	case ".csv":
+		cw := csv.NewWriter(w)
+		if err := cw.Write(append(csvHeader, "rating")); err != nil {
+			return err
+		}
+		for _, u := range users {
+			record := []string{
+				u.Name,
+				u.NickName,
+				strconv.Itoa(u.Likes),
+				strconv.Itoa(u.Viewers),
+				strconv.FormatFloat(u.Rating, 'f', 3, 64),
+			}
+			if err := cw.Write(record); err != nil {
+				return err
+			}
+		}
+		cw.Flush()
+		return cw.Error()
+	default:
+		return fmt.Errorf("unsupported file extension %q, use .json or .csv", ext)
+	}
+}
@@ -0,0 +1,144 @@
+package main
+
+import (
+	"bytes"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestDecodeUsers(t *testing.T) {
+	tests := []struct {
+		name    string
+		ext     string
+		input   string
+		want    []request.UserRequestDTO
+		wantErr string
+	}{
+		{
+			name:  "json",
+			ext:   ".json",
+			input: `[{"name":"Alice","nickname":"alice","likes":3,"viewers":10}]`,
+			want:  []request.UserRequestDTO{{Name: "Alice", Nickname: "alice", Likes: 3, Viewers: 10}},
+		},
+		{
+			name:  "csv",
+			ext:   ".csv",
+			input: "name,nickname,likes,viewers\nAlice,alice,3,10\nBob,bob,0,1\n",
+			want: []request.UserRequestDTO{
+				{Name: "Alice", Nickname: "alice", Likes: 3, Viewers: 10},
+				{Name: "Bob", Nickname: "bob", Viewers: 1},
+			},
+		},
+		{
+			name:  "csv reordered header",
+			ext:   ".CSV",
+			input: "Viewers, Likes,NICKNAME,name\n10,3,alice,Alice\n",
+			want:  []request.UserRequestDTO{{Name: "Alice", Nickname: "alice", Likes: 3, Viewers: 10}},
+		},
+		{
+			name:  "empty csv",
+			ext:   ".csv",
+			input: "",
+		},
+		{
+			name:    "csv missing column",
+			ext:     ".csv",
+			input:   "name,nickname,likes\nAlice,alice,3\n",
+			wantErr: `missing column "viewers"`,
+		},
+		{
+			name:    "csv bad number",
+			ext:     ".csv",
+			input:   "name,nickname,likes,viewers\nAlice,alice,3,10\nBob,bob,many,1\n",
+			wantErr: "line 3",
+		},
+		{
+			name:    "bad json",
+			ext:     ".json",
+			input:   `{"name":"Alice"}`,
+			wantErr: "failed to decode json",
+		},
+		{
+			name:    "unsupported extension",
+			ext:     ".xml",
+			wantErr: "unsupported file extension",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := decodeUsers(strings.NewReader(tt.input), tt.ext)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, got)
+		})
+	}
+}
+
+func TestEncodeUsers(t *testing.T) {
+	users := []model.User{{Id: 1, Name: "Alice", NickName: "alice", Likes: 3, Viewers: 10, Rating: 0.25}}
+
+	tests := []struct {
+		name    string
+		ext     string
+		want    string
+		wantErr string
+	}{
+		{
+			name: "json",
+			ext:  ".json",
+			want: "[\n  {\n    \"id\": 1,\n    \"name\": \"Alice\",\n    \"nickname\": \"alice\",\n    \"likes\": 3,\n    \"viewers\": 10,\n    \"rating\": 0.25\n  }\n]\n",
+		},
+		{
+			name: "csv",
+			ext:  ".csv",
+			want: "name,nickname,likes,viewers,rating\nAlice,alice,3,10,0.250\n",
+		},
+		{
+			name:    "unsupported extension",
+			ext:     ".xml",
+			wantErr: "unsupported file extension",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var buf bytes.Buffer
+			err := encodeUsers(&buf, tt.ext, users)
+			if tt.wantErr != "" {
+				require.ErrorContains(t, err, tt.wantErr)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.want, buf.String())
+		})
+	}
+}
+
+func TestEncodeUsers_RoundTrip(t *testing.T) {
+	users := []model.User{
+		{Name: "Alice", NickName: "alice", Likes: 3, Viewers: 10},
+		{Name: "Bob, Jr.", NickName: "bob", Viewers: 1},
+	}
+
+	for _, ext := range []string{".json", ".csv"} {
+		t.Run(ext, func(t *testing.T) {
+			var buf bytes.Buffer
+			require.NoError(t, encodeUsers(&buf, ext, users))
+
+			got, err := decodeUsers(&buf, ext)
+			require.NoError(t, err)
+			require.Equal(t, []request.UserRequestDTO{
+				{Name: "Alice", Nickname: "alice", Likes: 3, Viewers: 10},
+				{Name: "Bob, Jr.", Nickname: "bob", Viewers: 1},
+			}, got)
+		})
+	}
+}
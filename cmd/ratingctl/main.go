@@ -0,0 +1,130 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"flag"
+	"fmt"
+	"log/slog"
+	"os"
+	"os/signal"
+	"rating/internal/bootstrap"
+	"rating/internal/config"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"syscall"
+)
+
+const usage = `usage: ratingctl [-config FILE] [-db url] [-tenant ID] [-o table|json] <command> [args]
+
+The storage, nickname policy, milestones, fraud check and outboxes are taken
+from the same configuration as the API server.
+
+commands:
+  create -name NAME -nickname NICK [-likes N] [-viewers N]
+  get NICKNAME
+  list [-page N] [-size N] [-sort asc|desc]
+  update NICKNAME [-name NAME] [-nickname NICK] [-likes N] [-viewers N]
+  delete NICKNAME
+  import FILE           import users from a .json or .csv file
+  export FILE           export all users to a .json or .csv file, "-" for stdout
+  recompute             recompute stored ratings for every user
+  leaderboard [-top N]  print the top users by rating
+`
+
+type app struct {
+	service *service.UserService
+	store   service.UserStore
+	out     *printer
+	// pageSize is the largest page the user service serves in one listing
+	pageSize int
+}
+
+func main() {
+	configPath := flag.String("config", os.Getenv("CONFIG_FILE"), "path to a YAML or TOML config file")
+	dbUrl := flag.String("db", "", "database url, overrides DB_URL")
+	tenantId := flag.String("tenant", tenant.Default, "tenant the command acts on")
+	output := flag.String("o", outputTable, "output format: table or json")
+	flag.Usage = func() { fmt.Fprint(os.Stderr, usage) }
+	flag.Parse()
+
+	if flag.NArg() == 0 {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	if *dbUrl != "" {
+		os.Setenv("DB_URL", *dbUrl)
+	}
+
+	if err := run(*configPath, *tenantId, *output, flag.Args()); err != nil {
+		fmt.Fprintln(os.Stderr, "error:", err)
+		os.Exit(1)
+	}
+}
+
+func run(configPath, tenantId, output string, args []string) error {
+	if !tenant.Valid(tenantId) {
+		return fmt.Errorf("invalid tenant %q", tenantId)
+	}
+
+	out, err := newPrinter(os.Stdout, output)
+	if err != nil {
+		return err
+	}
+
+	cfg, err := config.Load(configPath)
+	if err != nil {
+		return fmt.Errorf("invalid configuration:\n%w", err)
+	}
+	if cfg.Storage.Driver == config.StorageDriverMemory {
+		return errors.New("the memory storage driver keeps no data between runs, use postgres or sqlite")
+	}
+
+	ctx, stop := signal.NotifyContext(context.Background(), syscall.SIGINT, syscall.SIGTERM)
+	defer stop()
+	ctx = tenant.WithID(ctx, tenantId)
+
+	// stdout carries the command output, logs go to stderr
+	log := slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: slog.LevelWarn}))
+	storage, err := bootstrap.NewStorage(ctx, cfg, log)
+	if err != nil {
+		return err
+	}
+	defer storage.Close()
+
+	opts, err := bootstrap.UserServiceOptions(cfg, storage, storage.Tx)
+	if err != nil {
+		return err
+	}
+	a := &app{
+		service:  service.NewUserService(storage.Store, opts...),
+		store:    storage.Store,
+		out:      out,
+		pageSize: cfg.Pagination.MaxPageSize,
+	}
+
+	command, rest := args[0], args[1:]
+	switch command {
+	case "create":
+		return a.create(ctx, rest)
+	case "get":
+		return a.get(ctx, rest)
+	case "list":
+		return a.list(ctx, rest)
+	case "update":
+		return a.update(ctx, rest)
+	case "delete":
+		return a.delete(ctx, rest)
+	case "import":
+		return a.importUsers(ctx, rest)
+	case "export":
+		return a.exportUsers(ctx, rest)
+	case "recompute":
+		return a.recompute(ctx)
+	case "leaderboard":
+		return a.leaderboard(ctx, rest)
+	default:
+		return fmt.Errorf("unknown command %q\n\n%s", command, usage)
+	}
+}
@@ -0,0 +1,96 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	responsedto "rating/internal/dto/response"
+	"rating/internal/model"
+	"text/tabwriter"
+)
+
+const (
+	outputTable = "table"
+	outputJSON  = "json"
+)
+
+type printer struct {
+	w      io.Writer
+	format string
+}
+
+func newPrinter(w io.Writer, format string) (*printer, error) {
+	if format != outputTable && format != outputJSON {
+		return nil, fmt.Errorf("unknown output format %q", format)
+	}
+
+	return &printer{
+		w:      w,
+		format: format,
+	}, nil
+}
+
+func (p *printer) json(data any) error {
+	enc := json.NewEncoder(p.w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(data)
+}
+
+func (p *printer) users(users []model.User, total int) error {
+	if p.format == outputJSON {
+		if total > 0 {
+			return p.json(responsedto.NewPaginatedResponse(users, total))
+		}
+		if len(users) == 1 {
+			return p.json(users[0])
+		}
+		return p.json(users)
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "ID\tNAME\tNICKNAME\tLIKES\tVIEWERS\tRATING")
+	for _, u := range users {
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%d\t%d\t%.3f\n", u.Id, u.Name, u.NickName, u.Likes, u.Viewers, u.Rating)
+	}
+	if err := tw.Flush(); err != nil {
+		return err
+	}
+
+	if total > 0 {
+		_, err := fmt.Fprintf(p.w, "\n%d of %d users\n", len(users), total)
+		return err
+	}
+
+	return nil
+}
+
+func (p *printer) leaderboard(users []model.User, total int) error {
+	if p.format == outputJSON {
+		type entry struct {
+			Rank int `json:"rank"`
+			model.User
+		}
+		entries := make([]entry, 0, len(users))
+		for i, u := range users {
+			entries = append(entries, entry{Rank: i + 1, User: u})
+		}
+		return p.json(responsedto.NewPaginatedResponse(entries, total))
+	}
+
+	tw := tabwriter.NewWriter(p.w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "RANK\tNICKNAME\tRATING\tLIKES\tVIEWERS")
+	for i, u := range users {
+		fmt.Fprintf(tw, "%d\t%s\t%.3f\t%d\t%d\n", i+1, u.NickName, u.Rating, u.Likes, u.Viewers)
+	}
+
+	return tw.Flush()
+}
+
+func (p *printer) status(msg string) error {
+	if p.format == outputJSON {
+		return p.json(map[string]string{"status": msg})
+	}
+
+	_, err := fmt.Fprintln(p.w, msg)
+	return err
+}
@@ -0,0 +1,47 @@
+package bootstrap
+
+import (
+	"fmt"
+	"rating/internal/config"
+	"rating/internal/fraud"
+	"rating/internal/service"
+)
+
+// UserServiceOptions returns the user service options every writer shares:
+// transactions through tx, the page size limit, milestones, the nickname
+// history and policy, the fraud check and the event outbox. Webhook events
+// are written by the Postgres store itself when webhooks are enabled.
+func UserServiceOptions(cfg config.Config, s *Storage, tx service.TxManager) ([]service.Option, error) {
+	milestoneRules, err := cfg.MilestoneRules()
+	if err != nil {
+		return nil, fmt.Errorf("invalid milestone rules: %w", err)
+	}
+	nicknamePolicy, err := cfg.NicknamePolicy()
+	if err != nil {
+		return nil, fmt.Errorf("invalid nickname policy: %w", err)
+	}
+
+	opts := []service.Option{
+		service.WithMaxPageSize(cfg.Pagination.MaxPageSize),
+		service.WithTxManager(tx),
+		service.WithMilestones(s.Milestones, milestoneRules),
+		service.WithNicknameHistory(s.Nicknames, cfg.Nicknames.Cooldown),
+		service.WithNicknamePolicy(nicknamePolicy),
+	}
+	if cfg.Fraud.Enabled {
+		detector := fraud.NewDetector(fraud.Config{
+			JumpMultiple:    cfg.Fraud.JumpMultiple,
+			JumpMinDelta:    cfg.Fraud.JumpMinDelta,
+			RatioThreshold:  cfg.Fraud.RatioThreshold,
+			RatioMinViewers: cfg.Fraud.RatioMinViewers,
+			BurstLimit:      cfg.Fraud.BurstLimit,
+			BurstWindow:     cfg.Fraud.BurstWindow,
+		})
+		opts = append(opts, service.WithFraudCheck(detector, s.Quarantine))
+	}
+	if s.Events != nil {
+		opts = append(opts, service.WithEventOutbox(s.Events))
+	}
+
+	return opts, nil
+}
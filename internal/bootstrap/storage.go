@@ -0,0 +1,214 @@
+// Package bootstrap wires the configured storage driver and the user service
+// options shared by the API server and ratingctl, so both write users through
+// the same pipeline.
+package bootstrap
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rating/internal/config"
+	"rating/internal/db"
+	"rating/internal/event"
+	"rating/internal/handler"
+	"rating/internal/metrics"
+	"rating/internal/migrate"
+	"rating/internal/repo/memory"
+	"rating/internal/repo/postgres"
+	"rating/internal/repo/sqlite"
+	"rating/internal/scheduler"
+	"rating/internal/service"
+	"rating/migrations"
+	sqlitemigrations "rating/migrations/sqlite"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type EventOutbox interface {
+	service.EventOutbox
+	event.Source
+}
+
+type JobRunStore interface {
+	scheduler.RunStore
+	service.JobRunStore
+	PruneRuns(ctx context.Context, before time.Time) (int64, error)
+}
+
+type NicknameStore interface {
+	service.NicknameStore
+	PurgeDeleted(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Storage holds the stores of the configured driver. Stores the driver does
+// not provide are nil.
+type Storage struct {
+	Store         service.UserStore
+	Tx            service.TxManager
+	Counter       metrics.UserCounter
+	Checker       handler.ReadinessChecker
+	SchemaVersion int64
+	Pool          *pgxpool.Pool
+	Replica       *postgres.Replica
+	CountCache    *postgres.CountCache
+	Listener      *postgres.Listener
+	Webhooks      *postgres.WebhookRepo
+	Events        EventOutbox
+	Milestones    service.MilestoneStore
+	Quarantine    service.QuarantineStore
+	Leaderboard   service.LeaderboardStore
+	Categories    service.CategoryStore
+	Nicknames     NicknameStore
+	JobRuns       JobRunStore
+	JobLocker     scheduler.Locker
+	SQLDB         *sql.DB
+}
+
+// NewStorage opens the storage selected by cfg.Storage.Driver.
+func NewStorage(ctx context.Context, cfg config.Config, log *slog.Logger) (*Storage, error) {
+	switch cfg.Storage.Driver {
+	case config.StorageDriverMemory:
+		repo := memory.NewUserRepo()
+		return &Storage{
+			Store:       repo,
+			Tx:          memory.NewTxManager(repo),
+			Counter:     repo,
+			Milestones:  memory.NewMilestoneRepo(),
+			Quarantine:  memory.NewQuarantineRepo(repo),
+			Leaderboard: memory.NewLeaderboardRepo(repo),
+			Categories:  memory.NewCategoryRepo(repo),
+			Nicknames:   memory.NewNicknameRepo(repo),
+			JobRuns:     memory.NewJobRunRepo(),
+		}, nil
+	case config.StorageDriverPostgres:
+		schemaVersion, err := migrate.LatestVersion(migrations.FS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		pool, err := db.NewDb(ctx, cfg.Database)
+		if err != nil {
+			return nil, err
+		}
+		if _, err := postgres.ParseIsoLevel(cfg.Database.TxIsolation); err != nil {
+			pool.Close()
+			return nil, err
+		}
+		var repoOpts []postgres.UserRepoOption
+		var webhooks *postgres.WebhookRepo
+		if cfg.Webhooks.Enabled {
+			webhooks = postgres.NewWebhookRepo(pool)
+			repoOpts = append(repoOpts, postgres.WithWebhookOutbox())
+		}
+		var replica *postgres.Replica
+		if cfg.Database.ReplicaURL != "" {
+			replicaPool, err := db.NewPool(ctx, cfg.Database.ReplicaURL, cfg.Database)
+			if err != nil {
+				pool.Close()
+				return nil, fmt.Errorf("failed to create replica pool: %w", err)
+			}
+			replica = postgres.NewReplica(replicaPool, cfg.Database.ReplicaMaxLag, cfg.Database.ReplicaCheckInterval, log)
+			replica.Start(context.Background())
+			repoOpts = append(repoOpts, postgres.WithReplica(replica))
+		}
+		var countCache *postgres.CountCache
+		if cfg.Pagination.CountCacheRefresh > 0 {
+			countCache = postgres.NewCountCache(postgres.NewUserRepo(pool, repoOpts...), cfg.Pagination.CountCacheRefresh, log)
+			countCache.Start(context.Background())
+			repoOpts = append(repoOpts, postgres.WithCountCache(countCache))
+		}
+		repo := postgres.NewUserRepo(pool, repoOpts...)
+		tx, err := postgres.NewTxManager(pool, cfg.Database.TxIsolation, cfg.Database.TxMaxRetries, repoOpts...)
+		if err != nil {
+			pool.Close()
+			return nil, err
+		}
+		var events EventOutbox
+		if cfg.Events.Enabled {
+			events = postgres.NewEventOutbox(pool)
+		}
+		return &Storage{
+			Store:         repo,
+			Tx:            tx,
+			Counter:       repo,
+			Checker:       db.NewHealth(pool),
+			SchemaVersion: schemaVersion,
+			Pool:          pool,
+			Replica:       replica,
+			CountCache:    countCache,
+			Listener:      postgres.NewListener(pool, log),
+			Webhooks:      webhooks,
+			Events:        events,
+			Milestones:    postgres.NewMilestoneRepo(pool),
+			Quarantine:    postgres.NewQuarantineRepo(pool),
+			Leaderboard:   postgres.NewLeaderboardRepo(pool),
+			Categories:    postgres.NewCategoryRepo(pool),
+			Nicknames:     postgres.NewNicknameRepo(pool),
+			JobRuns:       postgres.NewJobRunRepo(pool),
+			JobLocker:     postgres.NewAdvisoryLocker(pool, "rating:job:", log),
+		}, nil
+	case config.StorageDriverSQLite:
+		schemaVersion, err := migrate.LatestVersion(sqlitemigrations.FS)
+		if err != nil {
+			return nil, fmt.Errorf("failed to read embedded migrations: %w", err)
+		}
+		sqlDB, err := db.NewSQLite(ctx, cfg.Storage.SQLitePath)
+		if err != nil {
+			return nil, err
+		}
+		repo := sqlite.NewUserRepo(sqlDB)
+		var events EventOutbox
+		if cfg.Events.Enabled {
+			events = sqlite.NewEventOutbox(sqlDB)
+		}
+		return &Storage{
+			Store:         repo,
+			Tx:            sqlite.NewTxManager(sqlDB, cfg.Database.TxMaxRetries),
+			Counter:       repo,
+			Checker:       db.NewSQLiteHealth(sqlDB),
+			SchemaVersion: schemaVersion,
+			SQLDB:         sqlDB,
+			Events:        events,
+			Milestones:    sqlite.NewMilestoneRepo(sqlDB),
+			Quarantine:    sqlite.NewQuarantineRepo(sqlDB),
+			Leaderboard:   sqlite.NewLeaderboardRepo(sqlDB),
+			Categories:    sqlite.NewCategoryRepo(sqlDB),
+			Nicknames:     sqlite.NewNicknameRepo(sqlDB),
+			JobRuns:       sqlite.NewJobRunRepo(sqlDB),
+		}, nil
+	default:
+		return nil, fmt.Errorf("unknown storage driver %q", cfg.Storage.Driver)
+	}
+}
+
+func (s *Storage) Migrator() (*migrate.Runner, error) {
+	switch {
+	case s.Pool != nil:
+		return migrate.NewRunner(s.Pool)
+	case s.SQLDB != nil:
+		return migrate.NewSQLiteRunner(s.SQLDB)
+	default:
+		return nil, errors.New("migrations are not supported by the memory storage driver")
+	}
+}
+
+func (s *Storage) Close() {
+	if s.Listener != nil {
+		s.Listener.Stop()
+	}
+	if s.CountCache != nil {
+		s.CountCache.Stop()
+	}
+	if s.Replica != nil {
+		s.Replica.Stop()
+		s.Replica.Pool().Close()
+	}
+	if s.Pool != nil {
+		s.Pool.Close()
+	}
+	if s.SQLDB != nil {
+		s.SQLDB.Close()
+	}
+}
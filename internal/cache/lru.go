@@ -0,0 +1,94 @@
+package cache
+
+import (
+	"container/list"
+	"time"
+)
+
+type entry[V any] struct {
+	key       string
+	value     V
+	expiresAt time.Time
+}
+
+// lru is a size bounded LRU with per-entry expiry. It is not safe for
+// concurrent use, callers hold their own lock.
+type lru[V any] struct {
+	size  int
+	ttl   time.Duration
+	now   func() time.Time
+	order *list.List
+	items map[string]*list.Element
+}
+
+func newLRU[V any](size int, ttl time.Duration, now func() time.Time) *lru[V] {
+	return &lru[V]{
+		size:  size,
+		ttl:   ttl,
+		now:   now,
+		order: list.New(),
+		items: make(map[string]*list.Element, size),
+	}
+}
+
+func (l *lru[V]) get(key string) (V, bool) {
+	var zero V
+
+	el, ok := l.items[key]
+	if !ok {
+		return zero, false
+	}
+
+	e := el.Value.(*entry[V])
+	if l.now().After(e.expiresAt) {
+		l.removeElement(el)
+		return zero, false
+	}
+
+	l.order.MoveToFront(el)
+	return e.value, true
+}
+
+// add stores value and reports whether another entry was evicted.
+func (l *lru[V]) add(key string, value V) bool {
+	expiresAt := l.now().Add(l.ttl)
+
+	if el, ok := l.items[key]; ok {
+		e := el.Value.(*entry[V])
+		e.value = value
+		e.expiresAt = expiresAt
+		l.order.MoveToFront(el)
+		return false
+	}
+
+	l.items[key] = l.order.PushFront(&entry[V]{key: key, value: value, expiresAt: expiresAt})
+	if l.order.Len() <= l.size {
+		return false
+	}
+
+	l.removeElement(l.order.Back())
+	return true
+}
+
+func (l *lru[V]) remove(key string) {
+	if el, ok := l.items[key]; ok {
+		l.removeElement(el)
+	}
+}
+
+func (l *lru[V]) keys() []string {
+	keys := make([]string, 0, len(l.items))
+	for key := range l.items {
+		keys = append(keys, key)
+	}
+	return keys
+}
+
+func (l *lru[V]) len() int {
+	return l.order.Len()
+}
+
+func (l *lru[V]) removeElement(el *list.Element) {
+	l.order.Remove(el)
+	delete(l.items, el.Value.(*entry[V]).key)
+}
@@ -0,0 +1,219 @@
+package cache
+
+import (
+	"context"
+	"rating/internal/db"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"golang.org/x/sync/singleflight"
+)
+
+type Stats struct {
+	Hits      uint64
+	Misses    uint64
+	Evictions uint64
+	Size      int
+}
+
+// UserStore is a read-through cache for user lookups by tenant and nickname key
+// in front of another service.UserStore. Writes go straight to the wrapped
+// store and invalidate the affected nicknames.
+type UserStore struct {
+	next service.UserStore
+
+	mu    sync.Mutex
+	users *lru[model.User]
+	// generation changes on every invalidation, loads started before it are not cached
+	generation uint64
+
+	group singleflight.Group
+
+	hits      atomic.Uint64
+	misses    atomic.Uint64
+	evictions atomic.Uint64
+}
+
+func NewUserStore(next service.UserStore, size int, ttl time.Duration) *UserStore {
+	return &UserStore{
+		next:  next,
+		users: newLRU[model.User](size, ttl, time.Now),
+	}
+}
+
+func (c *UserStore) Stats() Stats {
+	c.mu.Lock()
+	size := c.users.len()
+	c.mu.Unlock()
+
+	return Stats{
+		Hits:      c.hits.Load(),
+		Misses:    c.misses.Load(),
+		Evictions: c.evictions.Load(),
+		Size:      size,
+	}
+}
+
+// key folds nickname like the stores do, so every spelling of a nickname
+// shares one entry and is invalidated together.
+func key(tenant, nickname string) string {
+	return tenant + "\x00" + model.NicknameKey(nickname)
+}
+
+// Invalidate drops the cached nicknames of tenant.
+func (c *UserStore) Invalidate(tenant string, nicknames ...string) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, nickname := range nicknames {
+		c.users.remove(key(tenant, nickname))
+		c.group.Forget(key(tenant, nickname))
+	}
+}
+
+// Purge drops every cached user, for when changes may have been missed.
+func (c *UserStore) Purge() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	c.generation++
+	for _, key := range c.users.keys() {
+		c.users.remove(key)
+		c.group.Forget(key)
+	}
+}
+
+func (c *UserStore) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	key := key(tenant.ID(ctx), nickname)
+
+	c.mu.Lock()
+	user, ok := c.users.get(key)
+	generation := c.generation
+	c.mu.Unlock()
+
+	if ok {
+		c.hits.Add(1)
+		return &user, nil
+	}
+	c.misses.Add(1)
+
+	v, err, _ := c.group.Do(key, func() (any, error) {
+		// detached from the caller so one canceled request does not fail the others waiting on it,
+		// and read from the primary so a lagging replica cannot cache a stale user past its invalidation
+		user, err := c.next.GetUser(db.ForcePrimary(context.WithoutCancel(ctx)), nickname)
+		if err != nil {
+			return nil, err
+		}
+
+		c.mu.Lock()
+		if c.generation == generation {
+			if c.users.add(key, *user) {
+				c.evictions.Add(1)
+			}
+		}
+		c.mu.Unlock()
+
+		return *user, nil
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	loaded := v.(model.User)
+	return &loaded, nil
+}
+
+// GetUserForUpdate bypasses the cache, the lock must be taken by the wrapped
+// store.
+func (c *UserStore) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	return c.next.GetUserForUpdate(ctx, nickname)
+}
+
+func (c *UserStore) Create(ctx context.Context, user model.User) error {
+	err := c.next.Create(ctx, user)
+	c.Invalidate(tenant.ID(ctx), user.NickName)
+	return err
+}
+
+func (c *UserStore) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
+	return c.next.GetAll(ctx, params)
+}
+
+func (c *UserStore) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	err := c.next.ChangeData(ctx, nickname, dto)
+	c.Invalidate(tenant.ID(ctx), changedNicknames(nickname, dto)...)
+	return err
+}
+
+func (c *UserStore) Delete(ctx context.Context, nickname string) error {
+	err := c.next.Delete(ctx, nickname)
+	c.Invalidate(tenant.ID(ctx), nickname)
+	return err
+}
+
+func changedNicknames(nickname string, dto request.UpdateUserDTO) []string {
+	if dto.Nickname != nil && *dto.Nickname != nickname {
+		return []string{nickname, *dto.Nickname}
+	}
+
+	return []string{nickname}
+}
+
+// TxManager wraps another service.TxManager so writes made inside a
+// transaction invalidate the cache once the transaction has finished.
+type TxManager struct {
+	next  service.TxManager
+	cache *UserStore
+}
+
+func NewTxManager(next service.TxManager, cache *UserStore) *TxManager {
+	return &TxManager{
+		next:  next,
+		cache: cache,
+	}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	tracked := &trackingStore{}
+
+	err := m.next.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+		tracked.UserStore = store
+		return fn(ctx, tracked)
+	})
+	m.cache.Invalidate(tenant.ID(ctx), tracked.nicknames...)
+
+	return err
+}
+
+type trackingStore struct {
+	service.UserStore
+	mu        sync.Mutex
+	nicknames []string
+}
+
+func (s *trackingStore) track(nicknames ...string) {
+	s.mu.Lock()
+	s.nicknames = append(s.nicknames, nicknames...)
+	s.mu.Unlock()
+}
+
+func (s *trackingStore) Create(ctx context.Context, user model.User) error {
+	s.track(user.NickName)
+	return s.UserStore.Create(ctx, user)
+}
+
+func (s *trackingStore) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	s.track(changedNicknames(nickname, dto)...)
+	return s.UserStore.ChangeData(ctx, nickname, dto)
+}
+
+func (s *trackingStore) Delete(ctx context.Context, nickname string) error {
+	s.track(nickname)
+	return s.UserStore.Delete(ctx, nickname)
+}
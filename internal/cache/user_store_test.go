@@ -0,0 +1,282 @@
+package cache
+
+import (
+	"context"
+	"errors"
+	"rating/internal/db"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/repo/memory"
+	"rating/internal/repo/storetest"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type countingStore struct {
+	service.UserStore
+	gets    atomic.Int32
+	replica atomic.Int32
+	release chan struct{}
+}
+
+func (s *countingStore) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	s.gets.Add(1)
+	if !db.PrimaryForced(ctx) {
+		s.replica.Add(1)
+	}
+	if s.release != nil {
+		<-s.release
+	}
+	return s.UserStore.GetUser(ctx, nickname)
+}
+
+func newTestStore(t *testing.T, size int) (*UserStore, *countingStore) {
+	repo := memory.NewUserRepo()
+	require.NoError(t, repo.Create(context.Background(), *model.NewUser("name", "nickname", 1, 2)))
+	next := &countingStore{UserStore: repo}
+	return NewUserStore(next, size, time.Minute), next
+}
+
+func ptrString(s string) *string { return &s }
+func ptrInt(i int) *int          { return &i }
+
+func TestUserStore_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) service.UserStore {
+		return NewUserStore(memory.NewUserRepo(), 100, time.Minute)
+	})
+}
+
+func TestUserStore_ReadThrough(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestStore(t, 10)
+
+	for range 3 {
+		user, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, "name", user.Name)
+	}
+
+	require.Equal(t, int32(1), next.gets.Load())
+	require.Zero(t, next.replica.Load(), "cache loads must read from the primary")
+	require.Equal(t, Stats{Hits: 2, Misses: 1, Size: 1}, c.Stats())
+
+	_, err := c.GetUser(ctx, "missing")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	require.Equal(t, 1, c.Stats().Size)
+
+	// every spelling of the nickname shares the entry
+	_, err = c.GetUser(ctx, "NickName")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), next.gets.Load())
+	require.Equal(t, uint64(3), c.Stats().Hits)
+}
+
+func TestUserStore_ReturnsCopies(t *testing.T) {
+	ctx := context.Background()
+	c, _ := newTestStore(t, 10)
+
+	user, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	user.Name = "mutated"
+
+	user, err = c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "name", user.Name)
+}
+
+func TestUserStore_TTL(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestStore(t, 10)
+
+	now := time.Now()
+	c.users.now = func() time.Time { return now }
+
+	_, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+
+	now = now.Add(2 * time.Minute)
+	_, err = c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+
+	require.Equal(t, int32(2), next.gets.Load())
+}
+
+func TestUserStore_Eviction(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestStore(t, 1)
+	require.NoError(t, c.Create(ctx, *model.NewUser("other", "other", 1, 2)))
+
+	_, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	_, err = c.GetUser(ctx, "other")
+	require.NoError(t, err)
+	_, err = c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+
+	require.Equal(t, int32(3), next.gets.Load())
+	require.Equal(t, uint64(2), c.Stats().Evictions)
+	require.Equal(t, 1, c.Stats().Size)
+}
+
+func TestUserStore_Invalidation(t *testing.T) {
+	ctx := context.Background()
+
+	t.Run("change data", func(t *testing.T) {
+		c, _ := newTestStore(t, 10)
+		_, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+
+		require.NoError(t, c.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)}))
+
+		user, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 2, user.Likes)
+	})
+
+	t.Run("change data by another case", func(t *testing.T) {
+		c, _ := newTestStore(t, 10)
+		_, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+
+		require.NoError(t, c.ChangeData(ctx, "NICKNAME", request.UpdateUserDTO{Likes: ptrInt(2)}))
+
+		user, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 2, user.Likes)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		c, _ := newTestStore(t, 10)
+		_, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		_, err = c.GetUser(ctx, "renamed")
+		require.ErrorIs(t, err, model.ErrNotFound)
+
+		require.NoError(t, c.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+
+		_, err = c.GetUser(ctx, "nickname")
+		require.ErrorIs(t, err, model.ErrNotFound)
+		_, err = c.GetUser(ctx, "renamed")
+		require.NoError(t, err)
+	})
+
+	t.Run("delete", func(t *testing.T) {
+		c, _ := newTestStore(t, 10)
+		_, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+
+		require.NoError(t, c.Delete(ctx, "nickname"))
+
+		_, err = c.GetUser(ctx, "nickname")
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("transaction", func(t *testing.T) {
+		repo := memory.NewUserRepo()
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+		c := NewUserStore(repo, 10, time.Minute)
+		tm := NewTxManager(memory.NewTxManager(repo), c)
+
+		_, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+
+		err = tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+			return store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)})
+		})
+		require.NoError(t, err)
+
+		user, err := c.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 2, user.Likes)
+	})
+
+	t.Run("stale load is not cached", func(t *testing.T) {
+		c, next := newTestStore(t, 10)
+		next.release = make(chan struct{})
+
+		done := make(chan error)
+		go func() {
+			_, err := c.GetUser(ctx, "nickname")
+			done <- err
+		}()
+		require.Eventually(t, func() bool { return next.gets.Load() == 1 }, time.Second, time.Millisecond)
+
+		c.Invalidate(tenant.Default, "nickname")
+		close(next.release)
+		require.NoError(t, <-done)
+		require.Equal(t, 0, c.Stats().Size)
+	})
+}
+
+func TestUserStore_Singleflight(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestStore(t, 10)
+	next.release = make(chan struct{})
+
+	const callers = 8
+	var wg sync.WaitGroup
+	errs := make(chan error, callers)
+	for range callers {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			_, err := c.GetUser(ctx, "nickname")
+			errs <- err
+		}()
+	}
+	require.Eventually(t, func() bool { return c.Stats().Misses == callers }, time.Second, time.Millisecond)
+	close(next.release)
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		require.NoError(t, err)
+	}
+	require.Equal(t, int32(1), next.gets.Load())
+}
+
+func TestTxManager_InvalidatesOnRollback(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepo()
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+	next := &countingStore{UserStore: repo}
+	c := NewUserStore(next, 10, time.Minute)
+	tm := NewTxManager(memory.NewTxManager(repo), c)
+
+	_, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+
+	rollbackErr := errors.New("rollback")
+	err = tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+		require.NoError(t, store.Delete(ctx, "nickname"))
+		return rollbackErr
+	})
+	require.ErrorIs(t, err, rollbackErr)
+
+	user, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "name", user.Name)
+	require.Equal(t, int32(2), next.gets.Load())
+}
+
+func TestUserStore_Purge(t *testing.T) {
+	ctx := context.Background()
+	c, next := newTestStore(t, 10)
+
+	_, err := c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, 1, c.Stats().Size)
+
+	c.Purge()
+	require.Equal(t, 0, c.Stats().Size)
+
+	_, err = c.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, int32(2), next.gets.Load())
+}
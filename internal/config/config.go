@@ -0,0 +1,902 @@
+// Package config loads service settings. Values are resolved in this order,
+// each step overriding the previous one: built-in defaults, the optional
+// YAML/TOML config file, the .env file and finally the process environment.
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/url"
+	"os"
+	"path/filepath"
+	"rating/internal/model"
+	"rating/internal/scheduler"
+	"rating/internal/tenant"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/BurntSushi/toml"
+	"github.com/joho/godotenv"
+	"gopkg.in/yaml.v3"
+)
+
+type Config struct {
+	HTTP        HTTP        `yaml:"http" toml:"http" json:"http"`
+	Database    Database    `yaml:"database" toml:"database" json:"database"`
+	Pagination  Pagination  `yaml:"pagination" toml:"pagination" json:"pagination"`
+	Log         Log         `yaml:"log" toml:"log" json:"log"`
+	Tracing     Tracing     `yaml:"tracing" toml:"tracing" json:"tracing"`
+	Storage     Storage     `yaml:"storage" toml:"storage" json:"storage"`
+	Cache       Cache       `yaml:"cache" toml:"cache" json:"cache"`
+	Stream      Stream      `yaml:"stream" toml:"stream" json:"stream"`
+	Websocket   Websocket   `yaml:"websocket" toml:"websocket" json:"websocket"`
+	Webhooks    Webhooks    `yaml:"webhooks" toml:"webhooks" json:"webhooks"`
+	Events      Events      `yaml:"events" toml:"events" json:"events"`
+	Milestones  Milestones  `yaml:"milestones" toml:"milestones" json:"milestones"`
+	Fraud       Fraud       `yaml:"fraud" toml:"fraud" json:"fraud"`
+	Leaderboard Leaderboard `yaml:"leaderboard" toml:"leaderboard" json:"leaderboard"`
+	Scheduler   Scheduler   `yaml:"scheduler" toml:"scheduler" json:"scheduler"`
+	Tenancy     Tenancy     `yaml:"tenancy" toml:"tenancy" json:"tenancy"`
+	Nicknames   Nicknames   `yaml:"nicknames" toml:"nicknames" json:"nicknames"`
+	Operator    Operator    `yaml:"operator" toml:"operator" json:"operator"`
+}
+
+type HTTP struct {
+	Addr               string        `yaml:"addr" toml:"addr" json:"addr"`
+	ReadTimeout        time.Duration `yaml:"read_timeout" toml:"read_timeout" json:"read_timeout"`
+	WriteTimeout       time.Duration `yaml:"write_timeout" toml:"write_timeout" json:"write_timeout"`
+	IdleTimeout        time.Duration `yaml:"idle_timeout" toml:"idle_timeout" json:"idle_timeout"`
+	ShutdownTimeout    time.Duration `yaml:"shutdown_timeout" toml:"shutdown_timeout" json:"shutdown_timeout"`
+	ShutdownDrainDelay time.Duration `yaml:"shutdown_drain_delay" toml:"shutdown_drain_delay" json:"shutdown_drain_delay"`
+}
+
+type Database struct {
+	URL             string        `yaml:"url" toml:"url" json:"url"`
+	MaxConns        int32         `yaml:"max_conns" toml:"max_conns" json:"max_conns"`
+	MinConns        int32         `yaml:"min_conns" toml:"min_conns" json:"min_conns"`
+	MaxConnLifetime time.Duration `yaml:"max_conn_lifetime" toml:"max_conn_lifetime" json:"max_conn_lifetime"`
+	MaxConnIdleTime time.Duration `yaml:"max_conn_idle_time" toml:"max_conn_idle_time" json:"max_conn_idle_time"`
+	TxIsolation     string        `yaml:"tx_isolation" toml:"tx_isolation" json:"tx_isolation"`
+	TxMaxRetries    int           `yaml:"tx_max_retries" toml:"tx_max_retries" json:"tx_max_retries"`
+
+	ReplicaURL           string        `yaml:"replica_url" toml:"replica_url" json:"replica_url"`
+	ReplicaMaxLag        time.Duration `yaml:"replica_max_lag" toml:"replica_max_lag" json:"replica_max_lag"`
+	ReplicaCheckInterval time.Duration `yaml:"replica_check_interval" toml:"replica_check_interval" json:"replica_check_interval"`
+
+	// RowLevelSecurity binds the tenant of each request to the app.tenant_id
+	// setting of the connections it uses, so the row-level security policies
+	// hold every query to its tenant. A connection that binds no tenant sees
+	// no rows; cross-tenant work sets app.all_tenants instead. The policies
+	// are forced on the table owner too; only superusers and BYPASSRLS roles
+	// skip them.
+	RowLevelSecurity bool `yaml:"row_level_security" toml:"row_level_security" json:"row_level_security"`
+}
+
+type Pagination struct {
+	DefaultPageSize   int           `yaml:"default_page_size" toml:"default_page_size" json:"default_page_size"`
+	MaxPageSize       int           `yaml:"max_page_size" toml:"max_page_size" json:"max_page_size"`
+	CountMode         string        `yaml:"count_mode" toml:"count_mode" json:"count_mode"`
+	CountCacheRefresh time.Duration `yaml:"count_cache_refresh" toml:"count_cache_refresh" json:"count_cache_refresh"`
+}
+
+type Log struct {
+	Level  string `yaml:"level" toml:"level" json:"level"`
+	Format string `yaml:"format" toml:"format" json:"format"`
+}
+
+type Tracing struct {
+	Exporter string `yaml:"exporter" toml:"exporter" json:"exporter"`
+}
+
+const (
+	StorageDriverPostgres = "postgres"
+	StorageDriverMemory   = "memory"
+	StorageDriverSQLite   = "sqlite"
+)
+
+const (
+	EventPublisherLog  = "log"
+	EventPublisherFile = "file"
+)
+
+type Cache struct {
+	Enabled bool          `yaml:"enabled" toml:"enabled" json:"enabled"`
+	Size    int           `yaml:"size" toml:"size" json:"size"`
+	TTL     time.Duration `yaml:"ttl" toml:"ttl" json:"ttl"`
+}
+
+// Stream configures the event broker. ReplayTTL is how long the replay
+// buffer of a tenant without subscribers is kept after its newest event.
+type Stream struct {
+	Heartbeat    time.Duration `yaml:"heartbeat" toml:"heartbeat" json:"heartbeat"`
+	ReplayBuffer int           `yaml:"replay_buffer" toml:"replay_buffer" json:"replay_buffer"`
+	ReplayTTL    time.Duration `yaml:"replay_ttl" toml:"replay_ttl" json:"replay_ttl"`
+	ClientBuffer int           `yaml:"client_buffer" toml:"client_buffer" json:"client_buffer"`
+	TopN         int           `yaml:"top_n" toml:"top_n" json:"top_n"`
+}
+
+type Websocket struct {
+	// AuthTokens are the bearer tokens accepted on upgrade, empty disables auth.
+	AuthTokens       []string `yaml:"auth_tokens" toml:"auth_tokens" json:"auth_tokens"`
+	MaxSubscriptions int      `yaml:"max_subscriptions" toml:"max_subscriptions" json:"max_subscriptions"`
+}
+
+// Webhooks controls the dispatcher. Retention keeps processed outbox events
+// and their finished deliveries, zero keeps them forever. Receivers on
+// loopback, link-local and private addresses are refused unless
+// AllowPrivateTargets is set.
+type Webhooks struct {
+	Enabled      bool          `yaml:"enabled" toml:"enabled" json:"enabled"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" json:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" json:"batch_size"`
+	Workers      int           `yaml:"workers" toml:"workers" json:"workers"`
+	MaxAttempts  int           `yaml:"max_attempts" toml:"max_attempts" json:"max_attempts"`
+	Timeout      time.Duration `yaml:"timeout" toml:"timeout" json:"timeout"`
+	BackoffBase  time.Duration `yaml:"backoff_base" toml:"backoff_base" json:"backoff_base"`
+	BackoffMax   time.Duration `yaml:"backoff_max" toml:"backoff_max" json:"backoff_max"`
+	Retention    time.Duration `yaml:"retention" toml:"retention" json:"retention"`
+
+	AllowPrivateTargets bool `yaml:"allow_private_targets" toml:"allow_private_targets" json:"allow_private_targets"`
+}
+
+type Events struct {
+	Enabled      bool          `yaml:"enabled" toml:"enabled" json:"enabled"`
+	Publisher    string        `yaml:"publisher" toml:"publisher" json:"publisher"`
+	FilePath     string        `yaml:"file_path" toml:"file_path" json:"file_path"`
+	PollInterval time.Duration `yaml:"poll_interval" toml:"poll_interval" json:"poll_interval"`
+	BatchSize    int           `yaml:"batch_size" toml:"batch_size" json:"batch_size"`
+	Retention    time.Duration `yaml:"retention" toml:"retention" json:"retention"`
+}
+
+// Milestones lists rules written as "metric>=threshold" over likes, viewers
+// or rating, for example "viewers>=1000".
+type Milestones struct {
+	Rules []string `yaml:"rules" toml:"rules" json:"rules"`
+}
+
+type Fraud struct {
+	Enabled         bool          `yaml:"enabled" toml:"enabled" json:"enabled"`
+	JumpMultiple    float64       `yaml:"jump_multiple" toml:"jump_multiple" json:"jump_multiple"`
+	JumpMinDelta    int           `yaml:"jump_min_delta" toml:"jump_min_delta" json:"jump_min_delta"`
+	RatioThreshold  float64       `yaml:"ratio_threshold" toml:"ratio_threshold" json:"ratio_threshold"`
+	RatioMinViewers int           `yaml:"ratio_min_viewers" toml:"ratio_min_viewers" json:"ratio_min_viewers"`
+	BurstLimit      int           `yaml:"burst_limit" toml:"burst_limit" json:"burst_limit"`
+	BurstWindow     time.Duration `yaml:"burst_window" toml:"burst_window" json:"burst_window"`
+
+	// ModeratorTokens are the bearer tokens accepted by the moderation
+	// routes, at least one is required when the fraud check is enabled.
+	ModeratorTokens []string `yaml:"moderator_tokens" toml:"moderator_tokens" json:"moderator_tokens"`
+}
+
+// Leaderboard controls rank snapshots, taken by the scheduler. An empty
+// SnapshotSchedule disables them and a zero SnapshotKeep keeps every
+// snapshot.
+type Leaderboard struct {
+	SnapshotSchedule string `yaml:"snapshot_schedule" toml:"snapshot_schedule" json:"snapshot_schedule"`
+	SnapshotKeep     int    `yaml:"snapshot_keep" toml:"snapshot_keep" json:"snapshot_keep"`
+}
+
+type Scheduler struct {
+	Enabled          bool          `yaml:"enabled" toml:"enabled" json:"enabled"`
+	HistoryRetention time.Duration `yaml:"history_retention" toml:"history_retention" json:"history_retention"`
+}
+
+// Tenancy resolves the tenant each request is scoped to. APIKeys are written
+// as "key=tenant"; when any are set a key is required and Header is ignored.
+// Without keys the tenant is read from Header, an empty Header or value
+// selects the default tenant.
+type Tenancy struct {
+	APIKeys []string `yaml:"api_keys" toml:"api_keys" json:"api_keys"`
+	Header  string   `yaml:"header" toml:"header" json:"header"`
+}
+
+// Nicknames controls nicknames released by a rename. Other users cannot take
+// one until Cooldown has passed. Looking a user up by a released nickname
+// redirects to the current one, or answers directly when Redirect is off.
+//
+// New nicknames must be MinLength to MaxLength characters long, a zero
+// MaxLength leaving them unbounded, and made of Charset, the body of a regexp
+// character class; an empty Charset allows any character. Reserved nicknames
+// are matched ignoring case.
+type Nicknames struct {
+	Cooldown  time.Duration `yaml:"cooldown" toml:"cooldown" json:"cooldown"`
+	Redirect  bool          `yaml:"redirect" toml:"redirect" json:"redirect"`
+	MinLength int           `yaml:"min_length" toml:"min_length" json:"min_length"`
+	MaxLength int           `yaml:"max_length" toml:"max_length" json:"max_length"`
+	Charset   string        `yaml:"charset" toml:"charset" json:"charset"`
+	Reserved  []string      `yaml:"reserved" toml:"reserved" json:"reserved"`
+}
+
+// Operator guards the operational routes, webhook subscriptions and the job
+// run history, with bearer tokens. At least one is required when webhooks are
+// enabled; without any the job run history is not served.
+type Operator struct {
+	Tokens []string `yaml:"tokens" toml:"tokens" json:"tokens"`
+}
+
+type Storage struct {
+	Driver     string `yaml:"driver" toml:"driver" json:"driver"`
+	SQLitePath string `yaml:"sqlite_path" toml:"sqlite_path" json:"sqlite_path"`
+}
+
+func Default() Config {
+	return Config{
+		HTTP: HTTP{
+			Addr:               ":8080",
+			ReadTimeout:        5 * time.Second,
+			WriteTimeout:       10 * time.Second,
+			IdleTimeout:        10 * time.Second,
+			ShutdownTimeout:    5 * time.Second,
+			ShutdownDrainDelay: 5 * time.Second,
+		},
+		Database: Database{
+			MaxConns:        10,
+			MinConns:        0,
+			MaxConnLifetime: time.Hour,
+			MaxConnIdleTime: 30 * time.Minute,
+			TxIsolation:     "read committed",
+			TxMaxRetries:    3,
+
+			ReplicaMaxLag:        5 * time.Second,
+			ReplicaCheckInterval: 2 * time.Second,
+		},
+		Pagination: Pagination{
+			DefaultPageSize:   10,
+			MaxPageSize:       100,
+			CountMode:         "exact",
+			CountCacheRefresh: 30 * time.Second,
+		},
+		Tracing: Tracing{
+			Exporter: "none",
+		},
+		Storage: Storage{
+			Driver:     StorageDriverPostgres,
+			SQLitePath: "rating.db",
+		},
+		Cache: Cache{
+			Size: 10000,
+			TTL:  time.Minute,
+		},
+		Stream: Stream{
+			Heartbeat:    15 * time.Second,
+			ReplayBuffer: 1000,
+			ReplayTTL:    10 * time.Minute,
+			ClientBuffer: 64,
+			TopN:         10,
+		},
+		Websocket: Websocket{
+			MaxSubscriptions: 100,
+		},
+		Webhooks: Webhooks{
+			PollInterval: time.Second,
+			BatchSize:    100,
+			Workers:      8,
+			MaxAttempts:  10,
+			Timeout:      10 * time.Second,
+			BackoffBase:  5 * time.Second,
+			BackoffMax:   time.Hour,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Events: Events{
+			Publisher:    EventPublisherLog,
+			FilePath:     "events.ndjson",
+			PollInterval: time.Second,
+			BatchSize:    100,
+			Retention:    7 * 24 * time.Hour,
+		},
+		Milestones: Milestones{
+			Rules: []string{"viewers>=1000", "rating>=0.9"},
+		},
+		Fraud: Fraud{
+			JumpMultiple:    10,
+			JumpMinDelta:    100,
+			RatioThreshold:  0.98,
+			RatioMinViewers: 1000,
+			BurstLimit:      10,
+			BurstWindow:     time.Minute,
+		},
+		Leaderboard: Leaderboard{
+			SnapshotSchedule: "@daily",
+			SnapshotKeep:     30,
+		},
+		Scheduler: Scheduler{
+			Enabled:          true,
+			HistoryRetention: 30 * 24 * time.Hour,
+		},
+		Tenancy: Tenancy{
+			Header: "X-Tenant-ID",
+		},
+		Nicknames: Nicknames{
+			Cooldown:  30 * 24 * time.Hour,
+			Redirect:  true,
+			MinLength: 3,
+			MaxLength: 32,
+			Charset:   `\p{L}\p{N}_.-`,
+			Reserved:  []string{"admin", "root", "system", "me", "stream", "watch"},
+		},
+	}
+}
+
+// Load builds the configuration from defaults, the file at path (if not
+// empty), the .env file and the environment, then validates the result.
+func Load(path string) (Config, error) {
+	cfg := Default()
+
+	if path != "" {
+		if err := loadFile(path, &cfg); err != nil {
+			return Config{}, err
+		}
+	}
+
+	if err := godotenv.Load(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return Config{}, fmt.Errorf("failed to load .env file: %w", err)
+	}
+
+	if err := loadEnv(&cfg); err != nil {
+		return Config{}, err
+	}
+
+	if err := cfg.Validate(); err != nil {
+		return Config{}, err
+	}
+
+	return cfg, nil
+}
+
+func loadFile(path string, cfg *Config) error {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return fmt.Errorf("failed to read config file: %w", err)
+	}
+
+	switch strings.ToLower(filepath.Ext(path)) {
+	case ".yaml", ".yml":
+		if err := yaml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse yaml config: %w", err)
+		}
+	case ".toml":
+		if err := toml.Unmarshal(data, cfg); err != nil {
+			return fmt.Errorf("failed to parse toml config: %w", err)
+		}
+	default:
+		return fmt.Errorf("unsupported config file extension %q", filepath.Ext(path))
+	}
+
+	return nil
+}
+
+type envLoader struct {
+	errs []error
+}
+
+func (l *envLoader) string(dst *string, key string) {
+	if v, ok := os.LookupEnv(key); ok && v != "" {
+		*dst = v
+	}
+}
+
+func (l *envLoader) list(dst *[]string, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+
+	var items []string
+	for item := range strings.SplitSeq(v, ",") {
+		if item = strings.TrimSpace(item); item != "" {
+			items = append(items, item)
+		}
+	}
+	*dst = items
+}
+
+func (l *envLoader) bool(dst *bool, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	b, err := strconv.ParseBool(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid boolean %q", key, v))
+		return
+	}
+	*dst = b
+}
+
+func (l *envLoader) int(dst *int, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.Atoi(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return
+	}
+	*dst = n
+}
+
+func (l *envLoader) int32(dst *int32, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	n, err := strconv.ParseInt(v, 10, 32)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid integer %q", key, v))
+		return
+	}
+	*dst = int32(n)
+}
+
+func (l *envLoader) float(dst *float64, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	f, err := strconv.ParseFloat(v, 64)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid number %q", key, v))
+		return
+	}
+	*dst = f
+}
+
+func (l *envLoader) duration(dst *time.Duration, key string) {
+	v, ok := os.LookupEnv(key)
+	if !ok || v == "" {
+		return
+	}
+	d, err := time.ParseDuration(v)
+	if err != nil {
+		l.errs = append(l.errs, fmt.Errorf("%s: invalid duration %q", key, v))
+		return
+	}
+	*dst = d
+}
+
+func loadEnv(cfg *Config) error {
+	l := &envLoader{}
+
+	l.string(&cfg.HTTP.Addr, "SERVER_ADDR")
+	l.duration(&cfg.HTTP.ReadTimeout, "HTTP_READ_TIMEOUT")
+	l.duration(&cfg.HTTP.WriteTimeout, "HTTP_WRITE_TIMEOUT")
+	l.duration(&cfg.HTTP.IdleTimeout, "HTTP_IDLE_TIMEOUT")
+	l.duration(&cfg.HTTP.ShutdownTimeout, "HTTP_SHUTDOWN_TIMEOUT")
+	l.duration(&cfg.HTTP.ShutdownDrainDelay, "HTTP_SHUTDOWN_DRAIN_DELAY")
+
+	l.string(&cfg.Database.URL, "DB_URL")
+	l.int32(&cfg.Database.MaxConns, "DB_MAX_CONNS")
+	l.int32(&cfg.Database.MinConns, "DB_MIN_CONNS")
+	l.duration(&cfg.Database.MaxConnLifetime, "DB_MAX_CONN_LIFETIME")
+	l.duration(&cfg.Database.MaxConnIdleTime, "DB_MAX_CONN_IDLE_TIME")
+	l.string(&cfg.Database.TxIsolation, "DB_TX_ISOLATION")
+	l.int(&cfg.Database.TxMaxRetries, "DB_TX_MAX_RETRIES")
+	l.string(&cfg.Database.ReplicaURL, "DB_REPLICA_URL")
+	l.duration(&cfg.Database.ReplicaMaxLag, "DB_REPLICA_MAX_LAG")
+	l.duration(&cfg.Database.ReplicaCheckInterval, "DB_REPLICA_CHECK_INTERVAL")
+	l.bool(&cfg.Database.RowLevelSecurity, "DB_ROW_LEVEL_SECURITY")
+
+	l.int(&cfg.Pagination.DefaultPageSize, "DEFAULT_PAGE_SIZE")
+	l.int(&cfg.Pagination.MaxPageSize, "MAX_PAGE_SIZE")
+	l.string(&cfg.Pagination.CountMode, "COUNT_MODE")
+	l.duration(&cfg.Pagination.CountCacheRefresh, "COUNT_CACHE_REFRESH")
+
+	l.string(&cfg.Log.Level, "LOG_LEVEL")
+	l.string(&cfg.Log.Format, "LOG_FORMAT")
+
+	l.string(&cfg.Tracing.Exporter, "TRACE_EXPORTER")
+
+	l.string(&cfg.Storage.Driver, "STORAGE_DRIVER")
+	l.string(&cfg.Storage.SQLitePath, "SQLITE_PATH")
+
+	l.bool(&cfg.Cache.Enabled, "CACHE_ENABLED")
+	l.int(&cfg.Cache.Size, "CACHE_SIZE")
+	l.duration(&cfg.Cache.TTL, "CACHE_TTL")
+
+	l.duration(&cfg.Stream.Heartbeat, "STREAM_HEARTBEAT")
+	l.int(&cfg.Stream.ReplayBuffer, "STREAM_REPLAY_BUFFER")
+	l.duration(&cfg.Stream.ReplayTTL, "STREAM_REPLAY_TTL")
+	l.int(&cfg.Stream.ClientBuffer, "STREAM_CLIENT_BUFFER")
+	l.int(&cfg.Stream.TopN, "STREAM_TOP_N")
+
+	l.list(&cfg.Websocket.AuthTokens, "WS_AUTH_TOKENS")
+	l.int(&cfg.Websocket.MaxSubscriptions, "WS_MAX_SUBSCRIPTIONS")
+
+	l.bool(&cfg.Webhooks.Enabled, "WEBHOOKS_ENABLED")
+	l.duration(&cfg.Webhooks.PollInterval, "WEBHOOK_POLL_INTERVAL")
+	l.int(&cfg.Webhooks.BatchSize, "WEBHOOK_BATCH_SIZE")
+	l.int(&cfg.Webhooks.Workers, "WEBHOOK_WORKERS")
+	l.int(&cfg.Webhooks.MaxAttempts, "WEBHOOK_MAX_ATTEMPTS")
+	l.duration(&cfg.Webhooks.Timeout, "WEBHOOK_TIMEOUT")
+	l.duration(&cfg.Webhooks.BackoffBase, "WEBHOOK_BACKOFF_BASE")
+	l.duration(&cfg.Webhooks.BackoffMax, "WEBHOOK_BACKOFF_MAX")
+	l.duration(&cfg.Webhooks.Retention, "WEBHOOK_RETENTION")
+	l.bool(&cfg.Webhooks.AllowPrivateTargets, "WEBHOOK_ALLOW_PRIVATE_TARGETS")
+
+	l.bool(&cfg.Events.Enabled, "EVENTS_ENABLED")
+	l.string(&cfg.Events.Publisher, "EVENT_PUBLISHER")
+	l.string(&cfg.Events.FilePath, "EVENT_FILE_PATH")
+	l.duration(&cfg.Events.PollInterval, "EVENT_POLL_INTERVAL")
+	l.int(&cfg.Events.BatchSize, "EVENT_BATCH_SIZE")
+	l.duration(&cfg.Events.Retention, "EVENT_RETENTION")
+
+	l.list(&cfg.Milestones.Rules, "MILESTONE_RULES")
+
+	l.bool(&cfg.Fraud.Enabled, "FRAUD_ENABLED")
+	l.float(&cfg.Fraud.JumpMultiple, "FRAUD_JUMP_MULTIPLE")
+	l.int(&cfg.Fraud.JumpMinDelta, "FRAUD_JUMP_MIN_DELTA")
+	l.float(&cfg.Fraud.RatioThreshold, "FRAUD_RATIO_THRESHOLD")
+	l.int(&cfg.Fraud.RatioMinViewers, "FRAUD_RATIO_MIN_VIEWERS")
+	l.int(&cfg.Fraud.BurstLimit, "FRAUD_BURST_LIMIT")
+	l.duration(&cfg.Fraud.BurstWindow, "FRAUD_BURST_WINDOW")
+	l.list(&cfg.Fraud.ModeratorTokens, "FRAUD_MODERATOR_TOKENS")
+
+	l.string(&cfg.Leaderboard.SnapshotSchedule, "LEADERBOARD_SNAPSHOT_SCHEDULE")
+	l.int(&cfg.Leaderboard.SnapshotKeep, "LEADERBOARD_SNAPSHOT_KEEP")
+
+	l.bool(&cfg.Scheduler.Enabled, "SCHEDULER_ENABLED")
+	l.duration(&cfg.Scheduler.HistoryRetention, "SCHEDULER_HISTORY_RETENTION")
+
+	l.list(&cfg.Tenancy.APIKeys, "TENANT_API_KEYS")
+	l.string(&cfg.Tenancy.Header, "TENANT_HEADER")
+
+	l.duration(&cfg.Nicknames.Cooldown, "NICKNAME_COOLDOWN")
+	l.bool(&cfg.Nicknames.Redirect, "NICKNAME_REDIRECT")
+	l.int(&cfg.Nicknames.MinLength, "NICKNAME_MIN_LENGTH")
+	l.int(&cfg.Nicknames.MaxLength, "NICKNAME_MAX_LENGTH")
+	l.string(&cfg.Nicknames.Charset, "NICKNAME_CHARSET")
+	l.list(&cfg.Nicknames.Reserved, "NICKNAME_RESERVED")
+
+	l.list(&cfg.Operator.Tokens, "OPERATOR_TOKENS")
+
+	return errors.Join(l.errs...)
+}
+
+func (c Config) Validate() error {
+	var errs []error
+
+	if c.HTTP.Addr == "" {
+		errs = append(errs, errors.New("http.addr cannot be empty"))
+	}
+	timeouts := []struct {
+		name  string
+		value time.Duration
+	}{
+		{"http.read_timeout", c.HTTP.ReadTimeout},
+		{"http.write_timeout", c.HTTP.WriteTimeout},
+		{"http.idle_timeout", c.HTTP.IdleTimeout},
+		{"http.shutdown_timeout", c.HTTP.ShutdownTimeout},
+	}
+	for _, timeout := range timeouts {
+		if timeout.value <= 0 {
+			errs = append(errs, fmt.Errorf("%s must be positive", timeout.name))
+		}
+	}
+	if c.HTTP.ShutdownDrainDelay < 0 {
+		errs = append(errs, errors.New("http.shutdown_drain_delay cannot be negative"))
+	}
+
+	if c.Database.URL == "" && c.Storage.Driver == StorageDriverPostgres {
+		errs = append(errs, errors.New("database.url (DB_URL) is not set"))
+	} else if _, err := url.Parse(c.Database.URL); err != nil {
+		errs = append(errs, fmt.Errorf("database.url is invalid: %w", err))
+	}
+	if c.Database.MaxConns < 1 {
+		errs = append(errs, errors.New("database.max_conns must be at least 1"))
+	}
+	if c.Database.MinConns < 0 || c.Database.MinConns > c.Database.MaxConns {
+		errs = append(errs, errors.New("database.min_conns must be between 0 and database.max_conns"))
+	}
+	switch c.Database.TxIsolation {
+	case "read committed", "repeatable read", "serializable":
+	default:
+		errs = append(errs, fmt.Errorf("database.tx_isolation %q must be one of read committed, repeatable read, serializable", c.Database.TxIsolation))
+	}
+	if c.Database.TxMaxRetries < 0 {
+		errs = append(errs, errors.New("database.tx_max_retries cannot be negative"))
+	}
+	if c.Database.ReplicaURL != "" {
+		if c.Database.ReplicaMaxLag <= 0 {
+			errs = append(errs, errors.New("database.replica_max_lag must be positive"))
+		}
+		if c.Database.ReplicaCheckInterval <= 0 {
+			errs = append(errs, errors.New("database.replica_check_interval must be positive"))
+		}
+	}
+	if c.Database.RowLevelSecurity && c.Storage.Driver != StorageDriverPostgres {
+		errs = append(errs, errors.New("database.row_level_security requires the postgres storage driver"))
+	}
+
+	if c.Pagination.MaxPageSize < 1 {
+		errs = append(errs, errors.New("pagination.max_page_size must be at least 1"))
+	}
+	if c.Pagination.DefaultPageSize < 1 || c.Pagination.DefaultPageSize > c.Pagination.MaxPageSize {
+		errs = append(errs, errors.New("pagination.default_page_size must be between 1 and pagination.max_page_size"))
+	}
+	switch c.Pagination.CountMode {
+	case "exact", "estimated", "cached", "none":
+	default:
+		errs = append(errs, fmt.Errorf("pagination.count_mode %q must be one of exact, estimated, cached, none", c.Pagination.CountMode))
+	}
+	if c.Pagination.CountCacheRefresh < 0 {
+		errs = append(errs, errors.New("pagination.count_cache_refresh cannot be negative"))
+	}
+
+	switch c.Log.Level {
+	case "":
+		errs = append(errs, errors.New("log.level (LOG_LEVEL) is not set"))
+	case "local", "dev", "prod":
+	default:
+		errs = append(errs, fmt.Errorf("log.level %q must be one of local, dev, prod", c.Log.Level))
+	}
+	switch c.Log.Format {
+	case "", "text", "json":
+	default:
+		errs = append(errs, fmt.Errorf("log.format %q must be text or json", c.Log.Format))
+	}
+
+	switch c.Tracing.Exporter {
+	case "", "none", "stdout", "otlp":
+	default:
+		errs = append(errs, fmt.Errorf("tracing.exporter %q must be one of none, stdout, otlp", c.Tracing.Exporter))
+	}
+
+	switch c.Storage.Driver {
+	case StorageDriverPostgres, StorageDriverMemory:
+	case StorageDriverSQLite:
+		if c.Storage.SQLitePath == "" {
+			errs = append(errs, errors.New("storage.sqlite_path cannot be empty"))
+		}
+	default:
+		errs = append(errs, fmt.Errorf("storage.driver %q must be one of %s, %s, %s", c.Storage.Driver, StorageDriverPostgres, StorageDriverMemory, StorageDriverSQLite))
+	}
+
+	if c.Cache.Enabled {
+		if c.Cache.Size < 1 {
+			errs = append(errs, errors.New("cache.size must be at least 1"))
+		}
+		if c.Cache.TTL <= 0 {
+			errs = append(errs, errors.New("cache.ttl must be positive"))
+		}
+	}
+
+	if c.Stream.Heartbeat <= 0 {
+		errs = append(errs, errors.New("stream.heartbeat must be positive"))
+	}
+	if c.Stream.ReplayBuffer < 0 {
+		errs = append(errs, errors.New("stream.replay_buffer cannot be negative"))
+	}
+	if c.Stream.ReplayTTL <= 0 {
+		errs = append(errs, errors.New("stream.replay_ttl must be positive"))
+	}
+	if c.Stream.ClientBuffer < 1 {
+		errs = append(errs, errors.New("stream.client_buffer must be at least 1"))
+	}
+	if c.Stream.TopN < 0 || c.Stream.TopN > c.Pagination.MaxPageSize {
+		errs = append(errs, errors.New("stream.top_n must be between 0 and pagination.max_page_size"))
+	}
+
+	if c.Websocket.MaxSubscriptions < 1 {
+		errs = append(errs, errors.New("websocket.max_subscriptions must be at least 1"))
+	}
+
+	if c.Webhooks.Enabled {
+		if c.Storage.Driver != StorageDriverPostgres {
+			errs = append(errs, errors.New("webhooks.enabled requires the postgres storage driver"))
+		}
+		for _, d := range []struct {
+			name  string
+			value time.Duration
+		}{
+			{"webhooks.poll_interval", c.Webhooks.PollInterval},
+			{"webhooks.timeout", c.Webhooks.Timeout},
+			{"webhooks.backoff_base", c.Webhooks.BackoffBase},
+		} {
+			if d.value <= 0 {
+				errs = append(errs, fmt.Errorf("%s must be positive", d.name))
+			}
+		}
+		if c.Webhooks.BackoffMax < c.Webhooks.BackoffBase {
+			errs = append(errs, errors.New("webhooks.backoff_max cannot be less than webhooks.backoff_base"))
+		}
+		if c.Webhooks.BatchSize < 1 || c.Webhooks.Workers < 1 || c.Webhooks.MaxAttempts < 1 {
+			errs = append(errs, errors.New("webhooks.batch_size, webhooks.workers and webhooks.max_attempts must be at least 1"))
+		}
+		if c.Webhooks.Retention < 0 {
+			errs = append(errs, errors.New("webhooks.retention cannot be negative"))
+		}
+		if len(c.Operator.Tokens) == 0 {
+			errs = append(errs, errors.New("operator.tokens (OPERATOR_TOKENS) must be set when webhooks.enabled is on"))
+		}
+	}
+
+	if c.Events.Enabled {
+		if c.Storage.Driver == StorageDriverMemory {
+			errs = append(errs, errors.New("events.enabled requires the postgres or sqlite storage driver"))
+		}
+		switch c.Events.Publisher {
+		case EventPublisherLog:
+		case EventPublisherFile:
+			if c.Events.FilePath == "" {
+				errs = append(errs, errors.New("events.file_path is required for the file publisher"))
+			}
+		default:
+			errs = append(errs, fmt.Errorf("events.publisher must be one of %q, %q", EventPublisherLog, EventPublisherFile))
+		}
+		if c.Events.PollInterval <= 0 {
+			errs = append(errs, errors.New("events.poll_interval must be positive"))
+		}
+		if c.Events.BatchSize < 1 {
+			errs = append(errs, errors.New("events.batch_size must be at least 1"))
+		}
+		if c.Events.Retention < 0 {
+			errs = append(errs, errors.New("events.retention cannot be negative"))
+		}
+	}
+
+	if _, err := c.MilestoneRules(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Fraud.Enabled {
+		if c.Fraud.JumpMultiple < 0 || c.Fraud.JumpMinDelta < 0 || c.Fraud.RatioMinViewers < 0 || c.Fraud.BurstLimit < 0 {
+			errs = append(errs, errors.New("fraud.jump_multiple, fraud.jump_min_delta, fraud.ratio_min_viewers and fraud.burst_limit cannot be negative"))
+		}
+		if c.Fraud.RatioThreshold <= 0 || c.Fraud.RatioThreshold > 1 {
+			errs = append(errs, errors.New("fraud.ratio_threshold must be in (0, 1]"))
+		}
+		if c.Fraud.BurstLimit > 0 && c.Fraud.BurstWindow <= 0 {
+			errs = append(errs, errors.New("fraud.burst_window must be positive when fraud.burst_limit is set"))
+		}
+		if len(c.Fraud.ModeratorTokens) == 0 {
+			errs = append(errs, errors.New("fraud.moderator_tokens (FRAUD_MODERATOR_TOKENS) must be set when fraud.enabled is on"))
+		}
+	}
+
+	if c.Leaderboard.SnapshotSchedule != "" {
+		if _, err := scheduler.Parse(c.Leaderboard.SnapshotSchedule); err != nil {
+			errs = append(errs, fmt.Errorf("leaderboard.snapshot_schedule: %w", err))
+		}
+	}
+	if c.Leaderboard.SnapshotKeep < 0 {
+		errs = append(errs, errors.New("leaderboard.snapshot_keep cannot be negative"))
+	}
+	if c.Scheduler.HistoryRetention < 0 {
+		errs = append(errs, errors.New("scheduler.history_retention cannot be negative"))
+	}
+
+	if _, err := c.TenantKeys(); err != nil {
+		errs = append(errs, err)
+	}
+
+	if c.Nicknames.Cooldown < 0 {
+		errs = append(errs, errors.New("nicknames.cooldown cannot be negative"))
+	}
+	if _, err := c.NicknamePolicy(); err != nil {
+		errs = append(errs, err)
+	}
+
+	return errors.Join(errs...)
+}
+
+// MilestoneRules parses Milestones.Rules.
+func (c Config) MilestoneRules() ([]model.MilestoneRule, error) {
+	rules := make([]model.MilestoneRule, 0, len(c.Milestones.Rules))
+	for _, raw := range c.Milestones.Rules {
+		rule, err := model.ParseMilestoneRule(raw)
+		if err != nil {
+			return nil, err
+		}
+		rules = append(rules, rule)
+	}
+
+	return rules, nil
+}
+
+// TenantKeys parses Tenancy.APIKeys into a map from API key to tenant.
+func (c Config) TenantKeys() (map[string]string, error) {
+	keys := make(map[string]string, len(c.Tenancy.APIKeys))
+	for _, raw := range c.Tenancy.APIKeys {
+		key, id, ok := strings.Cut(raw, "=")
+		if !ok || key == "" {
+			return nil, errors.New(`tenancy.api_keys entries must be written as "key=tenant"`)
+		}
+		if !tenant.Valid(id) {
+			return nil, fmt.Errorf("tenancy.api_keys: invalid tenant %q", id)
+		}
+		if _, ok := keys[key]; ok {
+			return nil, errors.New("tenancy.api_keys: duplicate key")
+		}
+		keys[key] = id
+	}
+
+	return keys, nil
+}
+
+// NicknamePolicy builds the policy described by Nicknames.
+func (c Config) NicknamePolicy() (model.NicknamePolicy, error) {
+	policy, err := model.NewNicknamePolicy(c.Nicknames.MinLength, c.Nicknames.MaxLength, c.Nicknames.Charset, c.Nicknames.Reserved)
+	if err != nil {
+		return model.NicknamePolicy{}, fmt.Errorf("nicknames: %w", err)
+	}
+
+	return policy, nil
+}
+
+// Redacted returns a copy of the config that is safe to print.
+func (c Config) Redacted() Config {
+	c.Database.URL = redactURL(c.Database.URL)
+	c.Database.ReplicaURL = redactURL(c.Database.ReplicaURL)
+	if len(c.Websocket.AuthTokens) > 0 {
+		c.Websocket.AuthTokens = slices.Repeat([]string{"xxxxx"}, len(c.Websocket.AuthTokens))
+	}
+	if len(c.Fraud.ModeratorTokens) > 0 {
+		c.Fraud.ModeratorTokens = slices.Repeat([]string{"xxxxx"}, len(c.Fraud.ModeratorTokens))
+	}
+	if len(c.Operator.Tokens) > 0 {
+		c.Operator.Tokens = slices.Repeat([]string{"xxxxx"}, len(c.Operator.Tokens))
+	}
+	if len(c.Tenancy.APIKeys) > 0 {
+		keys := make([]string, 0, len(c.Tenancy.APIKeys))
+		for _, raw := range c.Tenancy.APIKeys {
+			_, id, _ := strings.Cut(raw, "=")
+			keys = append(keys, "xxxxx="+id)
+		}
+		c.Tenancy.APIKeys = keys
+	}
+
+	return c
+}
+
+// Print writes the redacted config to w as indented JSON, with durations
+// written like "30s" rather than in nanoseconds.
+func (c Config) Print(w io.Writer) error {
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+
+	return enc.Encode(printable(reflect.ValueOf(c.Redacted())).Interface())
+}
+
+// printable rebuilds v with every time.Duration replaced by its String form,
+// keeping the field order and tags of the config structs.
+func printable(v reflect.Value) reflect.Value {
+	if v.Type() == reflect.TypeFor[time.Duration]() {
+		return reflect.ValueOf(time.Duration(v.Int()).String())
+	}
+	if v.Kind() != reflect.Struct {
+		return v
+	}
+
+	fields := make([]reflect.StructField, v.NumField())
+	values := make([]reflect.Value, v.NumField())
+	for i := range fields {
+		field := v.Type().Field(i)
+		values[i] = printable(v.Field(i))
+		fields[i] = reflect.StructField{Name: field.Name, Type: values[i].Type(), Tag: field.Tag}
+	}
+	out := reflect.New(reflect.StructOf(fields)).Elem()
+	for i, value := range values {
+		out.Field(i).Set(value)
+	}
+
+	return out
+}
+
+func redactURL(raw string) string {
+	if raw == "" {
+		return raw
+	}
+
+	u, err := url.Parse(raw)
+	if err != nil || u.Scheme == "" {
+		return "xxxxx"
+	}
+
+	return u.Redacted()
+}
@@ -0,0 +1,33 @@
+package db
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type Health struct {
+	pool *pgxpool.Pool
+}
+
+func NewHealth(pool *pgxpool.Pool) *Health {
+	return &Health{
+		pool: pool,
+	}
+}
+
+func (h *Health) Ping(ctx context.Context) error {
+	return h.pool.Ping(ctx)
+}
+
+func (h *Health) SchemaVersion(ctx context.Context) (int64, error) {
+	query := "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied"
+
+	var version int64
+	if err := h.pool.QueryRow(ctx, query).Scan(&version); err != nil {
+		return -1, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
@@ -3,15 +3,17 @@ package db
 import (
 	"context"
 	"fmt"
+	"rating/internal/config"
+	"rating/internal/tenant"
 
+	"github.com/jackc/pgx/v5"
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
-func NewDb(ctx context.Context, dbUrl string) (*pgxpool.Pool, error) {
-
-	pool, err := pgxpool.New(ctx, dbUrl)
+func NewDb(ctx context.Context, cfg config.Database) (*pgxpool.Pool, error) {
+	pool, err := NewPool(ctx, cfg.URL, cfg)
 	if err != nil {
-		return nil, fmt.Errorf("failed to create pool: %w", err)
+		return nil, err
 	}
 
 	if err := pool.Ping(ctx); err != nil {
@@ -20,3 +22,48 @@ func NewDb(ctx context.Context, dbUrl string) (*pgxpool.Pool, error) {
 
 	return pool, nil
 }
+
+// NewPool creates a pool for dbUrl with the settings from cfg without
+// connecting, so an unreachable replica does not block startup.
+func NewPool(ctx context.Context, dbUrl string, cfg config.Database) (*pgxpool.Pool, error) {
+	poolConfig, err := pgxpool.ParseConfig(dbUrl)
+	if err != nil {
+		return nil, fmt.Errorf("failed to parse db url: %w", err)
+	}
+	poolConfig.MaxConns = cfg.MaxConns
+	poolConfig.MinConns = cfg.MinConns
+	poolConfig.MaxConnLifetime = cfg.MaxConnLifetime
+	poolConfig.MaxConnIdleTime = cfg.MaxConnIdleTime
+	poolConfig.ConnConfig.Tracer = NewQueryTracer()
+	if cfg.RowLevelSecurity {
+		// the policies match rows of the bound tenant only; work marked by
+		// tenant.WithAll binds none and sets app.all_tenants instead
+		poolConfig.PrepareConn = func(ctx context.Context, conn *pgx.Conn) (bool, error) {
+			id, all := tenant.ID(ctx), "off"
+			if tenant.All(ctx) {
+				id, all = "", "on"
+			}
+			if _, err := conn.Exec(ctx, "SELECT set_config('app.tenant_id', $1, false), set_config('app.all_tenants', $2, false)", id, all); err != nil {
+				return false, fmt.Errorf("failed to bind tenant: %w", err)
+			}
+			return true, nil
+		}
+	} else {
+		// the policies are forced on the table owner as well, so without
+		// row-level security the connections opt out of them once and the
+		// queries filter by tenant themselves
+		poolConfig.AfterConnect = func(ctx context.Context, conn *pgx.Conn) error {
+			if _, err := conn.Exec(ctx, "SELECT set_config('app.all_tenants', 'on', false)"); err != nil {
+				return fmt.Errorf("failed to disable tenant policies: %w", err)
+			}
+			return nil
+		}
+	}
+
+	pool, err := pgxpool.NewWithConfig(ctx, poolConfig)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create pool: %w", err)
+	}
+
+	return pool, nil
+}
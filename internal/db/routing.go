@@ -0,0 +1,40 @@
+package db
+
+import (
+	"context"
+	"sync/atomic"
+)
+
+type (
+	writeKey  struct{}
+	forcedKey struct{}
+)
+
+// WithReadYourWrites returns a context in which reads go to the primary once
+// MarkWrite has been called, so a request observes its own writes.
+func WithReadYourWrites(ctx context.Context) context.Context {
+	return context.WithValue(ctx, writeKey{}, new(atomic.Bool))
+}
+
+// ForcePrimary routes every read made with the returned context to the primary.
+func ForcePrimary(ctx context.Context) context.Context {
+	return context.WithValue(ctx, forcedKey{}, true)
+}
+
+func MarkWrite(ctx context.Context) {
+	if wrote, ok := ctx.Value(writeKey{}).(*atomic.Bool); ok {
+		wrote.Store(true)
+	}
+}
+
+// Wrote reports whether MarkWrite was called with a context derived from
+// WithReadYourWrites.
+func Wrote(ctx context.Context) bool {
+	wrote, ok := ctx.Value(writeKey{}).(*atomic.Bool)
+	return ok && wrote.Load()
+}
+
+func PrimaryForced(ctx context.Context) bool {
+	forced, _ := ctx.Value(forcedKey{}).(bool)
+	return forced || Wrote(ctx)
+}
@@ -0,0 +1,31 @@
+package db
+
+import (
+	"context"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadYourWrites(t *testing.T) {
+	t.Run("no tracking", func(t *testing.T) {
+		ctx := context.Background()
+		MarkWrite(ctx)
+		require.False(t, PrimaryForced(ctx))
+	})
+
+	t.Run("primary after write", func(t *testing.T) {
+		ctx := WithReadYourWrites(context.Background())
+		require.False(t, PrimaryForced(ctx))
+
+		MarkWrite(ctx)
+		require.True(t, PrimaryForced(ctx))
+		require.True(t, Wrote(ctx))
+	})
+
+	t.Run("forced primary", func(t *testing.T) {
+		ctx := ForcePrimary(WithReadYourWrites(context.Background()))
+		require.True(t, PrimaryForced(ctx))
+		require.False(t, Wrote(ctx))
+	})
+}
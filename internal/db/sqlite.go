@@ -0,0 +1,52 @@
+package db
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+
+	_ "modernc.org/sqlite"
+)
+
+func NewSQLite(ctx context.Context, path string) (*sql.DB, error) {
+	dsn := fmt.Sprintf("file:%s?_pragma=busy_timeout(5000)&_pragma=journal_mode(WAL)&_pragma=foreign_keys(ON)", path)
+
+	sqlDB, err := sql.Open("sqlite", dsn)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open sqlite: %w", err)
+	}
+	// sqlite allows a single writer, serialising access avoids SQLITE_BUSY under load
+	sqlDB.SetMaxOpenConns(1)
+
+	if err := sqlDB.PingContext(ctx); err != nil {
+		sqlDB.Close()
+		return nil, fmt.Errorf("failed to ping: %w", err)
+	}
+
+	return sqlDB, nil
+}
+
+type SQLiteHealth struct {
+	db *sql.DB
+}
+
+func NewSQLiteHealth(db *sql.DB) *SQLiteHealth {
+	return &SQLiteHealth{
+		db: db,
+	}
+}
+
+func (h *SQLiteHealth) Ping(ctx context.Context) error {
+	return h.db.PingContext(ctx)
+}
+
+func (h *SQLiteHealth) SchemaVersion(ctx context.Context) (int64, error) {
+	query := "SELECT COALESCE(MAX(version_id), 0) FROM goose_db_version WHERE is_applied"
+
+	var version int64
+	if err := h.db.QueryRowContext(ctx, query).Scan(&version); err != nil {
+		return -1, fmt.Errorf("failed to get schema version: %w", err)
+	}
+
+	return version, nil
+}
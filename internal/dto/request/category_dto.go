@@ -0,0 +1,10 @@
+package request
+
+type CategoryDTO struct {
+	Slug string `json:"slug"`
+	Name string `json:"name"`
+}
+
+type UpdateCategoryDTO struct {
+	Name *string `json:"name"`
+}
@@ -1,9 +1,19 @@
 package request
 
+const (
+	CountExact     = "exact"
+	CountEstimated = "estimated"
+	CountCached    = "cached"
+	CountNone      = "none"
+)
+
 type PaginationQuery struct {
-	Limit  int
-	Offset int
-	Sort   string
+	Limit     int
+	Offset    int
+	Sort      string
+	CountMode string
+	// Category restricts the list to members of the category with this slug.
+	Category string
 }
 
 func NewPaginationQuery(limit int, offset int, sort string) PaginationQuery {
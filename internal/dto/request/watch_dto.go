@@ -0,0 +1,11 @@
+package request
+
+const (
+	WatchSubscribe   = "subscribe"
+	WatchUnsubscribe = "unsubscribe"
+)
+
+type WatchCommand struct {
+	Action    string   `json:"action"`
+	Nicknames []string `json:"nicknames"`
+}
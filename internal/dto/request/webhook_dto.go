@@ -0,0 +1,8 @@
+package request
+
+type WebhookSubscriptionDTO struct {
+	URL             string   `json:"url"`
+	EventTypes      []string `json:"event_types"`
+	Secret          string   `json:"secret"`
+	RatingThreshold *float64 `json:"rating_threshold"`
+}
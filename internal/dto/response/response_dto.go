@@ -1,11 +1,22 @@
 package responsedto
 
 type PaginatedResponse[T any] struct {
-	TotalCount int `json:"total_count"`
-	Data       []T `json:"data"`
+	TotalCount      int  `json:"total_count"`
+	TotalCountExact bool `json:"total_count_exact"`
+	Data            []T  `json:"data"`
 }
 
 func NewPaginatedResponse[T any](data []T, totalCount int) PaginatedResponse[T] {
+	return PaginatedResponse[T]{
+		Data:            data,
+		TotalCount:      totalCount,
+		TotalCountExact: true,
+	}
+}
+
+// NewEstimatedPaginatedResponse is used when total_count is an estimate, a
+// cached value or -1 when counting was skipped.
+func NewEstimatedPaginatedResponse[T any](data []T, totalCount int) PaginatedResponse[T] {
 	return PaginatedResponse[T]{
 		Data:       data,
 		TotalCount: totalCount,
@@ -0,0 +1,19 @@
+package responsedto
+
+import "rating/internal/model"
+
+const (
+	WatchUser       = "user"
+	WatchDeleted    = "deleted"
+	WatchNotFound   = "not_found"
+	WatchSubscribed = "subscribed"
+	WatchError      = "error"
+)
+
+type WatchMessage struct {
+	Type      string      `json:"type"`
+	User      *model.User `json:"user,omitempty"`
+	Nickname  string      `json:"nickname,omitempty"`
+	Nicknames []string    `json:"nicknames,omitempty"`
+	Error     string      `json:"error,omitempty"`
+}
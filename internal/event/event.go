@@ -0,0 +1,134 @@
+// Package event defines the domain events the user service records in the
+// outbox and relays them to a Publisher.
+package event
+
+import (
+	"encoding/json"
+	"fmt"
+	"rating/internal/model"
+	"time"
+)
+
+const (
+	TypeUserCreated   = "UserCreated"
+	TypeUserUpdated   = "UserUpdated"
+	TypeUserDeleted   = "UserDeleted"
+	TypeRatingChanged = "RatingChanged"
+
+	TypeMilestoneReached = "MilestoneReached"
+)
+
+// Event is a recorded domain event. Id is assigned by the outbox and orders
+// events, Tenant is the tenant of the user the outbox recorded it for; Data
+// holds the JSON payload of the event type.
+type Event struct {
+	Id         int64           `json:"id"`
+	Tenant     string          `json:"tenant"`
+	Type       string          `json:"type"`
+	Nickname   string          `json:"nickname"`
+	OccurredAt time.Time       `json:"occurred_at"`
+	Data       json.RawMessage `json:"data"`
+}
+
+type UserCreated struct {
+	User model.User `json:"user"`
+}
+
+type FieldChange struct {
+	Old any `json:"old"`
+	New any `json:"new"`
+}
+
+type UserUpdated struct {
+	User    model.User             `json:"user"`
+	Changes map[string]FieldChange `json:"changes"`
+}
+
+type UserDeleted struct {
+	User model.User `json:"user"`
+}
+
+type RatingChanged struct {
+	Nickname  string  `json:"nickname"`
+	OldRating float64 `json:"old_rating"`
+	NewRating float64 `json:"new_rating"`
+}
+
+type MilestoneReached struct {
+	Nickname  string          `json:"nickname"`
+	Milestone model.Milestone `json:"milestone"`
+}
+
+func New(eventType, nickname string, payload any) (Event, error) {
+	data, err := json.Marshal(payload)
+	if err != nil {
+		return Event{}, fmt.Errorf("failed to marshal %s: %w", eventType, err)
+	}
+
+	return Event{
+		Type:       eventType,
+		Nickname:   nickname,
+		OccurredAt: time.Now().UTC(),
+		Data:       data,
+	}, nil
+}
+
+func NewUserCreated(user model.User) ([]Event, error) {
+	e, err := New(TypeUserCreated, user.NickName, UserCreated{User: user})
+	if err != nil {
+		return nil, err
+	}
+
+	return []Event{e}, nil
+}
+
+func NewUserDeleted(user model.User) ([]Event, error) {
+	e, err := New(TypeUserDeleted, user.NickName, UserDeleted{User: user})
+	if err != nil {
+		return nil, err
+	}
+
+	return []Event{e}, nil
+}
+
+// NewUserUpdated returns a UserUpdated event with the fields that differ
+// between before and after, followed by RatingChanged when the rating moved.
+// An update that changed nothing produces no events.
+func NewUserUpdated(before, after model.User) ([]Event, error) {
+	changes := make(map[string]FieldChange)
+	if before.Name != after.Name {
+		changes["name"] = FieldChange{Old: before.Name, New: after.Name}
+	}
+	if before.NickName != after.NickName {
+		changes["nickname"] = FieldChange{Old: before.NickName, New: after.NickName}
+	}
+	if before.Likes != after.Likes {
+		changes["likes"] = FieldChange{Old: before.Likes, New: after.Likes}
+	}
+	if before.Viewers != after.Viewers {
+		changes["viewers"] = FieldChange{Old: before.Viewers, New: after.Viewers}
+	}
+	if len(changes) == 0 {
+		return nil, nil
+	}
+
+	updated, err := New(TypeUserUpdated, after.NickName, UserUpdated{User: after, Changes: changes})
+	if err != nil {
+		return nil, err
+	}
+	events := []Event{updated}
+
+	if before.Rating != after.Rating {
+		ratingChanged, err := New(TypeRatingChanged, after.NickName, RatingChanged{
+			Nickname:  after.NickName,
+			OldRating: before.Rating,
+			NewRating: after.Rating,
+		})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, ratingChanged)
+	}
+
+	return events, nil
+}
@@ -0,0 +1,171 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"rating/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNewUserUpdated(t *testing.T) {
+	before := model.User{Id: 1, Name: "name", NickName: "nickname", Likes: 1, Viewers: 2, Rating: 0.5}
+
+	t.Run("no changes", func(t *testing.T) {
+		events, err := NewUserUpdated(before, before)
+		require.NoError(t, err)
+		require.Empty(t, events)
+	})
+
+	t.Run("changed fields only", func(t *testing.T) {
+		after := before
+		after.Name = "renamed"
+
+		events, err := NewUserUpdated(before, after)
+		require.NoError(t, err)
+		require.Len(t, events, 1)
+		require.Equal(t, TypeUserUpdated, events[0].Type)
+
+		var updated UserUpdated
+		require.NoError(t, json.Unmarshal(events[0].Data, &updated))
+		require.Equal(t, map[string]FieldChange{"name": {Old: "name", New: "renamed"}}, updated.Changes)
+		require.Equal(t, after, updated.User)
+	})
+
+	t.Run("rating change", func(t *testing.T) {
+		after := before
+		after.Likes, after.Rating = 2, 1
+
+		events, err := NewUserUpdated(before, after)
+		require.NoError(t, err)
+		require.Len(t, events, 2)
+		require.Equal(t, TypeRatingChanged, events[1].Type)
+
+		var changed RatingChanged
+		require.NoError(t, json.Unmarshal(events[1].Data, &changed))
+		require.Equal(t, RatingChanged{Nickname: "nickname", OldRating: 0.5, NewRating: 1}, changed)
+	})
+}
+
+func TestFilePublisher(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "events.ndjson")
+	created, err := NewUserCreated(model.User{Id: 1, Name: "name", NickName: "nickname"})
+	require.NoError(t, err)
+	created[0].Id = 1
+	deleted, err := NewUserDeleted(model.User{Id: 1, Name: "name", NickName: "nickname"})
+	require.NoError(t, err)
+	deleted[0].Id = 2
+
+	publisher, err := NewFilePublisher(path)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), created))
+	require.NoError(t, publisher.Close())
+
+	// reopening appends instead of truncating
+	publisher, err = NewFilePublisher(path)
+	require.NoError(t, err)
+	require.NoError(t, publisher.Publish(context.Background(), deleted))
+	require.NoError(t, publisher.Close())
+
+	file, err := os.Open(path)
+	require.NoError(t, err)
+	defer file.Close()
+
+	var got []Event
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		var e Event
+		require.NoError(t, json.Unmarshal(scanner.Bytes(), &e))
+		got = append(got, e)
+	}
+	require.NoError(t, scanner.Err())
+	require.Len(t, got, 2)
+	require.Equal(t, []int64{1, 2}, []int64{got[0].Id, got[1].Id})
+	require.Equal(t, TypeUserDeleted, got[1].Type)
+	require.JSONEq(t, string(deleted[0].Data), string(got[1].Data))
+}
+
+type fakeSource struct {
+	pending   []Event
+	published []Event
+	pruned    int
+}
+
+func (s *fakeSource) Relay(ctx context.Context, limit int, fn func([]Event) error) (int, error) {
+	batch := s.pending[:min(limit, len(s.pending))]
+	if len(batch) == 0 {
+		return 0, nil
+	}
+	if err := fn(batch); err != nil {
+		return 0, err
+	}
+	s.published = append(s.published, batch...)
+	s.pending = s.pending[len(batch):]
+	return len(batch), nil
+}
+
+func (s *fakeSource) Prune(ctx context.Context, before time.Time) (int64, error) {
+	s.pruned++
+	return 0, nil
+}
+
+type fakePublisher struct {
+	batches [][]Event
+	err     error
+}
+
+func (p *fakePublisher) Publish(ctx context.Context, events []Event) error {
+	if p.err != nil {
+		return p.err
+	}
+	p.batches = append(p.batches, events)
+	return nil
+}
+
+func (p *fakePublisher) Close() error {
+	return nil
+}
+
+func TestRelay_RunOnce(t *testing.T) {
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	newSource := func() *fakeSource {
+		source := &fakeSource{}
+		for i := range 5 {
+			source.pending = append(source.pending, Event{Id: int64(i + 1), Type: TypeUserCreated})
+		}
+		return source
+	}
+
+	t.Run("drains in batches", func(t *testing.T) {
+		source := newSource()
+		publisher := &fakePublisher{}
+		relay := NewRelay(source, publisher, time.Second, 2, time.Hour, log)
+
+		require.NoError(t, relay.RunOnce(context.Background()))
+		require.Empty(t, source.pending)
+		require.Len(t, publisher.batches, 3)
+		require.Equal(t, int64(5), publisher.batches[2][0].Id)
+
+		// pruning is rate limited
+		require.NoError(t, relay.RunOnce(context.Background()))
+		require.Equal(t, 1, source.pruned)
+	})
+
+	t.Run("publish error keeps events", func(t *testing.T) {
+		publishErr := errors.New("broker unavailable")
+		source := newSource()
+		relay := NewRelay(source, &fakePublisher{err: publishErr}, time.Second, 2, 0, log)
+
+		require.ErrorIs(t, relay.RunOnce(context.Background()), publishErr)
+		require.Len(t, source.pending, 5)
+		require.Zero(t, source.pruned)
+	})
+}
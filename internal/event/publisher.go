@@ -0,0 +1,88 @@
+package event
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sync"
+)
+
+// Publisher delivers relayed events downstream. A batch is retried as a whole
+// when Publish fails, so publishers must tolerate duplicates.
+type Publisher interface {
+	Publish(ctx context.Context, events []Event) error
+	Close() error
+}
+
+// LogPublisher writes every event to a structured logger.
+type LogPublisher struct {
+	logger *slog.Logger
+}
+
+func NewLogPublisher(log *slog.Logger) *LogPublisher {
+	return &LogPublisher{
+		logger: log,
+	}
+}
+
+func (p *LogPublisher) Publish(ctx context.Context, events []Event) error {
+	for _, e := range events {
+		p.logger.InfoContext(ctx, "domain event",
+			slog.Int64("id", e.Id),
+			slog.String("type", e.Type),
+			slog.String("nickname", e.Nickname),
+			slog.Time("occurred_at", e.OccurredAt),
+			slog.String("data", string(e.Data)),
+		)
+	}
+
+	return nil
+}
+
+func (p *LogPublisher) Close() error {
+	return nil
+}
+
+// FilePublisher appends events to a file as newline delimited JSON and syncs
+// the file after every batch.
+type FilePublisher struct {
+	mu   sync.Mutex
+	file *os.File
+}
+
+func NewFilePublisher(path string) (*FilePublisher, error) {
+	file, err := os.OpenFile(path, os.O_CREATE|os.O_WRONLY|os.O_APPEND, 0o644)
+	if err != nil {
+		return nil, fmt.Errorf("failed to open event file: %w", err)
+	}
+
+	return &FilePublisher{
+		file: file,
+	}, nil
+}
+
+func (p *FilePublisher) Publish(ctx context.Context, events []Event) error {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	w := bufio.NewWriter(p.file)
+	enc := json.NewEncoder(w)
+	for _, e := range events {
+		if err := enc.Encode(e); err != nil {
+			return fmt.Errorf("failed to encode event %d: %w", e.Id, err)
+		}
+	}
+
+	if err := w.Flush(); err != nil {
+		return fmt.Errorf("failed to write events: %w", err)
+	}
+
+	return p.file.Sync()
+}
+
+func (p *FilePublisher) Close() error {
+	return p.file.Close()
+}
@@ -0,0 +1,102 @@
+package event
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+const pruneInterval = time.Hour
+
+type Source interface {
+	// Relay passes up to limit unpublished events, oldest first, to fn and
+	// marks them published only when fn succeeds. It returns how many events
+	// were relayed.
+	Relay(ctx context.Context, limit int, fn func([]Event) error) (int, error)
+	// Prune deletes events published before the given time.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+// Relay moves events from the outbox to a Publisher. Delivery is at least
+// once: a batch whose publish fails is offered again on the next run.
+type Relay struct {
+	source       Source
+	publisher    Publisher
+	pollInterval time.Duration
+	batchSize    int
+	retention    time.Duration
+	logger       *slog.Logger
+	lastPrune    time.Time
+	cancel       context.CancelFunc
+	done         chan struct{}
+}
+
+func NewRelay(source Source, publisher Publisher, pollInterval time.Duration, batchSize int, retention time.Duration, log *slog.Logger) *Relay {
+	return &Relay{
+		source:       source,
+		publisher:    publisher,
+		pollInterval: pollInterval,
+		batchSize:    batchSize,
+		retention:    retention,
+		logger:       log,
+	}
+}
+
+func (r *Relay) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.pollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := r.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				r.logger.WarnContext(ctx, "event relay", slog.Any("run failed", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (r *Relay) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+// RunOnce relays every pending event and prunes old published ones.
+func (r *Relay) RunOnce(ctx context.Context) error {
+	for {
+		relayed, err := r.source.Relay(ctx, r.batchSize, func(events []Event) error {
+			return r.publisher.Publish(ctx, events)
+		})
+		if err != nil {
+			return err
+		}
+		if relayed < r.batchSize {
+			break
+		}
+	}
+
+	if r.retention > 0 && time.Since(r.lastPrune) > pruneInterval {
+		pruned, err := r.source.Prune(ctx, time.Now().Add(-r.retention))
+		if err != nil {
+			return err
+		}
+		r.lastPrune = time.Now()
+		if pruned > 0 {
+			r.logger.InfoContext(ctx, "event relay", slog.Int64("pruned published events", pruned))
+		}
+	}
+
+	return nil
+}
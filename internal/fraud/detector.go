@@ -0,0 +1,115 @@
+// Package fraud flags user updates that look like artificially pushed
+// engagement so they can be held for review.
+package fraud
+
+import (
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"sync"
+	"time"
+)
+
+type Config struct {
+	// JumpMultiple flags likes or viewers growing to more than this many
+	// times their previous value, once the increase is at least JumpMinDelta.
+	JumpMultiple float64
+	JumpMinDelta int
+	// RatioThreshold flags a likes to viewers ratio at or above it for users
+	// with at least RatioMinViewers viewers.
+	RatioThreshold  float64
+	RatioMinViewers int
+	// BurstLimit flags more than this many updates of one user within BurstWindow.
+	BurstLimit  int
+	BurstWindow time.Duration
+}
+
+// Detector checks updates against Config. Update bursts are counted in
+// memory, so each instance only sees the updates it served.
+type Detector struct {
+	cfg Config
+	now func() time.Time
+
+	mu        sync.Mutex
+	updates   map[int64][]time.Time
+	lastSweep time.Time
+}
+
+func NewDetector(cfg Config) *Detector {
+	return &Detector{
+		cfg:     cfg,
+		now:     time.Now,
+		updates: make(map[int64][]time.Time),
+	}
+}
+
+// Check returns why applying dto to user looks suspicious, or nothing when it
+// does not. Every call counts as an update attempt for burst detection.
+func (d *Detector) Check(user model.User, dto request.UpdateUserDTO) []string {
+	var reasons []string
+
+	likes, viewers := user.Likes, user.Viewers
+	if dto.Likes != nil {
+		likes = *dto.Likes
+		if d.jumped(user.Likes, likes) {
+			reasons = append(reasons, fmt.Sprintf("likes jumped from %d to %d", user.Likes, likes))
+		}
+	}
+	if dto.Viewers != nil {
+		viewers = *dto.Viewers
+		if d.jumped(user.Viewers, viewers) {
+			reasons = append(reasons, fmt.Sprintf("viewers jumped from %d to %d", user.Viewers, viewers))
+		}
+	}
+
+	if (dto.Likes != nil || dto.Viewers != nil) && viewers >= d.cfg.RatioMinViewers && viewers > 0 {
+		if ratio := float64(likes) / float64(viewers); ratio >= d.cfg.RatioThreshold {
+			reasons = append(reasons, fmt.Sprintf("likes ratio %.3f at %d viewers", ratio, viewers))
+		}
+	}
+
+	if count := d.recordUpdate(user.Id); d.cfg.BurstLimit > 0 && count > d.cfg.BurstLimit {
+		reasons = append(reasons, fmt.Sprintf("%d updates within %s", count, d.cfg.BurstWindow))
+	}
+
+	return reasons
+}
+
+func (d *Detector) jumped(before, after int) bool {
+	if d.cfg.JumpMultiple <= 0 || after-before < d.cfg.JumpMinDelta {
+		return false
+	}
+
+	return float64(after) > float64(max(before, 1))*d.cfg.JumpMultiple
+}
+
+// recordUpdate notes an update of the user and returns how many fell within
+// the burst window, including this one.
+func (d *Detector) recordUpdate(userId int64) int {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := d.now()
+	cutoff := now.Add(-d.cfg.BurstWindow)
+
+	if now.Sub(d.lastSweep) > d.cfg.BurstWindow {
+		for id, times := range d.updates {
+			if !times[len(times)-1].After(cutoff) {
+				delete(d.updates, id)
+			}
+		}
+		d.lastSweep = now
+	}
+
+	times := d.updates[userId]
+	kept := times[:0]
+	for _, t := range times {
+		if t.After(cutoff) {
+			kept = append(kept, t)
+		}
+	}
+	kept = append(kept, now)
+	d.updates[userId] = kept
+
+	return len(kept)
+}
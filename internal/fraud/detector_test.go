@@ -0,0 +1,61 @@
+package fraud
+
+import (
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func ptrInt(i int) *int { return &i }
+
+func TestDetector_Check(t *testing.T) {
+	cfg := Config{
+		JumpMultiple:    10,
+		JumpMinDelta:    100,
+		RatioThreshold:  0.98,
+		RatioMinViewers: 1000,
+		BurstLimit:      3,
+		BurstWindow:     time.Minute,
+	}
+	user := model.User{Id: 1, NickName: "nickname", Likes: 10, Viewers: 20000}
+
+	tests := []struct {
+		name    string
+		dto     request.UpdateUserDTO
+		reasons int
+	}{
+		{name: "organic growth", dto: request.UpdateUserDTO{Likes: ptrInt(50)}},
+		{name: "likes jump", dto: request.UpdateUserDTO{Likes: ptrInt(10000)}, reasons: 1},
+		{name: "jump below min delta", dto: request.UpdateUserDTO{Viewers: ptrInt(20050)}},
+		{name: "ratio near one", dto: request.UpdateUserDTO{Likes: ptrInt(99), Viewers: ptrInt(100)}},
+		{name: "ratio near one at volume", dto: request.UpdateUserDTO{Likes: ptrInt(19900)}, reasons: 2},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			d := NewDetector(cfg)
+			require.Len(t, d.Check(user, tt.dto), tt.reasons)
+		})
+	}
+
+	t.Run("burst", func(t *testing.T) {
+		now := time.Now()
+		d := NewDetector(cfg)
+		d.now = func() time.Time { return now }
+		rename := request.UpdateUserDTO{}
+
+		for range 3 {
+			require.Empty(t, d.Check(user, rename))
+		}
+		require.Len(t, d.Check(user, rename), 1)
+
+		// other users have their own window
+		require.Empty(t, d.Check(model.User{Id: 2}, rename))
+
+		now = now.Add(2 * time.Minute)
+		require.Empty(t, d.Check(user, rename))
+		require.NotContains(t, d.updates, int64(2))
+	})
+}
@@ -0,0 +1,166 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/dto/request"
+	responsedto "rating/internal/dto/response"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+	"strconv"
+)
+
+type CategoryService interface {
+	CreateCategory(ctx context.Context, dto request.CategoryDTO) (*model.Category, error)
+	ListCategories(ctx context.Context) ([]model.Category, error)
+	GetCategory(ctx context.Context, slug string) (*model.Category, error)
+	UpdateCategory(ctx context.Context, slug string, dto request.UpdateCategoryDTO) (*model.Category, error)
+	DeleteCategory(ctx context.Context, slug string) error
+	AddMember(ctx context.Context, slug, nickname string) error
+	RemoveMember(ctx context.Context, slug, nickname string) error
+	UserCategories(ctx context.Context, nickname string) ([]model.CategoryRank, error)
+	Leaderboard(ctx context.Context, slug string, limit, offset int) ([]model.RankedUser, int, error)
+}
+
+type CategoryHandler struct {
+	service         CategoryService
+	logger          *slog.Logger
+	defaultPageSize int
+}
+
+func NewCategoryHandler(service CategoryService, log *slog.Logger, defaultPageSize int) *CategoryHandler {
+	return &CategoryHandler{
+		service:         service,
+		logger:          log,
+		defaultPageSize: defaultPageSize,
+	}
+}
+
+func (h *CategoryHandler) CreateCategory(w http.ResponseWriter, r *http.Request) {
+	var dto request.CategoryDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	category, err := h.service.CreateCategory(r.Context(), dto)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusCreated, category)
+}
+
+func (h *CategoryHandler) ListCategories(w http.ResponseWriter, r *http.Request) {
+	categories, err := h.service.ListCategories(r.Context())
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, categories)
+}
+
+func (h *CategoryHandler) GetCategory(w http.ResponseWriter, r *http.Request) {
+	category, err := h.service.GetCategory(r.Context(), r.PathValue("slug"))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) UpdateCategory(w http.ResponseWriter, r *http.Request) {
+	var dto request.UpdateCategoryDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	category, err := h.service.UpdateCategory(r.Context(), r.PathValue("slug"), dto)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, category)
+}
+
+func (h *CategoryHandler) DeleteCategory(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.DeleteCategory(r.Context(), r.PathValue("slug")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) AddMember(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.AddMember(r.Context(), r.PathValue("slug"), r.PathValue("nickname")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) RemoveMember(w http.ResponseWriter, r *http.Request) {
+	if err := h.service.RemoveMember(r.Context(), r.PathValue("slug"), r.PathValue("nickname")); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *CategoryHandler) UserCategories(w http.ResponseWriter, r *http.Request) {
+	ranks, err := h.service.UserCategories(r.Context(), r.PathValue("nickname"))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, ranks)
+}
+
+// Leaderboard pages through a category with the page and size parameters
+// of GET /users.
+func (h *CategoryHandler) Leaderboard(w http.ResponseWriter, r *http.Request) {
+	param := r.URL.Query()
+
+	size, err := strconv.Atoi(param.Get("size"))
+	if err != nil {
+		size = h.defaultPageSize
+	}
+	page, err := strconv.Atoi(param.Get("page"))
+	if err != nil || page < 1 {
+		page = 1
+	}
+
+	users, total, err := h.service.Leaderboard(r.Context(), r.PathValue("slug"), size, (page-1)*size)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, responsedto.NewPaginatedResponse(users, total))
+}
+
+func (h *CategoryHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidInput):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrAlreadyExists):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusConflict, err.Error())
+	default:
+		h.logger.ErrorContext(r.Context(), "category handler", slog.Any("error", err))
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+	}
+}
@@ -0,0 +1,88 @@
+package handler
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	response "rating/internal/transport/http"
+	"sync/atomic"
+	"time"
+)
+
+type ReadinessChecker interface {
+	Ping(ctx context.Context) error
+	SchemaVersion(ctx context.Context) (int64, error)
+}
+
+type HealthHandler struct {
+	checker         ReadinessChecker
+	expectedVersion int64
+	shuttingDown    atomic.Bool
+	logger          *slog.Logger
+}
+
+func NewHealthHandler(checker ReadinessChecker, expectedVersion int64, log *slog.Logger) *HealthHandler {
+	return &HealthHandler{
+		checker:         checker,
+		expectedVersion: expectedVersion,
+		logger:          log,
+	}
+}
+
+func (h *HealthHandler) SetShuttingDown() {
+	h.shuttingDown.Store(true)
+}
+
+func (h *HealthHandler) Liveness(w http.ResponseWriter, r *http.Request) {
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, map[string]string{"status": "ok"})
+}
+
+func (h *HealthHandler) Readiness(w http.ResponseWriter, r *http.Request) {
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	checks := map[string]string{
+		"database":   "ok",
+		"migrations": "ok",
+		"shutdown":   "ok",
+	}
+	ready := true
+
+	if h.shuttingDown.Load() {
+		checks["shutdown"] = "shutting down"
+		ready = false
+	}
+
+	if h.checker == nil {
+		h.respond(w, r, ready, checks)
+		return
+	}
+
+	if err := h.checker.Ping(ctx); err != nil {
+		h.logger.WarnContext(ctx, "readiness", slog.Any("database ping failed", err))
+		checks["database"] = "unavailable"
+		ready = false
+	}
+
+	version, err := h.checker.SchemaVersion(ctx)
+	if err != nil {
+		h.logger.WarnContext(ctx, "readiness", slog.Any("schema version check failed", err))
+		checks["migrations"] = "unknown"
+		ready = false
+	} else if version < h.expectedVersion {
+		checks["migrations"] = fmt.Sprintf("schema version %d is behind %d", version, h.expectedVersion)
+		ready = false
+	}
+
+	h.respond(w, r, ready, checks)
+}
+
+func (h *HealthHandler) respond(w http.ResponseWriter, r *http.Request, ready bool, checks map[string]string) {
+	status := http.StatusOK
+	if !ready {
+		status = http.StatusServiceUnavailable
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, status, checks)
+}
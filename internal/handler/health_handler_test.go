@@ -0,0 +1,86 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockReadinessChecker struct {
+	PingErr    error
+	Version    int64
+	VersionErr error
+}
+
+func (m *MockReadinessChecker) Ping(ctx context.Context) error {
+	return m.PingErr
+}
+
+func (m *MockReadinessChecker) SchemaVersion(ctx context.Context) (int64, error) {
+	return m.Version, m.VersionErr
+}
+
+func TestHealthHandler_Liveness(t *testing.T) {
+	handler := NewHealthHandler(&MockReadinessChecker{PingErr: serverErr}, 1, discardLogger)
+
+	req := httptest.NewRequest(http.MethodGet, "/healthz", nil)
+	rr := httptest.NewRecorder()
+	handler.Liveness(rr, req)
+
+	require.Equal(t, http.StatusOK, rr.Code)
+}
+
+func TestHealthHandler_Readiness(t *testing.T) {
+	tests := []struct {
+		name           string
+		checker        *MockReadinessChecker
+		shuttingDown   bool
+		expectedStatus int
+	}{
+		{
+			name:           "ready",
+			checker:        &MockReadinessChecker{Version: 2},
+			expectedStatus: http.StatusOK,
+		},
+		{
+			name:           "ping failed",
+			checker:        &MockReadinessChecker{PingErr: serverErr, Version: 2},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "schema behind",
+			checker:        &MockReadinessChecker{Version: 1},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "schema unknown",
+			checker:        &MockReadinessChecker{VersionErr: errors.New("no table")},
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+		{
+			name:           "shutting down",
+			checker:        &MockReadinessChecker{Version: 2},
+			shuttingDown:   true,
+			expectedStatus: http.StatusServiceUnavailable,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewHealthHandler(tt.checker, 2, discardLogger)
+			if tt.shuttingDown {
+				handler.SetShuttingDown()
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/readyz", nil)
+			rr := httptest.NewRecorder()
+			handler.Readiness(rr, req)
+
+			require.Equal(t, tt.expectedStatus, rr.Code)
+		})
+	}
+}
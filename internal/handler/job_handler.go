@@ -0,0 +1,57 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+)
+
+type JobService interface {
+	ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error)
+}
+
+type JobHandler struct {
+	service JobService
+	auth    Authenticator
+	logger  *slog.Logger
+}
+
+// NewJobHandler creates the handler; every request must pass auth, as the
+// run history names the instances and carries their errors.
+func NewJobHandler(service JobService, auth Authenticator, log *slog.Logger) *JobHandler {
+	return &JobHandler{
+		service: service,
+		auth:    auth,
+		logger:  log,
+	}
+}
+
+func (h *JobHandler) ListRuns(w http.ResponseWriter, r *http.Request) {
+	if err := h.auth.Authenticate(r); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusUnauthorized, err.Error())
+		return
+	}
+
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	runs, err := h.service.ListRuns(r.Context(), r.URL.Query().Get("job"), int(limit))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidInput):
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "job handler", slog.Any("error", err))
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, runs)
+}
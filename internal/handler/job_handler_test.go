@@ -0,0 +1,46 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockJobService struct{}
+
+func (m *MockJobService) ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error) {
+	return []model.JobRun{}, nil
+}
+
+func TestJobHandler_Auth(t *testing.T) {
+	tests := []struct {
+		name           string
+		tokens         []string
+		token          string
+		expectedStatus int
+	}{
+		{"without token", []string{"operator"}, "", http.StatusUnauthorized},
+		{"with a wrong token", []string{"operator"}, "user", http.StatusUnauthorized},
+		{"no tokens configured", nil, "operator", http.StatusUnauthorized},
+		{"with token", []string{"operator"}, "operator", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			handler := NewJobHandler(&MockJobService{}, NewTokenAuth(tt.tokens), discardLogger)
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/jobs/runs", nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			handler.ListRuns(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+}
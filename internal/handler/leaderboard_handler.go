@@ -0,0 +1,103 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+	"strconv"
+)
+
+type LeaderboardService interface {
+	ListSnapshots(ctx context.Context, limit int) ([]model.LeaderboardSnapshot, error)
+	Diff(ctx context.Context, from, to int64, limit int) ([]model.RankChange, error)
+	UserRank(ctx context.Context, nickname string) (*model.RankDelta, error)
+}
+
+type LeaderboardHandler struct {
+	service LeaderboardService
+	logger  *slog.Logger
+}
+
+func NewLeaderboardHandler(service LeaderboardService, log *slog.Logger) *LeaderboardHandler {
+	return &LeaderboardHandler{
+		service: service,
+		logger:  log,
+	}
+}
+
+func (h *LeaderboardHandler) ListSnapshots(w http.ResponseWriter, r *http.Request) {
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	snapshots, err := h.service.ListSnapshots(r.Context(), int(limit))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, snapshots)
+}
+
+func (h *LeaderboardHandler) Diff(w http.ResponseWriter, r *http.Request) {
+	from, err := queryInt(r, "from")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid from snapshot id")
+		return
+	}
+	to, err := queryInt(r, "to")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid to snapshot id")
+		return
+	}
+	limit, err := queryInt(r, "limit")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid limit")
+		return
+	}
+
+	changes, err := h.service.Diff(r.Context(), from, to, int(limit))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, changes)
+}
+
+func (h *LeaderboardHandler) UserRank(w http.ResponseWriter, r *http.Request) {
+	rank, err := h.service.UserRank(r.Context(), r.PathValue("nickname"))
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, rank)
+}
+
+func (h *LeaderboardHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidInput):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+	default:
+		h.logger.ErrorContext(r.Context(), "leaderboard handler", slog.Any("error", err))
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+	}
+}
+
+// queryInt parses an optional integer query parameter, returning 0 when it
+// is absent.
+func queryInt(r *http.Request, name string) (int64, error) {
+	raw := r.URL.Query().Get(name)
+	if raw == "" {
+		return 0, nil
+	}
+	return strconv.ParseInt(raw, 10, 64)
+}
@@ -0,0 +1,44 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+)
+
+type MilestoneService interface {
+	ListMilestones(ctx context.Context, nickname string) ([]model.Milestone, error)
+}
+
+type MilestoneHandler struct {
+	service MilestoneService
+	logger  *slog.Logger
+}
+
+func NewMilestoneHandler(service MilestoneService, log *slog.Logger) *MilestoneHandler {
+	return &MilestoneHandler{
+		service: service,
+		logger:  log,
+	}
+}
+
+func (h *MilestoneHandler) ListMilestones(w http.ResponseWriter, r *http.Request) {
+	milestones, err := h.service.ListMilestones(r.Context(), r.PathValue("nickname"))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidInput):
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrNotFound):
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "milestone handler", slog.Any("error", err))
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, milestones)
+}
@@ -0,0 +1,109 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+	"strconv"
+)
+
+type ModerationService interface {
+	ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error)
+	ApproveQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error)
+	RejectQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error)
+}
+
+type ModerationHandler struct {
+	service ModerationService
+	auth    Authenticator
+	logger  *slog.Logger
+}
+
+// NewModerationHandler creates the handler; every request must pass auth, as
+// approving a change applies it without the fraud check.
+func NewModerationHandler(service ModerationService, auth Authenticator, log *slog.Logger) *ModerationHandler {
+	return &ModerationHandler{
+		service: service,
+		auth:    auth,
+		logger:  log,
+	}
+}
+
+func (h *ModerationHandler) ListQuarantined(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	query := r.URL.Query()
+
+	var limit int
+	if raw := query.Get("limit"); raw != "" {
+		var err error
+		if limit, err = strconv.Atoi(raw); err != nil {
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid limit")
+			return
+		}
+	}
+
+	changes, err := h.service.ListQuarantined(r.Context(), query.Get("status"), limit)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, changes)
+}
+
+func (h *ModerationHandler) Approve(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.service.ApproveQuarantined)
+}
+
+func (h *ModerationHandler) Reject(w http.ResponseWriter, r *http.Request) {
+	h.resolve(w, r, h.service.RejectQuarantined)
+}
+
+func (h *ModerationHandler) resolve(w http.ResponseWriter, r *http.Request, fn func(context.Context, int64) (*model.QuarantinedChange, error)) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid quarantined change id")
+		return
+	}
+
+	change, err := fn(r.Context(), id)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, change)
+}
+
+func (h *ModerationHandler) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if err := h.auth.Authenticate(r); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusUnauthorized, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (h *ModerationHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidInput):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+	case errors.Is(err, model.ErrConflict), errors.Is(err, model.ErrAlreadyExists):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusConflict, err.Error())
+	default:
+		h.logger.ErrorContext(r.Context(), "moderation handler", slog.Any("error", err))
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+	}
+}
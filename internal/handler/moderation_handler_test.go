@@ -0,0 +1,69 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockModerationService struct {
+	approved []int64
+}
+
+func (m *MockModerationService) ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error) {
+	return []model.QuarantinedChange{}, nil
+}
+
+func (m *MockModerationService) ApproveQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	m.approved = append(m.approved, id)
+	return &model.QuarantinedChange{Id: id, Status: model.QuarantineApproved}, nil
+}
+
+func (m *MockModerationService) RejectQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	return &model.QuarantinedChange{Id: id, Status: model.QuarantineRejected}, nil
+}
+
+func TestModerationHandler_Auth(t *testing.T) {
+	service := &MockModerationService{}
+	handler := NewModerationHandler(service, NewTokenAuth([]string{"moderator"}), discardLogger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /moderation/quarantine", handler.ListQuarantined)
+	mux.HandleFunc("POST /moderation/quarantine/{id}/approve", handler.Approve)
+	mux.HandleFunc("POST /moderation/quarantine/{id}/reject", handler.Reject)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		token          string
+		expectedStatus int
+	}{
+		{"approve without token", http.MethodPost, "/moderation/quarantine/1/approve", "", http.StatusUnauthorized},
+		{"approve with a wrong token", http.MethodPost, "/moderation/quarantine/1/approve", "user", http.StatusUnauthorized},
+		{"reject without token", http.MethodPost, "/moderation/quarantine/1/reject", "", http.StatusUnauthorized},
+		{"list without token", http.MethodGet, "/moderation/quarantine", "", http.StatusUnauthorized},
+		{"list", http.MethodGet, "/moderation/quarantine", "moderator", http.StatusOK},
+		{"approve", http.MethodPost, "/moderation/quarantine/2/approve", "moderator", http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, nil)
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			mux.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+
+	require.Equal(t, []int64{2}, service.approved)
+}
@@ -0,0 +1,45 @@
+package handler
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+)
+
+type NicknameService interface {
+	ListNicknames(ctx context.Context, nickname string) ([]model.NicknameAlias, error)
+}
+
+type NicknameHandler struct {
+	service NicknameService
+	logger  *slog.Logger
+}
+
+func NewNicknameHandler(service NicknameService, log *slog.Logger) *NicknameHandler {
+	return &NicknameHandler{
+		service: service,
+		logger:  log,
+	}
+}
+
+// ListNicknames returns the nicknames a user was renamed from, newest first.
+func (h *NicknameHandler) ListNicknames(w http.ResponseWriter, r *http.Request) {
+	aliases, err := h.service.ListNicknames(r.Context(), r.PathValue("nickname"))
+	if err != nil {
+		switch {
+		case errors.Is(err, model.ErrInvalidInput):
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+		case errors.Is(err, model.ErrNotFound):
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+		default:
+			h.logger.ErrorContext(r.Context(), "nickname handler", slog.Any("error", err))
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+		}
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, aliases)
+}
@@ -0,0 +1,95 @@
+package handler
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"rating/internal/stream"
+	"rating/internal/tenant"
+	response "rating/internal/transport/http"
+	"time"
+)
+
+const defaultHeartbeat = 15 * time.Second
+
+type StreamHandler struct {
+	broker    *stream.Broker
+	heartbeat time.Duration
+	logger    *slog.Logger
+}
+
+func NewStreamHandler(broker *stream.Broker, heartbeat time.Duration, log *slog.Logger) *StreamHandler {
+	if heartbeat <= 0 {
+		heartbeat = defaultHeartbeat
+	}
+
+	return &StreamHandler{
+		broker:    broker,
+		heartbeat: heartbeat,
+		logger:    log,
+	}
+}
+
+// Stream serves user change events as Server-Sent Events. Clients resume with
+// the Last-Event-ID header; a client that falls behind is disconnected and
+// resumes the same way. Clients only receive the events of their tenant.
+func (s *StreamHandler) Stream(w http.ResponseWriter, r *http.Request) {
+	rc := http.NewResponseController(w)
+
+	lastEventID := r.Header.Get("Last-Event-ID")
+	if lastEventID == "" {
+		lastEventID = r.URL.Query().Get("lastEventId")
+	}
+	sub, err := s.broker.Subscribe(tenant.ID(r.Context()), lastEventID)
+	if err != nil {
+		response.ResponseErr(r.Context(), s.logger, w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	w.Header().Set("Content-Type", "text/event-stream")
+	w.Header().Set("Cache-Control", "no-cache")
+	w.Header().Set("X-Accel-Buffering", "no")
+	if err := s.write(rc, func() { w.WriteHeader(http.StatusOK) }); err != nil {
+		s.logger.ErrorContext(r.Context(), "stream", slog.Any("failed to start stream", err))
+		return
+	}
+
+	heartbeat := time.NewTicker(s.heartbeat)
+	defer heartbeat.Stop()
+
+	for {
+		var err error
+		select {
+		case <-r.Context().Done():
+			return
+		case event, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					s.logger.WarnContext(r.Context(), "stream", slog.String("client disconnected", "too slow to keep up"))
+				}
+				return
+			}
+			err = s.write(rc, func() {
+				fmt.Fprintf(w, "id: %s\nevent: %s\ndata: %s\n\n", event.ID, event.Type, event.Data)
+			})
+		case <-heartbeat.C:
+			err = s.write(rc, func() { fmt.Fprint(w, ": heartbeat\n\n") })
+		}
+		if err != nil {
+			return
+		}
+	}
+}
+
+// write runs fn and flushes under a deadline of its own instead of the server
+// write timeout, which the stream outlives, so a stalled client is still dropped.
+func (s *StreamHandler) write(rc *http.ResponseController, fn func()) error {
+	if err := rc.SetWriteDeadline(time.Now().Add(s.heartbeat)); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		return err
+	}
+	fn()
+
+	return rc.Flush()
+}
@@ -0,0 +1,61 @@
+package handler
+
+import (
+	"bufio"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/stream"
+	"rating/internal/tenant"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestStreamHandler_Stream(t *testing.T) {
+	broker := stream.NewBroker(10, 10, time.Minute)
+	require.NoError(t, broker.Publish(tenant.Default, stream.EventDeleted, stream.UserEvent{Nickname: "old"}))
+
+	server := httptest.NewServer(http.HandlerFunc(NewStreamHandler(broker, 50*time.Millisecond, discardLogger).Stream))
+	defer server.Close()
+	defer broker.Close()
+
+	sub, err := broker.Subscribe(tenant.Default, "")
+	require.NoError(t, err)
+	require.NoError(t, broker.Publish(tenant.Default, stream.EventDeleted, stream.UserEvent{Nickname: "seen"}))
+	seen := <-sub.C
+	sub.Close()
+
+	req, err := http.NewRequest(http.MethodGet, server.URL, nil)
+	require.NoError(t, err)
+	req.Header.Set("Last-Event-ID", seen.ID)
+
+	resp, err := http.DefaultClient.Do(req)
+	require.NoError(t, err)
+	defer resp.Body.Close()
+	require.Equal(t, http.StatusOK, resp.StatusCode)
+	require.Equal(t, "text/event-stream", resp.Header.Get("Content-Type"))
+
+	require.NoError(t, broker.Publish(tenant.Default, stream.EventDeleted, stream.UserEvent{Nickname: "new"}))
+
+	reader := bufio.NewReader(resp.Body)
+	readFrame := func() string {
+		var frame strings.Builder
+		for {
+			line, err := reader.ReadString('\n')
+			require.NoError(t, err)
+			if line == "\n" {
+				return frame.String()
+			}
+			frame.WriteString(line)
+		}
+	}
+
+	frame := readFrame()
+	require.Contains(t, frame, "event: deleted\n")
+	require.Contains(t, frame, `data: {"nickname":"new"}`)
+	require.NotContains(t, frame, "old")
+
+	require.Equal(t, ": heartbeat\n", readFrame())
+}
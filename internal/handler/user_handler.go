@@ -6,6 +6,7 @@ import (
 	"errors"
 	"log/slog"
 	"net/http"
+	"net/url"
 	"rating/internal/dto/request"
 	responsedto "rating/internal/dto/response"
 	"rating/internal/model"
@@ -15,22 +16,65 @@ import (
 
 type UserService interface {
 	CreateUser(ctx context.Context, dto request.UserRequestDTO) error
-	GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error)
+	GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error)
 	GetUser(ctx context.Context, nickname string) (*model.User, error)
 	ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error
 	Delete(ctx context.Context, nickname string) error
 }
 
+// NicknameResolver finds the current nickname of a user by a nickname it was
+// renamed from.
+type NicknameResolver interface {
+	ResolveNickname(ctx context.Context, nickname string) (string, error)
+}
+
+const defaultPageSize = 10
+
 type UserHandler struct {
-	service UserService
-	logger  *slog.Logger
+	service          UserService
+	logger           *slog.Logger
+	defaultPageSize  int
+	defaultCountMode string
+	nicknames        NicknameResolver
+	redirect         bool
+}
+
+type UserHandlerOption func(*UserHandler)
+
+func WithDefaultPageSize(size int) UserHandlerOption {
+	return func(u *UserHandler) {
+		u.defaultPageSize = size
+	}
+}
+
+func WithDefaultCountMode(mode string) UserHandlerOption {
+	return func(u *UserHandler) {
+		u.defaultCountMode = mode
+	}
+}
+
+// WithNicknameAliases lets GetUser find users by a nickname they were renamed
+// from: with redirect the client is sent to the current nickname, otherwise
+// the user is returned directly.
+func WithNicknameAliases(resolver NicknameResolver, redirect bool) UserHandlerOption {
+	return func(u *UserHandler) {
+		u.nicknames = resolver
+		u.redirect = redirect
+	}
 }
 
-func NewUserHandler(service UserService, log *slog.Logger) *UserHandler {
-	return &UserHandler{
-		service: service,
-		logger:  log,
+func NewUserHandler(service UserService, log *slog.Logger, opts ...UserHandlerOption) *UserHandler {
+	u := &UserHandler{
+		service:          service,
+		logger:           log,
+		defaultPageSize:  defaultPageSize,
+		defaultCountMode: request.CountExact,
 	}
+	for _, opt := range opts {
+		opt(u)
+	}
+
+	return u
 }
 
 func (u *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request) {
@@ -39,27 +83,27 @@ func (u *UserHandler) CreateUserHandler(w http.ResponseWriter, r *http.Request)
 	var userRequestDto request.UserRequestDTO
 
 	if err := json.NewDecoder(r.Body).Decode(&userRequestDto); err != nil {
-		response.ResponseErr(u.logger, w, http.StatusBadRequest, "invalid request body")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if err := u.service.CreateUser(ctx, userRequestDto); err != nil {
 
 		if errors.Is(err, model.ErrInvalidInput) {
-			response.ResponseErr(u.logger, w, http.StatusBadRequest, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, err.Error())
 			return
 		}
 
 		if errors.Is(err, model.ErrAlreadyExists) {
-			response.ResponseErr(u.logger, w, http.StatusConflict, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusConflict, err.Error())
 			return
 		}
 
-		response.ResponseErr(u.logger, w, http.StatusInternalServerError, "internal server error")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 	statusMsg := map[string]string{"status": "ok"}
-	response.ResponseJSON(u.logger, w, http.StatusCreated, statusMsg)
+	response.ResponseJSON(r.Context(), u.logger, w, http.StatusCreated, statusMsg)
 }
 
 func (u *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
@@ -71,7 +115,7 @@ func (u *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 
 	size, err := strconv.Atoi(param.Get("size"))
 	if err != nil {
-		size = 10
+		size = u.defaultPageSize
 	}
 	page, err := strconv.Atoi(param.Get("page"))
 	if err != nil {
@@ -80,24 +124,34 @@ func (u *UserHandler) GetUsers(w http.ResponseWriter, r *http.Request) {
 	offset := (page - 1) * size
 
 	params := request.NewPaginationQuery(size, offset, sort)
+	params.Category = param.Get("category")
+	params.CountMode = param.Get("count")
+	if params.CountMode == "" {
+		params.CountMode = u.defaultCountMode
+	}
 
-	userList, totalCount, err := u.service.GetAll(ctx, params)
+	userList, count, err := u.service.GetAll(ctx, params)
 
 	if err != nil {
 		if errors.Is(err, model.ErrInvalidInput) {
-			response.ResponseErr(u.logger, w, http.StatusBadRequest, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, err.Error())
 			return
 		}
 		if errors.Is(err, model.ErrInvalidSort) {
-			response.ResponseErr(u.logger, w, http.StatusBadRequest, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, err.Error())
 			return
 		}
-		response.ResponseErr(u.logger, w, http.StatusInternalServerError, "internal server error")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusInternalServerError, "internal server error")
+		return
+	}
+	if !count.Exact {
+		data := responsedto.NewEstimatedPaginatedResponse(userList, count.Total)
+		response.ResponseJSON(r.Context(), u.logger, w, http.StatusOK, data)
 		return
 	}
-	data := responsedto.NewPaginatedResponse(userList, totalCount)
 
-	response.ResponseJSON(u.logger, w, http.StatusOK, data)
+	data := responsedto.NewPaginatedResponse(userList, count.Total)
+	response.ResponseJSON(r.Context(), u.logger, w, http.StatusOK, data)
 }
 
 func (u *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
@@ -106,16 +160,32 @@ func (u *UserHandler) GetUser(w http.ResponseWriter, r *http.Request) {
 	nickname := r.PathValue("nickname")
 
 	user, err := u.service.GetUser(ctx, nickname)
+	if errors.Is(err, model.ErrNotFound) && u.nicknames != nil {
+		current, resolveErr := u.nicknames.ResolveNickname(ctx, nickname)
+		switch {
+		case resolveErr == nil && u.redirect:
+			location := "/users/" + url.PathEscape(current)
+			if r.URL.RawQuery != "" {
+				location += "?" + r.URL.RawQuery
+			}
+			http.Redirect(w, r, location, http.StatusPermanentRedirect)
+			return
+		case resolveErr == nil:
+			user, err = u.service.GetUser(ctx, current)
+		case !errors.Is(resolveErr, model.ErrNotFound):
+			err = resolveErr
+		}
+	}
 	if err != nil {
 		if errors.Is(err, model.ErrNotFound) {
-			response.ResponseErr(u.logger, w, http.StatusNotFound, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusNotFound, err.Error())
 			return
 		}
-		response.ResponseErr(u.logger, w, http.StatusInternalServerError, "internal server error")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
-	response.ResponseJSON(u.logger, w, http.StatusOK, user)
+	response.ResponseJSON(r.Context(), u.logger, w, http.StatusOK, user)
 }
 
 func (u *UserHandler) ChangeData(w http.ResponseWriter, r *http.Request) {
@@ -125,24 +195,33 @@ func (u *UserHandler) ChangeData(w http.ResponseWriter, r *http.Request) {
 	var updateUser request.UpdateUserDTO
 
 	if err := json.NewDecoder(r.Body).Decode(&updateUser); err != nil {
-		response.ResponseErr(u.logger, w, http.StatusBadRequest, "invalid request body")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, "invalid request body")
 		return
 	}
 
 	if err := u.service.ChangeData(ctx, nickname, updateUser); err != nil {
+		var held *model.QuarantinedError
+		if errors.As(err, &held) {
+			response.ResponseJSON(r.Context(), u.logger, w, http.StatusAccepted, held.Change)
+			return
+		}
 		if errors.Is(err, model.ErrInvalidInput) {
-			response.ResponseErr(u.logger, w, http.StatusBadRequest, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, err.Error())
 			return
 		}
 		if errors.Is(err, model.ErrNotFound) {
-			response.ResponseErr(u.logger, w, http.StatusNotFound, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusNotFound, err.Error())
+			return
+		}
+		if errors.Is(err, model.ErrAlreadyExists) {
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusConflict, err.Error())
 			return
 		}
-		response.ResponseErr(u.logger, w, http.StatusInternalServerError, "internal server error")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 	responseMsg := map[string]string{"status": "ok"}
-	response.ResponseJSON(u.logger, w, http.StatusOK, responseMsg)
+	response.ResponseJSON(r.Context(), u.logger, w, http.StatusOK, responseMsg)
 }
 
 func (u *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
@@ -152,14 +231,14 @@ func (u *UserHandler) Delete(w http.ResponseWriter, r *http.Request) {
 
 	if err := u.service.Delete(ctx, nickname); err != nil {
 		if errors.Is(err, model.ErrInvalidInput) {
-			response.ResponseErr(u.logger, w, http.StatusBadRequest, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusBadRequest, err.Error())
 			return
 		}
 		if errors.Is(err, model.ErrNotFound) {
-			response.ResponseErr(u.logger, w, http.StatusNotFound, err.Error())
+			response.ResponseErr(r.Context(), u.logger, w, http.StatusNotFound, err.Error())
 			return
 		}
-		response.ResponseErr(u.logger, w, http.StatusInternalServerError, "internal server error")
+		response.ResponseErr(r.Context(), u.logger, w, http.StatusInternalServerError, "internal server error")
 		return
 	}
 
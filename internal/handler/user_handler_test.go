@@ -5,6 +5,7 @@ import (
 	"context"
 	"encoding/json"
 	"errors"
+	"fmt"
 	"io"
 	"log/slog"
 	"net/http"
@@ -25,7 +26,9 @@ type MockUserService struct {
 
 	CreateErr error
 
-	GetAllErr error
+	GetAllErr    error
+	GetAllCount  model.UserCount
+	GetAllParams request.PaginationQuery
 
 	GetUserErr error
 
@@ -37,8 +40,9 @@ func (m *MockUserService) CreateUser(ctx context.Context, dto request.UserReques
 	return m.CreateErr
 }
 
-func (m *MockUserService) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error) {
-	return nil, 0, m.GetAllErr
+func (m *MockUserService) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
+	m.GetAllParams = params
+	return nil, m.GetAllCount, m.GetAllErr
 }
 
 func (m *MockUserService) GetUser(ctx context.Context, nickname string) (*model.User, error) {
@@ -195,6 +199,43 @@ func TestUserHandler_GetUsers(t *testing.T) {
 	}
 }
 
+func TestUserHandler_GetUsersCountMode(t *testing.T) {
+	tests := []struct {
+		name         string
+		count        string
+		defaultMode  string
+		storeCount   model.UserCount
+		expectedMode string
+	}{
+		{name: "default exact", count: "", defaultMode: request.CountExact, storeCount: model.UserCount{Total: 5, Exact: true}, expectedMode: request.CountExact},
+		{name: "default estimated", count: "", defaultMode: request.CountEstimated, storeCount: model.UserCount{Total: 5}, expectedMode: request.CountEstimated},
+		{name: "explicit exact", count: request.CountExact, defaultMode: request.CountCached, storeCount: model.UserCount{Total: 5, Exact: true}, expectedMode: request.CountExact},
+		{name: "estimate fell back to exact", count: request.CountEstimated, defaultMode: request.CountExact, storeCount: model.UserCount{Total: 5, Exact: true}, expectedMode: request.CountEstimated},
+		{name: "none", count: request.CountNone, defaultMode: request.CountExact, storeCount: model.UserCount{Total: -1}, expectedMode: request.CountNone},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(http.MethodGet, "/users?count="+tt.count, nil)
+
+			mock := &MockUserService{GetAllCount: tt.storeCount}
+			handler := NewUserHandler(mock, discardLogger, WithDefaultCountMode(tt.defaultMode))
+			handler.GetUsers(rec, req)
+			require.Equal(t, http.StatusOK, rec.Code)
+			require.Equal(t, tt.expectedMode, mock.GetAllParams.CountMode)
+
+			var body struct {
+				TotalCount      int  `json:"total_count"`
+				TotalCountExact bool `json:"total_count_exact"`
+			}
+			require.NoError(t, json.NewDecoder(rec.Body).Decode(&body))
+			require.Equal(t, tt.storeCount.Total, body.TotalCount)
+			require.Equal(t, tt.storeCount.Exact, body.TotalCountExact)
+		})
+	}
+}
+
 func TestUserHandler_GetUser(t *testing.T) {
 	tests := []struct {
 		name           string
@@ -242,6 +283,60 @@ func TestUserHandler_GetUser(t *testing.T) {
 	}
 }
 
+// renamedUserService knows the user "current", formerly "old".
+type renamedUserService struct {
+	MockUserService
+}
+
+func (m *renamedUserService) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	if nickname != "current" {
+		return nil, model.ErrNotFound
+	}
+	return &model.User{Id: 1, NickName: "current"}, nil
+}
+
+func (m *renamedUserService) ResolveNickname(ctx context.Context, nickname string) (string, error) {
+	if nickname != "old" {
+		return "", model.ErrNotFound
+	}
+	return "current", nil
+}
+
+func TestUserHandler_GetUserByOldNickname(t *testing.T) {
+	tests := []struct {
+		name             string
+		path             string
+		redirect         bool
+		expectedStatus   int
+		expectedLocation string
+	}{
+		{name: "redirect", path: "/users/old?fields=all", redirect: true, expectedStatus: http.StatusPermanentRedirect, expectedLocation: "/users/current?fields=all"},
+		{name: "resolve", path: "/users/old", expectedStatus: http.StatusOK},
+		{name: "unknown", path: "/users/unknown", redirect: true, expectedStatus: http.StatusNotFound},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			service := &renamedUserService{}
+			handler := NewUserHandler(service, discardLogger, WithNicknameAliases(service, tt.redirect))
+
+			mux := http.NewServeMux()
+			mux.HandleFunc("GET /users/{nickname}", handler.GetUser)
+
+			rec := httptest.NewRecorder()
+			mux.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, tt.path, nil))
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+			require.Equal(t, tt.expectedLocation, rec.Header().Get("Location"))
+			if tt.expectedStatus == http.StatusOK {
+				var user model.User
+				require.NoError(t, json.NewDecoder(rec.Body).Decode(&user))
+				require.Equal(t, "current", user.NickName)
+			}
+		})
+	}
+}
+
 func ptrString(s string) *string { return &s }
 func ptrInt(i int) *int          { return &i }
 
@@ -289,6 +384,15 @@ func TestUserHandler_ChangeData(t *testing.T) {
 			mockErr:        model.ErrNotFound,
 			expectedStatus: http.StatusNotFound,
 		},
+		{
+			name:     "nickname taken",
+			nickname: "testNick",
+			dto: request.UpdateUserDTO{
+				Nickname: ptrString("Nick"),
+			},
+			mockErr:        model.ErrAlreadyExists,
+			expectedStatus: http.StatusConflict,
+		},
 		{
 			name:     "server error",
 			nickname: "testNick",
@@ -301,6 +405,15 @@ func TestUserHandler_ChangeData(t *testing.T) {
 			mockErr:        serverErr,
 			expectedStatus: http.StatusInternalServerError,
 		},
+		{
+			name:     "held for review",
+			nickname: "testNick",
+			dto: request.UpdateUserDTO{
+				Likes: ptrInt(25000),
+			},
+			mockErr:        fmt.Errorf("wrapped: %w", &model.QuarantinedError{Change: model.QuarantinedChange{Id: 1, Status: model.QuarantinePending}}),
+			expectedStatus: http.StatusAccepted,
+		},
 	}
 
 	for _, tt := range tests {
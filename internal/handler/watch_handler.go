@@ -0,0 +1,371 @@
+package handler
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"maps"
+	"net/http"
+	"rating/internal/dto/request"
+	responsedto "rating/internal/dto/response"
+	"rating/internal/model"
+	"rating/internal/stream"
+	"rating/internal/tenant"
+	response "rating/internal/transport/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+)
+
+const (
+	watchWriteTimeout  = 10 * time.Second
+	watchLookupTimeout = 5 * time.Second
+	watchPingInterval  = 30 * time.Second
+)
+
+var errUnauthorized = errors.New("missing or invalid token")
+
+type Authenticator interface {
+	Authenticate(r *http.Request) error
+}
+
+type TokenAuth struct {
+	tokens [][]byte
+}
+
+func NewTokenAuth(tokens []string) *TokenAuth {
+	a := &TokenAuth{}
+	for _, token := range tokens {
+		a.tokens = append(a.tokens, []byte(token))
+	}
+
+	return a
+}
+
+// Authenticate accepts a bearer token in the Authorization header or, for
+// browsers that cannot set headers on a WebSocket, the access_token query parameter.
+func (a *TokenAuth) Authenticate(r *http.Request) error {
+	token, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer ")
+	if !ok {
+		token = r.URL.Query().Get("access_token")
+	}
+	if token == "" {
+		return errUnauthorized
+	}
+
+	for _, t := range a.tokens {
+		if subtle.ConstantTimeCompare(t, []byte(token)) == 1 {
+			return nil
+		}
+	}
+
+	return errUnauthorized
+}
+
+// WatchHandler serves WebSocket connections that subscribe to individual
+// users and receive them again whenever their likes, viewers or rating change.
+type WatchHandler struct {
+	service          UserService
+	broker           *stream.Broker
+	auth             Authenticator
+	maxSubscriptions int
+	logger           *slog.Logger
+
+	mu       sync.Mutex
+	conns    map[*websocket.Conn]struct{}
+	shutdown bool
+	active   sync.WaitGroup
+}
+
+// NewWatchHandler creates the handler, a nil auth accepts every connection.
+func NewWatchHandler(service UserService, broker *stream.Broker, auth Authenticator, maxSubscriptions int, log *slog.Logger) *WatchHandler {
+	return &WatchHandler{
+		service:          service,
+		broker:           broker,
+		auth:             auth,
+		maxSubscriptions: maxSubscriptions,
+		logger:           log,
+		conns:            make(map[*websocket.Conn]struct{}),
+	}
+}
+
+func (h *WatchHandler) Watch(w http.ResponseWriter, r *http.Request) {
+	if h.auth != nil {
+		if err := h.auth.Authenticate(r); err != nil {
+			response.ResponseErr(r.Context(), h.logger, w, http.StatusUnauthorized, err.Error())
+			return
+		}
+	}
+
+	sub, err := h.broker.Subscribe(tenant.ID(r.Context()), "")
+	if err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+		return
+	}
+	defer sub.Close()
+
+	// the hijacked connection keeps the deadlines of the server timeouts
+	rc := http.NewResponseController(w)
+	_ = rc.SetReadDeadline(time.Time{})
+	_ = rc.SetWriteDeadline(time.Time{})
+
+	conn, err := websocket.Accept(w, r, nil)
+	if err != nil {
+		h.logger.WarnContext(r.Context(), "watch", slog.Any("failed to accept websocket", err))
+		return
+	}
+	if !h.track(conn) {
+		conn.Close(websocket.StatusGoingAway, "server shutting down")
+		return
+	}
+	defer h.untrack(conn)
+
+	// a hijacked request's context is not canceled when the connection ends
+	ctx, cancel := context.WithCancel(context.WithoutCancel(r.Context()))
+	defer cancel()
+
+	commands := make(chan request.WatchCommand)
+	readErr := make(chan error, 1)
+	go func() {
+		for {
+			var cmd request.WatchCommand
+			if err := wsjson.Read(ctx, conn, &cmd); err != nil {
+				readErr <- err
+				return
+			}
+			select {
+			case commands <- cmd:
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	ping := time.NewTicker(watchPingInterval)
+	defer ping.Stop()
+
+	c := &watchConn{handler: h, conn: conn, watched: make(map[string]*model.User)}
+	for {
+		var err error
+		select {
+		case err := <-readErr:
+			if websocket.CloseStatus(err) == -1 && !errors.Is(err, context.Canceled) {
+				h.logger.DebugContext(ctx, "watch", slog.Any("connection closed", err))
+			}
+			conn.CloseNow()
+			return
+		case cmd := <-commands:
+			err = c.command(ctx, cmd)
+		case event, ok := <-sub.C:
+			if !ok {
+				if sub.Lagged() {
+					conn.Close(websocket.StatusTryAgainLater, "too slow to keep up")
+				} else {
+					conn.Close(websocket.StatusGoingAway, "server shutting down")
+				}
+				return
+			}
+			err = c.event(ctx, event)
+		case <-ping.C:
+			pingCtx, cancel := context.WithTimeout(ctx, watchWriteTimeout)
+			err = conn.Ping(pingCtx)
+			cancel()
+		}
+		if err != nil {
+			conn.CloseNow()
+			return
+		}
+	}
+}
+
+// Shutdown closes every connection with a going away status and waits for
+// their handlers to return. Hijacked connections are not covered by
+// http.Server.Shutdown.
+func (h *WatchHandler) Shutdown(ctx context.Context) error {
+	h.mu.Lock()
+	h.shutdown = true
+	conns := slices.Collect(maps.Keys(h.conns))
+	h.mu.Unlock()
+
+	for _, conn := range conns {
+		go conn.Close(websocket.StatusGoingAway, "server shutting down")
+	}
+
+	done := make(chan struct{})
+	go func() {
+		h.active.Wait()
+		close(done)
+	}()
+
+	select {
+	case <-done:
+		return nil
+	case <-ctx.Done():
+		return ctx.Err()
+	}
+}
+
+func (h *WatchHandler) track(conn *websocket.Conn) bool {
+	h.mu.Lock()
+	defer h.mu.Unlock()
+
+	if h.shutdown {
+		return false
+	}
+	h.conns[conn] = struct{}{}
+	h.active.Add(1)
+
+	return true
+}
+
+func (h *WatchHandler) untrack(conn *websocket.Conn) {
+	h.mu.Lock()
+	delete(h.conns, conn)
+	h.mu.Unlock()
+	h.active.Done()
+}
+
+// watchConn is the state of one connection, owned by its handler goroutine.
+type watchConn struct {
+	handler *WatchHandler
+	conn    *websocket.Conn
+	// watched maps each subscribed nickname to the last user sent, nil while unknown
+	watched map[string]*model.User
+}
+
+func (c *watchConn) command(ctx context.Context, cmd request.WatchCommand) error {
+	if len(cmd.Nicknames) == 0 {
+		return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchError, Error: "nicknames cannot be empty"})
+	}
+
+	switch cmd.Action {
+	case request.WatchSubscribe:
+		var added []string
+		for _, nickname := range cmd.Nicknames {
+			if _, ok := c.watched[nickname]; !ok && nickname != "" && !slices.Contains(added, nickname) {
+				added = append(added, nickname)
+			}
+		}
+		if len(c.watched)+len(added) > c.handler.maxSubscriptions {
+			return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchError, Error: "too many subscriptions"})
+		}
+
+		for _, nickname := range added {
+			c.watched[nickname] = nil
+		}
+		if err := c.sendSubscribed(ctx); err != nil {
+			return err
+		}
+		for _, nickname := range added {
+			if err := c.refresh(ctx, nickname); err != nil {
+				return err
+			}
+		}
+
+		return nil
+	case request.WatchUnsubscribe:
+		for _, nickname := range cmd.Nicknames {
+			delete(c.watched, nickname)
+		}
+
+		return c.sendSubscribed(ctx)
+	default:
+		return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchError, Error: "action must be subscribe or unsubscribe"})
+	}
+}
+
+func (c *watchConn) event(ctx context.Context, event stream.Event) error {
+	switch event.Type {
+	case stream.EventReset:
+		for nickname := range c.watched {
+			if err := c.refresh(ctx, nickname); err != nil {
+				return err
+			}
+		}
+		return nil
+	case stream.EventCreated, stream.EventUpdated, stream.EventDeleted:
+	default:
+		return nil
+	}
+
+	var change stream.UserEvent
+	if err := json.Unmarshal(event.Data, &change); err != nil {
+		c.handler.logger.WarnContext(ctx, "watch", slog.Any("malformed event", err))
+		return nil
+	}
+
+	// follow renames of watched users
+	if change.OldNickname != "" {
+		last, ok := c.watched[change.OldNickname]
+		if !ok {
+			return nil
+		}
+		delete(c.watched, change.OldNickname)
+		c.watched[change.Nickname] = last
+		if err := c.sendSubscribed(ctx); err != nil {
+			return err
+		}
+	}
+
+	last, ok := c.watched[change.Nickname]
+	if !ok {
+		return nil
+	}
+
+	if event.Type == stream.EventDeleted {
+		c.watched[change.Nickname] = nil
+		return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchDeleted, Nickname: change.Nickname})
+	}
+
+	if change.User == nil || (!ratingChanged(last, change.User) && change.OldNickname == "") {
+		return nil
+	}
+
+	return c.sendUser(ctx, change.User)
+}
+
+func (c *watchConn) refresh(ctx context.Context, nickname string) error {
+	lookupCtx, cancel := context.WithTimeout(ctx, watchLookupTimeout)
+	user, err := c.handler.service.GetUser(lookupCtx, nickname)
+	cancel()
+
+	switch {
+	case errors.Is(err, model.ErrNotFound):
+		c.watched[nickname] = nil
+		return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchNotFound, Nickname: nickname})
+	case err != nil:
+		c.handler.logger.ErrorContext(ctx, "watch", slog.Any("failed to load user", err), slog.String("nickname", nickname))
+		return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchError, Nickname: nickname, Error: "failed to load user"})
+	case !ratingChanged(c.watched[nickname], user):
+		return nil
+	}
+
+	return c.sendUser(ctx, user)
+}
+
+func (c *watchConn) sendUser(ctx context.Context, user *model.User) error {
+	c.watched[user.NickName] = user
+	return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchUser, User: user})
+}
+
+func (c *watchConn) sendSubscribed(ctx context.Context) error {
+	nicknames := slices.Sorted(maps.Keys(c.watched))
+	return c.send(ctx, responsedto.WatchMessage{Type: responsedto.WatchSubscribed, Nicknames: nicknames})
+}
+
+func (c *watchConn) send(ctx context.Context, msg responsedto.WatchMessage) error {
+	ctx, cancel := context.WithTimeout(ctx, watchWriteTimeout)
+	defer cancel()
+
+	return wsjson.Write(ctx, c.conn, msg)
+}
+
+func ratingChanged(last, user *model.User) bool {
+	return last == nil || last.Likes != user.Likes || last.Viewers != user.Viewers || last.Rating != user.Rating
+}
@@ -0,0 +1,129 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/dto/request"
+	responsedto "rating/internal/dto/response"
+	"rating/internal/model"
+	"rating/internal/repo/memory"
+	"rating/internal/stream"
+	"rating/internal/tenant"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/coder/websocket"
+	"github.com/coder/websocket/wsjson"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTokenAuth_Authenticate(t *testing.T) {
+	auth := NewTokenAuth([]string{"secret"})
+
+	tests := []struct {
+		name    string
+		header  string
+		query   string
+		wantErr bool
+	}{
+		{name: "bearer header", header: "Bearer secret"},
+		{name: "query parameter", query: "?access_token=secret"},
+		{name: "wrong token", header: "Bearer other", wantErr: true},
+		{name: "missing token", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/users/watch"+tt.query, nil)
+			if tt.header != "" {
+				req.Header.Set("Authorization", tt.header)
+			}
+
+			err := auth.Authenticate(req)
+			if tt.wantErr {
+				require.ErrorIs(t, err, errUnauthorized)
+			} else {
+				require.NoError(t, err)
+			}
+		})
+	}
+}
+
+func TestWatchHandler_Watch(t *testing.T) {
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+
+	repo := memory.NewUserRepo()
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "watched", 1, 2)))
+	broker := stream.NewBroker(10, 10, time.Minute)
+	handler := NewWatchHandler(repoService{repo}, broker, NewTokenAuth([]string{"secret"}), 2, discardLogger)
+
+	server := httptest.NewServer(http.HandlerFunc(handler.Watch))
+	defer server.Close()
+	url := "ws" + strings.TrimPrefix(server.URL, "http")
+
+	t.Run("unauthorized", func(t *testing.T) {
+		_, resp, err := websocket.Dial(ctx, url, nil)
+		require.Error(t, err)
+		require.Equal(t, http.StatusUnauthorized, resp.StatusCode)
+	})
+
+	conn, _, err := websocket.Dial(ctx, url+"?access_token=secret", nil)
+	require.NoError(t, err)
+	defer conn.CloseNow()
+
+	read := func() responsedto.WatchMessage {
+		var msg responsedto.WatchMessage
+		require.NoError(t, wsjson.Read(ctx, conn, &msg))
+		return msg
+	}
+	publish := func(eventType string, event stream.UserEvent) {
+		require.NoError(t, broker.Publish(tenant.Default, eventType, event))
+	}
+
+	require.NoError(t, wsjson.Write(ctx, conn, request.WatchCommand{Action: request.WatchSubscribe, Nicknames: []string{"watched", "missing"}}))
+	require.Equal(t, []string{"missing", "watched"}, read().Nicknames)
+	msg := read()
+	require.Equal(t, responsedto.WatchUser, msg.Type)
+	require.Equal(t, 1, msg.User.Likes)
+	require.Equal(t, responsedto.WatchMessage{Type: responsedto.WatchNotFound, Nickname: "missing"}, read())
+
+	require.NoError(t, wsjson.Write(ctx, conn, request.WatchCommand{Action: request.WatchSubscribe, Nicknames: []string{"third"}}))
+	require.Equal(t, responsedto.WatchError, read().Type)
+
+	// a name change alone is not sent, a likes change is
+	publish(stream.EventUpdated, stream.UserEvent{Nickname: "watched", User: &model.User{NickName: "watched", Name: "new", Likes: 1, Viewers: 2, Rating: 0.5}})
+	publish(stream.EventUpdated, stream.UserEvent{Nickname: "other", User: &model.User{NickName: "other", Likes: 5, Viewers: 5, Rating: 1}})
+	publish(stream.EventUpdated, stream.UserEvent{Nickname: "watched", User: &model.User{NickName: "watched", Likes: 2, Viewers: 2, Rating: 1}})
+	msg = read()
+	require.Equal(t, responsedto.WatchUser, msg.Type)
+	require.Equal(t, 2, msg.User.Likes)
+
+	publish(stream.EventUpdated, stream.UserEvent{Nickname: "renamed", OldNickname: "watched", User: &model.User{NickName: "renamed", Likes: 2, Viewers: 2, Rating: 1}})
+	require.Equal(t, []string{"missing", "renamed"}, read().Nicknames)
+	require.Equal(t, "renamed", read().User.NickName)
+
+	publish(stream.EventDeleted, stream.UserEvent{Nickname: "renamed"})
+	require.Equal(t, responsedto.WatchMessage{Type: responsedto.WatchDeleted, Nickname: "renamed"}, read())
+
+	require.NoError(t, wsjson.Write(ctx, conn, request.WatchCommand{Action: request.WatchUnsubscribe, Nicknames: []string{"renamed"}}))
+	require.Equal(t, []string{"missing"}, read().Nicknames)
+
+	shutdown := make(chan error, 1)
+	go func() { shutdown <- handler.Shutdown(ctx) }()
+
+	_, _, err = conn.Read(ctx)
+	require.Equal(t, websocket.StatusGoingAway, websocket.CloseStatus(err))
+	require.NoError(t, <-shutdown)
+}
+
+// repoService exposes a store through the UserService interface.
+type repoService struct {
+	*memory.UserRepo
+}
+
+func (s repoService) CreateUser(ctx context.Context, dto request.UserRequestDTO) error {
+	return s.Create(ctx, *model.NewUser(dto.Name, dto.Nickname, dto.Likes, dto.Viewers))
+}
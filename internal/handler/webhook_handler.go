@@ -0,0 +1,165 @@
+package handler
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	response "rating/internal/transport/http"
+	"strconv"
+)
+
+type WebhookService interface {
+	CreateSubscription(ctx context.Context, dto request.WebhookSubscriptionDTO) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) ([]model.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error)
+}
+
+type WebhookHandler struct {
+	service WebhookService
+	auth    Authenticator
+	logger  *slog.Logger
+}
+
+// NewWebhookHandler creates the handler; every request must pass auth, as a
+// subscription receives every event of its tenant.
+func NewWebhookHandler(service WebhookService, auth Authenticator, log *slog.Logger) *WebhookHandler {
+	return &WebhookHandler{
+		service: service,
+		auth:    auth,
+		logger:  log,
+	}
+}
+
+func (h *WebhookHandler) CreateSubscription(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	var dto request.WebhookSubscriptionDTO
+	if err := json.NewDecoder(r.Body).Decode(&dto); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid request body")
+		return
+	}
+
+	sub, err := h.service.CreateSubscription(r.Context(), dto)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusCreated, sub)
+}
+
+func (h *WebhookHandler) ListSubscriptions(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	subs, err := h.service.ListSubscriptions(r.Context())
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, subs)
+}
+
+func (h *WebhookHandler) DeleteSubscription(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	id, ok := h.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	if err := h.service.DeleteSubscription(r.Context(), id); err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	w.WriteHeader(http.StatusNoContent)
+}
+
+func (h *WebhookHandler) ListDeliveries(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	id, ok := h.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	param := r.URL.Query()
+	limit, err := strconv.Atoi(param.Get("limit"))
+	if err != nil {
+		limit = 0
+	}
+
+	deliveries, err := h.service.ListDeliveries(r.Context(), id, param.Get("status"), limit)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusOK, deliveries)
+}
+
+// ReplayDelivery queues a delivery again, typically a dead-lettered one.
+func (h *WebhookHandler) ReplayDelivery(w http.ResponseWriter, r *http.Request) {
+	if !h.authenticate(w, r) {
+		return
+	}
+
+	id, ok := h.pathId(w, r)
+	if !ok {
+		return
+	}
+
+	delivery, err := h.service.ReplayDelivery(r.Context(), id)
+	if err != nil {
+		h.error(w, r, err)
+		return
+	}
+
+	response.ResponseJSON(r.Context(), h.logger, w, http.StatusAccepted, delivery)
+}
+
+func (h *WebhookHandler) authenticate(w http.ResponseWriter, r *http.Request) bool {
+	if err := h.auth.Authenticate(r); err != nil {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusUnauthorized, err.Error())
+		return false
+	}
+
+	return true
+}
+
+func (h *WebhookHandler) pathId(w http.ResponseWriter, r *http.Request) (int64, bool) {
+	id, err := strconv.ParseInt(r.PathValue("id"), 10, 64)
+	if err != nil || id < 1 {
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, "invalid id")
+		return 0, false
+	}
+
+	return id, true
+}
+
+func (h *WebhookHandler) error(w http.ResponseWriter, r *http.Request, err error) {
+	switch {
+	case errors.Is(err, model.ErrInvalidInput):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusBadRequest, err.Error())
+	case errors.Is(err, model.ErrNotFound):
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusNotFound, err.Error())
+	default:
+		h.logger.ErrorContext(r.Context(), "webhook handler", slog.Any("error", err))
+		response.ResponseErr(r.Context(), h.logger, w, http.StatusInternalServerError, "internal server error")
+	}
+}
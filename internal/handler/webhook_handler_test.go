@@ -0,0 +1,83 @@
+package handler
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookService struct {
+	created int
+}
+
+func (m *MockWebhookService) CreateSubscription(ctx context.Context, dto request.WebhookSubscriptionDTO) (*model.WebhookSubscription, error) {
+	m.created++
+	return &model.WebhookSubscription{Id: 1, URL: dto.URL}, nil
+}
+
+func (m *MockWebhookService) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	return []model.WebhookSubscription{}, nil
+}
+
+func (m *MockWebhookService) DeleteSubscription(ctx context.Context, id int64) error {
+	return nil
+}
+
+func (m *MockWebhookService) ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) ([]model.WebhookDelivery, error) {
+	return []model.WebhookDelivery{}, nil
+}
+
+func (m *MockWebhookService) ReplayDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	return &model.WebhookDelivery{Id: id}, nil
+}
+
+func TestWebhookHandler_Auth(t *testing.T) {
+	service := &MockWebhookService{}
+	handler := NewWebhookHandler(service, NewTokenAuth([]string{"operator"}), discardLogger)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /webhooks", handler.CreateSubscription)
+	mux.HandleFunc("GET /webhooks", handler.ListSubscriptions)
+	mux.HandleFunc("DELETE /webhooks/{id}", handler.DeleteSubscription)
+	mux.HandleFunc("GET /webhooks/{id}/deliveries", handler.ListDeliveries)
+	mux.HandleFunc("POST /webhooks/deliveries/{id}/replay", handler.ReplayDelivery)
+
+	tests := []struct {
+		name           string
+		method         string
+		target         string
+		token          string
+		expectedStatus int
+	}{
+		{"create without token", http.MethodPost, "/webhooks", "", http.StatusUnauthorized},
+		{"create with a wrong token", http.MethodPost, "/webhooks", "user", http.StatusUnauthorized},
+		{"list without token", http.MethodGet, "/webhooks", "", http.StatusUnauthorized},
+		{"delete without token", http.MethodDelete, "/webhooks/1", "", http.StatusUnauthorized},
+		{"deliveries without token", http.MethodGet, "/webhooks/1/deliveries", "", http.StatusUnauthorized},
+		{"replay without token", http.MethodPost, "/webhooks/deliveries/1/replay", "", http.StatusUnauthorized},
+		{"list", http.MethodGet, "/webhooks", "operator", http.StatusOK},
+		{"create", http.MethodPost, "/webhooks", "operator", http.StatusCreated},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := httptest.NewRecorder()
+			req := httptest.NewRequest(tt.method, tt.target, strings.NewReader(`{"url":"https://partner.example.com/hooks"}`))
+			if tt.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tt.token)
+			}
+
+			mux.ServeHTTP(rec, req)
+
+			require.Equal(t, tt.expectedStatus, rec.Code)
+		})
+	}
+
+	require.Equal(t, 1, service.created)
+}
@@ -0,0 +1,46 @@
+// Package leaderboard materialises the leaderboard into snapshots so rank
+// changes can be reported.
+package leaderboard
+
+import (
+	"context"
+	"log/slog"
+	"rating/internal/model"
+)
+
+type Store interface {
+	TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error)
+	PruneSnapshots(ctx context.Context, keep int) (int64, error)
+}
+
+// Snapshotter takes a snapshot and keeps the newest keep snapshots (all of
+// them when keep is 0). It is run as a scheduled job.
+type Snapshotter struct {
+	store  Store
+	keep   int
+	logger *slog.Logger
+}
+
+func NewSnapshotter(store Store, keep int, log *slog.Logger) *Snapshotter {
+	return &Snapshotter{
+		store:  store,
+		keep:   keep,
+		logger: log,
+	}
+}
+
+func (s *Snapshotter) Run(ctx context.Context) error {
+	snapshot, err := s.store.TakeSnapshot(ctx)
+	if err != nil {
+		return err
+	}
+	s.logger.InfoContext(ctx, "leaderboard snapshot", slog.Int64("id", snapshot.Id), slog.Int("users", snapshot.Users))
+
+	if s.keep > 0 {
+		if _, err := s.store.PruneSnapshots(ctx, s.keep); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
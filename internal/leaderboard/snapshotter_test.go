@@ -0,0 +1,39 @@
+package leaderboard
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"rating/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeStore struct {
+	snapshots []model.LeaderboardSnapshot
+}
+
+func (s *fakeStore) TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error) {
+	snapshot := model.LeaderboardSnapshot{Id: int64(len(s.snapshots) + 1)}
+	s.snapshots = append([]model.LeaderboardSnapshot{snapshot}, s.snapshots...)
+	return &snapshot, nil
+}
+
+func (s *fakeStore) PruneSnapshots(ctx context.Context, keep int) (int64, error) {
+	pruned := max(len(s.snapshots)-keep, 0)
+	s.snapshots = s.snapshots[:len(s.snapshots)-pruned]
+	return int64(pruned), nil
+}
+
+func TestSnapshotter(t *testing.T) {
+	ctx := context.Background()
+	store := &fakeStore{}
+	s := NewSnapshotter(store, 2, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	for range 3 {
+		require.NoError(t, s.Run(ctx))
+	}
+	require.Len(t, store.snapshots, 2)
+	require.Equal(t, int64(3), store.snapshots[0].Id)
+}
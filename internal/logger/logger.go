@@ -11,18 +11,38 @@ const (
 	envProd  = "prod"
 )
 
-func SetupLogger(env string) *slog.Logger {
-	var log *slog.Logger
+const (
+	formatText = "text"
+	formatJSON = "json"
+)
+
+func SetupLogger(env, format string) *slog.Logger {
+	var level slog.Level
 	switch env {
-	case envLocal:
-		log = slog.New(slog.NewTextHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
-	case envDev:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelDebug}))
+	case envLocal, envDev:
+		level = slog.LevelDebug
 	case envProd:
-		log = slog.New(slog.NewJSONHandler(os.Stdout, &slog.HandlerOptions{Level: slog.LevelInfo}))
+		level = slog.LevelInfo
+	default:
+		return slog.New(traceHandler{slog.Default().Handler()})
+	}
+
+	if format == "" {
+		format = formatJSON
+		if env == envLocal {
+			format = formatText
+		}
+	}
+
+	opts := &slog.HandlerOptions{Level: level}
+
+	var handler slog.Handler
+	switch format {
+	case formatText:
+		handler = slog.NewTextHandler(os.Stdout, opts)
 	default:
-		log = slog.Default()
+		handler = slog.NewJSONHandler(os.Stdout, opts)
 	}
 
-	return log
+	return slog.New(traceHandler{handler})
 }
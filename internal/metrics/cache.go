@@ -0,0 +1,54 @@
+package metrics
+
+import (
+	"rating/internal/cache"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+type CacheStatser interface {
+	Stats() cache.Stats
+}
+
+type CacheCollector struct {
+	source    CacheStatser
+	hits      *prometheus.Desc
+	misses    *prometheus.Desc
+	evictions *prometheus.Desc
+	size      *prometheus.Desc
+}
+
+func NewCacheCollector(name string, source CacheStatser) *CacheCollector {
+	labels := prometheus.Labels{"cache": name}
+	desc := func(metric, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "cache", metric), help, nil, labels)
+	}
+
+	return &CacheCollector{
+		source:    source,
+		hits:      desc("hits_total", "Number of cache hits."),
+		misses:    desc("misses_total", "Number of cache misses."),
+		evictions: desc("evictions_total", "Number of entries evicted to respect the size bound."),
+		size:      desc("entries", "Number of entries currently cached."),
+	}
+}
+
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.hits
+	ch <- c.misses
+	ch <- c.evictions
+	ch <- c.size
+}
+
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats := c.source.Stats()
+
+	ch <- prometheus.MustNewConstMetric(c.hits, prometheus.CounterValue, float64(stats.Hits))
+	ch <- prometheus.MustNewConstMetric(c.misses, prometheus.CounterValue, float64(stats.Misses))
+	ch <- prometheus.MustNewConstMetric(c.evictions, prometheus.CounterValue, float64(stats.Evictions))
+	ch <- prometheus.MustNewConstMetric(c.size, prometheus.GaugeValue, float64(stats.Size))
+}
+
+func (m *Metrics) RegisterCache(name string, source CacheStatser) error {
+	return m.Register(NewCacheCollector(name, source))
+}
@@ -0,0 +1,182 @@
+package metrics
+
+import (
+	"context"
+	"log/slog"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "rating"
+
+type Metrics struct {
+	registry        *prometheus.Registry
+	requestsTotal   *prometheus.CounterVec
+	requestDuration *prometheus.HistogramVec
+}
+
+func NewMetrics() *Metrics {
+	registry := prometheus.NewRegistry()
+
+	requestsTotal := prometheus.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "requests_total",
+		Help:      "Total number of HTTP requests by method, route and status.",
+	}, []string{"method", "route", "status"})
+
+	requestDuration := prometheus.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Subsystem: "http",
+		Name:      "request_duration_seconds",
+		Help:      "HTTP request latency by method, route and status.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method", "route", "status"})
+
+	registry.MustRegister(
+		requestsTotal,
+		requestDuration,
+		collectors.NewGoCollector(),
+		collectors.NewProcessCollector(collectors.ProcessCollectorOpts{}),
+	)
+
+	return &Metrics{
+		registry:        registry,
+		requestsTotal:   requestsTotal,
+		requestDuration: requestDuration,
+	}
+}
+
+func (m *Metrics) ObserveRequest(method, route string, status int, duration time.Duration) {
+	if route == "" {
+		route = "unmatched"
+	}
+	code := strconv.Itoa(status)
+	m.requestsTotal.WithLabelValues(method, route, code).Inc()
+	m.requestDuration.WithLabelValues(method, route, code).Observe(duration.Seconds())
+}
+
+func (m *Metrics) Register(c prometheus.Collector) error {
+	return m.registry.Register(c)
+}
+
+func (m *Metrics) RegisterPool(pool *pgxpool.Pool) error {
+	return m.Register(NewPoolCollector(pool))
+}
+
+func (m *Metrics) RegisterUserCounter(counter UserCounter, log *slog.Logger) error {
+	return m.Register(NewUserCollector(counter, log))
+}
+
+func (m *Metrics) Handler() http.Handler {
+	return promhttp.HandlerFor(m.registry, promhttp.HandlerOpts{})
+}
+
+type PoolCollector struct {
+	pool *pgxpool.Pool
+
+	acquiredConns    *prometheus.Desc
+	idleConns        *prometheus.Desc
+	totalConns       *prometheus.Desc
+	maxConns         *prometheus.Desc
+	acquireCount     *prometheus.Desc
+	acquireDuration  *prometheus.Desc
+	emptyAcquire     *prometheus.Desc
+	canceledAcquire  *prometheus.Desc
+	newConns         *prometheus.Desc
+	maxLifetimeClose *prometheus.Desc
+	maxIdleClose     *prometheus.Desc
+}
+
+func NewPoolCollector(pool *pgxpool.Pool) *PoolCollector {
+	desc := func(name, help string) *prometheus.Desc {
+		return prometheus.NewDesc(prometheus.BuildFQName(namespace, "db_pool", name), help, nil, nil)
+	}
+
+	return &PoolCollector{
+		pool:             pool,
+		acquiredConns:    desc("acquired_conns", "Number of currently acquired connections."),
+		idleConns:        desc("idle_conns", "Number of currently idle connections."),
+		totalConns:       desc("total_conns", "Total number of connections in the pool."),
+		maxConns:         desc("max_conns", "Maximum size of the pool."),
+		acquireCount:     desc("acquire_total", "Cumulative count of successful acquires."),
+		acquireDuration:  desc("acquire_duration_seconds_total", "Total time spent acquiring connections."),
+		emptyAcquire:     desc("empty_acquire_total", "Acquires that waited for a connection because the pool was empty."),
+		canceledAcquire:  desc("canceled_acquire_total", "Acquires canceled by their context."),
+		newConns:         desc("new_conns_total", "Cumulative count of new connections opened."),
+		maxLifetimeClose: desc("max_lifetime_destroy_total", "Connections closed because they exceeded MaxConnLifetime."),
+		maxIdleClose:     desc("max_idle_destroy_total", "Connections closed because they exceeded MaxConnIdleTime."),
+	}
+}
+
+func (c *PoolCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.acquiredConns
+	ch <- c.idleConns
+	ch <- c.totalConns
+	ch <- c.maxConns
+	ch <- c.acquireCount
+	ch <- c.acquireDuration
+	ch <- c.emptyAcquire
+	ch <- c.canceledAcquire
+	ch <- c.newConns
+	ch <- c.maxLifetimeClose
+	ch <- c.maxIdleClose
+}
+
+func (c *PoolCollector) Collect(ch chan<- prometheus.Metric) {
+	stat := c.pool.Stat()
+
+	ch <- prometheus.MustNewConstMetric(c.acquiredConns, prometheus.GaugeValue, float64(stat.AcquiredConns()))
+	ch <- prometheus.MustNewConstMetric(c.idleConns, prometheus.GaugeValue, float64(stat.IdleConns()))
+	ch <- prometheus.MustNewConstMetric(c.totalConns, prometheus.GaugeValue, float64(stat.TotalConns()))
+	ch <- prometheus.MustNewConstMetric(c.maxConns, prometheus.GaugeValue, float64(stat.MaxConns()))
+	ch <- prometheus.MustNewConstMetric(c.acquireCount, prometheus.CounterValue, float64(stat.AcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.acquireDuration, prometheus.CounterValue, stat.AcquireDuration().Seconds())
+	ch <- prometheus.MustNewConstMetric(c.emptyAcquire, prometheus.CounterValue, float64(stat.EmptyAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.canceledAcquire, prometheus.CounterValue, float64(stat.CanceledAcquireCount()))
+	ch <- prometheus.MustNewConstMetric(c.newConns, prometheus.CounterValue, float64(stat.NewConnsCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxLifetimeClose, prometheus.CounterValue, float64(stat.MaxLifetimeDestroyCount()))
+	ch <- prometheus.MustNewConstMetric(c.maxIdleClose, prometheus.CounterValue, float64(stat.MaxIdleDestroyCount()))
+}
+
+type UserCounter interface {
+	Count(ctx context.Context) (int, error)
+}
+
+type UserCollector struct {
+	counter    UserCounter
+	logger     *slog.Logger
+	totalUsers *prometheus.Desc
+}
+
+func NewUserCollector(counter UserCounter, log *slog.Logger) *UserCollector {
+	return &UserCollector{
+		counter:    counter,
+		logger:     log,
+		totalUsers: prometheus.NewDesc(prometheus.BuildFQName(namespace, "", "users_total"), "Total number of registered users.", nil, nil),
+	}
+}
+
+func (c *UserCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.totalUsers
+}
+
+func (c *UserCollector) Collect(ch chan<- prometheus.Metric) {
+	ctx, cancel := context.WithTimeout(context.Background(), 2*time.Second)
+	defer cancel()
+
+	total, err := c.counter.Count(ctx)
+	if err != nil {
+		c.logger.ErrorContext(ctx, "metrics", slog.Any("failed to count users", err))
+		ch <- prometheus.NewInvalidMetric(c.totalUsers, err)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.totalUsers, prometheus.GaugeValue, float64(total))
+}
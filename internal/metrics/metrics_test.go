@@ -0,0 +1,84 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+var discardLogger = slog.New(slog.NewTextHandler(io.Discard, nil))
+
+type MockUserCounter struct {
+	Total int
+	Err   error
+}
+
+func (m *MockUserCounter) Count(ctx context.Context) (int, error) {
+	return m.Total, m.Err
+}
+
+func scrape(t *testing.T, m *Metrics) (int, string) {
+	req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	rr := httptest.NewRecorder()
+	m.Handler().ServeHTTP(rr, req)
+
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+
+	return rr.Code, string(body)
+}
+
+func TestMetrics_ObserveRequest(t *testing.T) {
+	m := NewMetrics()
+
+	m.ObserveRequest(http.MethodGet, "GET /users/{nickname}", http.StatusOK, 15*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "GET /users/{nickname}", http.StatusNotFound, 5*time.Millisecond)
+	m.ObserveRequest(http.MethodGet, "", http.StatusNotFound, time.Millisecond)
+
+	status, body := scrape(t, m)
+	require.Equal(t, http.StatusOK, status)
+	require.Contains(t, body, `rating_http_requests_total{method="GET",route="GET /users/{nickname}",status="200"} 1`)
+	require.Contains(t, body, `rating_http_requests_total{method="GET",route="GET /users/{nickname}",status="404"} 1`)
+	require.Contains(t, body, `rating_http_requests_total{method="GET",route="unmatched",status="404"} 1`)
+	require.Contains(t, body, `rating_http_request_duration_seconds_bucket{method="GET",route="GET /users/{nickname}",status="200",le="0.025"} 1`)
+}
+
+func TestMetrics_UserCollector(t *testing.T) {
+	tests := []struct {
+		name     string
+		counter  *MockUserCounter
+		expected string
+		status   int
+	}{
+		{
+			name:     "success",
+			counter:  &MockUserCounter{Total: 42},
+			expected: "rating_users_total 42",
+			status:   http.StatusOK,
+		},
+		{
+			name:     "count error",
+			counter:  &MockUserCounter{Err: errors.New("db down")},
+			expected: "db down",
+			status:   http.StatusInternalServerError,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			m := NewMetrics()
+			require.NoError(t, m.RegisterUserCounter(tt.counter, discardLogger))
+
+			status, body := scrape(t, m)
+			require.Equal(t, tt.status, status)
+			require.Contains(t, body, tt.expected)
+		})
+	}
+}
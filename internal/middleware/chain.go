@@ -1,14 +1,42 @@
 package middleware
 
-import "net/http"
+import (
+	"context"
+	"net/http"
+)
 
 type Middleware func(http.Handler) http.Handler
 
+type patternKey struct{}
+
+// Chain wraps mux in middlewares, the first one outermost. Middlewares that
+// pass r.WithContext inward hand the mux a copy of the request, so the route
+// it matched is recorded in a holder shared through the context; outer
+// middlewares read it with Pattern once the handler returned.
 func Chain(mux http.Handler, middlewares ...Middleware) http.Handler {
-	handler := mux
+	var handler http.Handler = http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mux.ServeHTTP(w, r)
+		if pattern, ok := r.Context().Value(patternKey{}).(*string); ok {
+			*pattern = r.Pattern
+		}
+	})
 	for i := len(middlewares) - 1; i >= 0; i-- {
 		handler = middlewares[i](handler)
 	}
 
-	return handler
+	inner := handler
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var pattern string
+		inner.ServeHTTP(w, r.WithContext(context.WithValue(r.Context(), patternKey{}, &pattern)))
+	})
+}
+
+// Pattern returns the route pattern the mux matched r to, empty before the
+// handler returned or when no route matched.
+func Pattern(r *http.Request) string {
+	if pattern, ok := r.Context().Value(patternKey{}).(*string); ok && *pattern != "" {
+		return *pattern
+	}
+
+	return r.Pattern
 }
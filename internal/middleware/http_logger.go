@@ -29,7 +29,7 @@ func LoggerMiddleware(log *slog.Logger) func(next http.Handler) http.Handler {
 			}
 			start := time.Now()
 			next.ServeHTTP(&responseWriter, r)
-			log.Info("handler log",
+			log.InfoContext(r.Context(), "handler log",
 				slog.String("method", r.Method),
 				slog.String("url", r.URL.Path),
 				slog.Duration("duration", time.Since(start)),
@@ -0,0 +1,21 @@
+package middleware
+
+import (
+	"net/http"
+	"rating/internal/metrics"
+	"time"
+)
+
+func MetricsMiddleware(m *metrics.Metrics) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			responseWriter := responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+			start := time.Now()
+			next.ServeHTTP(&responseWriter, r)
+			m.ObserveRequest(r.Method, Pattern(r), responseWriter.statusCode, time.Since(start))
+		})
+	}
+}
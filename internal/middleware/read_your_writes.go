@@ -0,0 +1,91 @@
+package middleware
+
+import (
+	"math"
+	"net/http"
+	"rating/internal/db"
+	"strconv"
+	"time"
+)
+
+// PrimaryCookie holds the unix time in milliseconds until which the reads of
+// a client go to the primary.
+const PrimaryCookie = "rating_primary_until"
+
+// ReadYourWritesMiddleware sends the reads of a request to the primary once
+// it wrote. A replica may still lag behind for window, so responses to writes
+// also set PrimaryCookie and the client's requests carrying it read from the
+// primary until then. A zero window, as without a replica, sets no cookie.
+func ReadYourWritesMiddleware(window time.Duration) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			ctx := db.WithReadYourWrites(r.Context())
+			if window <= 0 {
+				next.ServeHTTP(w, r.WithContext(ctx))
+				return
+			}
+
+			if sticky(r, window) {
+				ctx = db.ForcePrimary(ctx)
+			}
+			sw := &stickyWriter{ResponseWriter: w, r: r.WithContext(ctx), window: window}
+			next.ServeHTTP(sw, sw.r)
+			sw.stick()
+		})
+	}
+}
+
+// sticky reports whether the request carries a PrimaryCookie still running,
+// ignoring values further away than window.
+func sticky(r *http.Request, window time.Duration) bool {
+	cookie, err := r.Cookie(PrimaryCookie)
+	if err != nil {
+		return false
+	}
+	ms, err := strconv.ParseInt(cookie.Value, 10, 64)
+	if err != nil {
+		return false
+	}
+	until, now := time.UnixMilli(ms), time.Now()
+
+	return until.After(now) && !until.After(now.Add(window))
+}
+
+// stickyWriter sets PrimaryCookie before the response headers are sent when
+// the request wrote.
+type stickyWriter struct {
+	http.ResponseWriter
+	r      *http.Request
+	window time.Duration
+	done   bool
+}
+
+func (w *stickyWriter) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+func (w *stickyWriter) WriteHeader(code int) {
+	w.stick()
+	w.ResponseWriter.WriteHeader(code)
+}
+
+func (w *stickyWriter) Write(b []byte) (int, error) {
+	w.stick()
+	return w.ResponseWriter.Write(b)
+}
+
+func (w *stickyWriter) stick() {
+	if w.done || !db.Wrote(w.r.Context()) {
+		return
+	}
+	w.done = true
+
+	http.SetCookie(w.ResponseWriter, &http.Cookie{
+		Name:     PrimaryCookie,
+		Value:    strconv.FormatInt(time.Now().Add(w.window).UnixMilli(), 10),
+		Path:     "/",
+		MaxAge:   int(math.Ceil(w.window.Seconds())),
+		HttpOnly: true,
+		SameSite: http.SameSiteLaxMode,
+	})
+}
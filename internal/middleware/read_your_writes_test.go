@@ -0,0 +1,70 @@
+package middleware
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/db"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestReadYourWritesMiddleware(t *testing.T) {
+	var forced bool
+	mux := http.NewServeMux()
+	mux.HandleFunc("PATCH /users/{nickname}", func(w http.ResponseWriter, r *http.Request) {
+		db.MarkWrite(r.Context())
+		forced = db.PrimaryForced(r.Context())
+		w.WriteHeader(http.StatusOK)
+	})
+	mux.HandleFunc("GET /users/{nickname}", func(w http.ResponseWriter, r *http.Request) {
+		forced = db.PrimaryForced(r.Context())
+	})
+	handler := Chain(mux, ReadYourWritesMiddleware(5*time.Second))
+
+	serve := func(method string, cookies ...*http.Cookie) *httptest.ResponseRecorder {
+		forced = false
+		req := httptest.NewRequest(method, "/users/nickname", nil)
+		for _, c := range cookies {
+			req.AddCookie(c)
+		}
+		rr := httptest.NewRecorder()
+		handler.ServeHTTP(rr, req)
+		return rr
+	}
+
+	rr := serve(http.MethodGet)
+	require.False(t, forced)
+	require.Empty(t, rr.Result().Cookies())
+
+	rr = serve(http.MethodPatch)
+	require.True(t, forced)
+	cookies := rr.Result().Cookies()
+	require.Len(t, cookies, 1)
+	require.Equal(t, PrimaryCookie, cookies[0].Name)
+	require.Equal(t, 5, cookies[0].MaxAge)
+
+	// the next request of the client reads its write from the primary
+	rr = serve(http.MethodGet, cookies[0])
+	require.True(t, forced)
+	require.Empty(t, rr.Result().Cookies())
+
+	t.Run("expired", func(t *testing.T) {
+		serve(http.MethodGet, &http.Cookie{Name: PrimaryCookie, Value: strconv.FormatInt(time.Now().Add(-time.Second).UnixMilli(), 10)})
+		require.False(t, forced)
+	})
+
+	t.Run("beyond the window", func(t *testing.T) {
+		serve(http.MethodGet, &http.Cookie{Name: PrimaryCookie, Value: strconv.FormatInt(time.Now().Add(time.Hour).UnixMilli(), 10)})
+		require.False(t, forced)
+	})
+
+	t.Run("without a replica", func(t *testing.T) {
+		rr := httptest.NewRecorder()
+		Chain(mux, ReadYourWritesMiddleware(0)).ServeHTTP(rr, httptest.NewRequest(http.MethodPatch, "/users/nickname", nil))
+		require.True(t, forced)
+		require.Empty(t, rr.Result().Cookies())
+	})
+}
@@ -0,0 +1,39 @@
+package middleware
+
+import (
+	"errors"
+	"log/slog"
+	"net/http"
+	"rating/internal/tenant"
+	response "rating/internal/transport/http"
+	"slices"
+)
+
+// TenantMiddleware scopes every request to the tenant resolver finds for it.
+// Requests for the public paths, such as probes and metrics, need none.
+func TenantMiddleware(resolver *tenant.Resolver, log *slog.Logger, public ...string) func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			if slices.Contains(public, r.URL.Path) {
+				next.ServeHTTP(w, r)
+				return
+			}
+
+			id, err := resolver.Resolve(r)
+			if err != nil {
+				status := http.StatusBadRequest
+				if errors.Is(err, tenant.ErrUnauthorized) {
+					status = http.StatusUnauthorized
+				}
+				response.ResponseErr(r.Context(), log, w, status, err.Error())
+				return
+			}
+
+			if id == "" {
+				next.ServeHTTP(w, r)
+				return
+			}
+			next.ServeHTTP(w, r.WithContext(tenant.WithID(r.Context(), id)))
+		})
+	}
+}
@@ -0,0 +1,53 @@
+package middleware
+
+import (
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTenantMiddleware(t *testing.T) {
+	var seen string
+	next := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		seen = tenant.ID(r.Context())
+	})
+
+	tests := []struct {
+		name     string
+		keys     map[string]string
+		path     string
+		headers  map[string]string
+		status   int
+		expected string
+	}{
+		{name: "default without header", path: "/users", status: http.StatusOK, expected: tenant.Default},
+		{name: "header", path: "/users", headers: map[string]string{"X-Tenant-ID": "acme"}, status: http.StatusOK, expected: "acme"},
+		{name: "invalid header", path: "/users", headers: map[string]string{"X-Tenant-ID": "Acme Inc"}, status: http.StatusBadRequest},
+		{name: "api key", keys: map[string]string{"secret": "acme"}, path: "/users", headers: map[string]string{tenant.APIKeyHeader: "secret", "X-Tenant-ID": "other"}, status: http.StatusOK, expected: "acme"},
+		{name: "missing api key", keys: map[string]string{"secret": "acme"}, path: "/users", headers: map[string]string{"X-Tenant-ID": "acme"}, status: http.StatusUnauthorized},
+		{name: "public path", keys: map[string]string{"secret": "acme"}, path: "/healthz", status: http.StatusOK},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			seen = ""
+			handler := Chain(next, TenantMiddleware(tenant.NewResolver(tt.keys, "X-Tenant-ID"), slog.New(slog.DiscardHandler), "/healthz"))
+
+			req := httptest.NewRequest(http.MethodGet, tt.path, nil)
+			for k, v := range tt.headers {
+				req.Header.Set(k, v)
+			}
+			rr := httptest.NewRecorder()
+			handler.ServeHTTP(rr, req)
+
+			require.Equal(t, tt.status, rr.Code)
+			if tt.expected != "" {
+				require.Equal(t, tt.expected, seen)
+			}
+		})
+	}
+}
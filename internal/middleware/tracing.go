@@ -0,0 +1,47 @@
+package middleware
+
+import (
+	"net/http"
+
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	"go.opentelemetry.io/otel/propagation"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+	"go.opentelemetry.io/otel/trace"
+)
+
+const tracerName = "rating/internal/middleware"
+
+func TracingMiddleware() func(next http.Handler) http.Handler {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			tracer := otel.Tracer(tracerName)
+			ctx := otel.GetTextMapPropagator().Extract(r.Context(), propagation.HeaderCarrier(r.Header))
+
+			ctx, span := tracer.Start(ctx, r.Method,
+				trace.WithSpanKind(trace.SpanKindServer),
+				trace.WithAttributes(
+					semconv.HTTPRequestMethodKey.String(r.Method),
+					semconv.URLPath(r.URL.Path),
+				),
+			)
+			defer span.End()
+
+			responseWriter := responseWriter{
+				ResponseWriter: w,
+				statusCode:     http.StatusOK,
+			}
+			r = r.WithContext(ctx)
+			next.ServeHTTP(&responseWriter, r)
+
+			if pattern := Pattern(r); pattern != "" {
+				span.SetName(pattern)
+				span.SetAttributes(semconv.HTTPRoute(pattern))
+			}
+			span.SetAttributes(semconv.HTTPResponseStatusCode(responseWriter.statusCode))
+			if responseWriter.statusCode >= http.StatusInternalServerError {
+				span.SetStatus(codes.Error, http.StatusText(responseWriter.statusCode))
+			}
+		})
+	}
+}
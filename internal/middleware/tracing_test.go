@@ -0,0 +1,79 @@
+package middleware
+
+import (
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/metrics"
+	"rating/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/propagation"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
+	semconv "go.opentelemetry.io/otel/semconv/v1.26.0"
+)
+
+func TestTracingMiddleware(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+	otel.SetTextMapPropagator(propagation.TraceContext{})
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{nickname}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+	})
+	handler := Chain(mux, TracingMiddleware())
+
+	req := httptest.NewRequest(http.MethodGet, "/users/nickname", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	rr := httptest.NewRecorder()
+	handler.ServeHTTP(rr, req)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /users/{nickname}", spans[0].Name())
+	require.Equal(t, "4bf92f3577b34da6a3ce929d0e0e4736", spans[0].SpanContext().TraceID().String())
+	require.Equal(t, "00f067aa0ba902b7", spans[0].Parent().SpanID().String())
+}
+
+func TestChain_RouteReachesOuterMiddlewares(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	provider := sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder))
+	otel.SetTracerProvider(provider)
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /users/{nickname}", func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+	logger := slog.New(slog.DiscardHandler)
+	appMetrics := metrics.NewMetrics()
+	// the same order as the server, with layers that pass a copy of the request inward
+	handler := Chain(
+		mux,
+		RecoveryMiddleware(logger),
+		TracingMiddleware(),
+		LoggerMiddleware(logger),
+		ReadYourWritesMiddleware(time.Second),
+		TenantMiddleware(tenant.NewResolver(nil, "X-Tenant-ID"), logger),
+		MetricsMiddleware(appMetrics),
+	)
+
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/users/nickname", nil))
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 1)
+	require.Equal(t, "GET /users/{nickname}", spans[0].Name())
+	require.Contains(t, spans[0].Attributes(), semconv.HTTPRoute("GET /users/{nickname}"))
+
+	rr := httptest.NewRecorder()
+	appMetrics.Handler().ServeHTTP(rr, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	body, err := io.ReadAll(rr.Body)
+	require.NoError(t, err)
+	require.Contains(t, string(body), `route="GET /users/{nickname}"`)
+}
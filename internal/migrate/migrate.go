@@ -0,0 +1,146 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"io"
+	"io/fs"
+	"rating/internal/tenant"
+	"rating/migrations"
+	sqlitemigrations "rating/migrations/sqlite"
+	"text/tabwriter"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/jackc/pgx/v5/stdlib"
+	"github.com/pressly/goose/v3"
+	"github.com/pressly/goose/v3/lock"
+)
+
+type Runner struct {
+	db       *sql.DB
+	provider *goose.Provider
+	ownsDB   bool
+}
+
+// NewRunner builds a migration runner over the embedded migrations. A Postgres
+// advisory lock is held while migrating so concurrent instances apply them once.
+func NewRunner(pool *pgxpool.Pool) (*Runner, error) {
+	db := stdlib.OpenDBFromPool(pool)
+
+	locker, err := lock.NewPostgresSessionLocker()
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration locker: %w", err)
+	}
+
+	return newRunner(goose.DialectPostgres, db, migrations.FS, true, goose.WithSessionLocker(locker), goose.WithGoMigrations(goMigrations(bindPostgres)...))
+}
+
+// NewSQLiteRunner builds a runner over the embedded SQLite migrations. The
+// database handle stays owned by the caller.
+func NewSQLiteRunner(db *sql.DB) (*Runner, error) {
+	return newRunner(goose.DialectSQLite3, db, sqlitemigrations.FS, false, goose.WithGoMigrations(goMigrations(bindSQLite)...))
+}
+
+func newRunner(dialect goose.Dialect, db *sql.DB, fsys fs.FS, ownsDB bool, opts ...goose.ProviderOption) (*Runner, error) {
+	provider, err := goose.NewProvider(dialect, db, fsys, opts...)
+	if err != nil {
+		return nil, fmt.Errorf("failed to create migration provider: %w", err)
+	}
+
+	return &Runner{
+		db:       db,
+		provider: provider,
+		ownsDB:   ownsDB,
+	}, nil
+}
+
+func (r *Runner) Close() error {
+	if !r.ownsDB {
+		return nil
+	}
+
+	return r.db.Close()
+}
+
+// Up applies every pending migration. Migrations, like the other
+// methods of Runner, run across all tenants.
+func (r *Runner) Up(ctx context.Context) error {
+	ctx = tenant.WithAll(ctx)
+	if _, err := r.provider.Up(ctx); err != nil {
+		return fmt.Errorf("failed to apply migrations: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) Down(ctx context.Context) error {
+	ctx = tenant.WithAll(ctx)
+	if _, err := r.provider.Down(ctx); err != nil {
+		return fmt.Errorf("failed to roll back migration: %w", err)
+	}
+
+	return nil
+}
+
+func (r *Runner) To(ctx context.Context, version int64) error {
+	ctx = tenant.WithAll(ctx)
+	current, err := r.provider.GetDBVersion(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get db version: %w", err)
+	}
+
+	if version >= current {
+		_, err = r.provider.UpTo(ctx, version)
+	} else {
+		_, err = r.provider.DownTo(ctx, version)
+	}
+	if err != nil {
+		return fmt.Errorf("failed to migrate to version %d: %w", version, err)
+	}
+
+	return nil
+}
+
+func (r *Runner) Status(ctx context.Context, w io.Writer) error {
+	ctx = tenant.WithAll(ctx)
+	statuses, err := r.provider.Status(ctx)
+	if err != nil {
+		return fmt.Errorf("failed to get migration status: %w", err)
+	}
+
+	tw := tabwriter.NewWriter(w, 0, 0, 2, ' ', 0)
+	fmt.Fprintln(tw, "VERSION\tSTATE\tAPPLIED AT\tFILE")
+	for _, s := range statuses {
+		appliedAt := "-"
+		if !s.AppliedAt.IsZero() {
+			appliedAt = s.AppliedAt.Format("2006-01-02 15:04:05")
+		}
+		fmt.Fprintf(tw, "%d\t%s\t%s\t%s\n", s.Source.Version, s.State, appliedAt, s.Source.Path)
+	}
+
+	return tw.Flush()
+}
+
+// LatestVersion returns the newest migration version in fsys, which is
+// migrations.FS for Postgres, or of the Go migrations when newer.
+func LatestVersion(fsys fs.FS) (int64, error) {
+	files, err := fs.Glob(fsys, "*.sql")
+	if err != nil {
+		return -1, fmt.Errorf("failed to list migrations: %w", err)
+	}
+
+	var latest int64
+	for _, file := range files {
+		version, err := goose.NumericComponent(file)
+		if err != nil {
+			return -1, fmt.Errorf("invalid migration file name %s: %w", file, err)
+		}
+		latest = max(latest, version)
+	}
+	for _, m := range goMigrations(bindPostgres) {
+		latest = max(latest, m.Version)
+	}
+
+	return latest, nil
+}
@@ -0,0 +1,84 @@
+package migrate
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rating/internal/model"
+	"strconv"
+
+	"github.com/pressly/goose/v3"
+)
+
+// goMigrations are registered with both dialects next to the embedded SQL
+// files. bind renders the nth query placeholder of the dialect.
+func goMigrations(bind func(n int) string) []*goose.Migration {
+	return []*goose.Migration{
+		goose.NewGoMigration(20261019190100,
+			&goose.GoFunc{RunTx: backfillNicknameKeys(bind)},
+			&goose.GoFunc{RunTx: dropNicknameKeyIndexes},
+		),
+	}
+}
+
+func bindPostgres(n int) string { return "$" + strconv.Itoa(n) }
+
+func bindSQLite(int) string { return "?" }
+
+// backfillNicknameKeys fills nickname_key with model.NicknameKey and makes it
+// unique per tenant. Users whose nicknames share a key must be renamed before
+// migrating.
+func backfillNicknameKeys(bind func(n int) string) func(ctx context.Context, tx *sql.Tx) error {
+	return func(ctx context.Context, tx *sql.Tx) error {
+		for _, table := range []string{"users", "nickname_aliases"} {
+			rows, err := tx.QueryContext(ctx, "SELECT DISTINCT nickname FROM "+table)
+			if err != nil {
+				return fmt.Errorf("failed to read %s nicknames: %w", table, err)
+			}
+			var nicknames []string
+			for rows.Next() {
+				var nickname string
+				if err := rows.Scan(&nickname); err != nil {
+					rows.Close()
+					return fmt.Errorf("failed to scan %s nickname: %w", table, err)
+				}
+				nicknames = append(nicknames, nickname)
+			}
+			rows.Close()
+			if err := rows.Err(); err != nil {
+				return fmt.Errorf("rows iteration error: %w", err)
+			}
+
+			query := fmt.Sprintf("UPDATE %s SET nickname_key = %s WHERE nickname = %s", table, bind(1), bind(2))
+			for _, nickname := range nicknames {
+				if _, err := tx.ExecContext(ctx, query, model.NicknameKey(nickname), nickname); err != nil {
+					return fmt.Errorf("failed to backfill %s nickname key: %w", table, err)
+				}
+			}
+		}
+
+		for _, query := range []string{
+			"CREATE UNIQUE INDEX users_tenant_nickname_key_folded ON users (tenant_id, nickname_key)",
+			"CREATE UNIQUE INDEX nickname_aliases_tenant_nickname_key ON nickname_aliases (tenant_id, nickname_key)",
+		} {
+			if _, err := tx.ExecContext(ctx, query); err != nil {
+				return fmt.Errorf("failed to index nickname keys: %w", err)
+			}
+		}
+
+		return nil
+	}
+}
+
+func dropNicknameKeyIndexes(ctx context.Context, tx *sql.Tx) error {
+	for _, query := range []string{
+		"DROP INDEX nickname_aliases_tenant_nickname_key",
+		"DROP INDEX users_tenant_nickname_key_folded",
+	} {
+		if _, err := tx.ExecContext(ctx, query); err != nil {
+			return fmt.Errorf("failed to drop nickname key index: %w", err)
+		}
+	}
+
+	return nil
+}
@@ -0,0 +1,25 @@
+package model
+
+import "time"
+
+// Category groups users into a league with its own leaderboard. Slug is
+// the stable identifier used in URLs and filters.
+type Category struct {
+	Id        int64     `json:"id"`
+	Slug      string    `json:"slug"`
+	Name      string    `json:"name"`
+	Members   int       `json:"members"`
+	CreatedAt time.Time `json:"created_at"`
+}
+
+// CategoryRank is the place of a user in a category, 1 being the top.
+type CategoryRank struct {
+	Category Category `json:"category"`
+	Rank     int      `json:"rank"`
+}
+
+// RankedUser is a leaderboard row.
+type RankedUser struct {
+	Rank int  `json:"rank"`
+	User User `json:"user"`
+}
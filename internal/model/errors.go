@@ -7,4 +7,6 @@ var (
 	ErrInvalidInput  = errors.New("invalid input parameters")
 	ErrAlreadyExists = errors.New("already exists")
 	ErrInvalidSort   = errors.New("invalid sort parameters")
+	ErrConflict      = errors.New("conflict")
+	ErrQuarantined   = errors.New("held for review")
 )
@@ -0,0 +1,23 @@
+package model
+
+import "time"
+
+const (
+	JobRunning   = "running"
+	JobSucceeded = "succeeded"
+	JobFailed    = "failed"
+	JobCancelled = "cancelled"
+)
+
+// JobRun is one execution of a scheduled job. ScheduledAt is the slot the
+// run belongs to, so each job runs at most once per slot across replicas.
+type JobRun struct {
+	Id          int64      `json:"id"`
+	Job         string     `json:"job"`
+	ScheduledAt time.Time  `json:"scheduled_at"`
+	StartedAt   time.Time  `json:"started_at"`
+	FinishedAt  *time.Time `json:"finished_at,omitempty"`
+	Status      string     `json:"status"`
+	Error       string     `json:"error,omitempty"`
+	Instance    string     `json:"instance"`
+}
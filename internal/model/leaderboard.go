@@ -0,0 +1,47 @@
+package model
+
+import "time"
+
+type LeaderboardSnapshot struct {
+	Id      int64     `json:"id"`
+	TakenAt time.Time `json:"taken_at"`
+	Users   int       `json:"users"`
+}
+
+// SnapshotRank is the place of a user in one snapshot, 1 being the top.
+type SnapshotRank struct {
+	SnapshotId int64     `json:"snapshot_id"`
+	TakenAt    time.Time `json:"taken_at"`
+	Rank       int       `json:"rank"`
+	Rating     float64   `json:"rating"`
+}
+
+// RankChange compares the rank of a user in two snapshots. FromRank or
+// ToRank is nil when the user is missing from that snapshot, and Delta is
+// positive when the user moved up.
+type RankChange struct {
+	UserId   int64  `json:"user_id"`
+	Nickname string `json:"nickname"`
+	FromRank *int   `json:"from_rank"`
+	ToRank   *int   `json:"to_rank"`
+	Delta    *int   `json:"delta"`
+}
+
+func NewRankChange(userId int64, nickname string, fromRank, toRank *int) RankChange {
+	change := RankChange{UserId: userId, Nickname: nickname, FromRank: fromRank, ToRank: toRank}
+	if fromRank != nil && toRank != nil {
+		delta := *fromRank - *toRank
+		change.Delta = &delta
+	}
+
+	return change
+}
+
+// RankDelta is the rank of a user in the latest snapshot compared with the
+// one before it.
+type RankDelta struct {
+	Nickname string        `json:"nickname"`
+	Current  SnapshotRank  `json:"current"`
+	Previous *SnapshotRank `json:"previous,omitempty"`
+	Delta    *int          `json:"delta"`
+}
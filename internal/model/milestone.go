@@ -0,0 +1,72 @@
+package model
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+const (
+	MetricLikes   = "likes"
+	MetricViewers = "viewers"
+	MetricRating  = "rating"
+)
+
+// MilestoneRule is reached once the metric of a user is at least Threshold.
+type MilestoneRule struct {
+	Metric    string
+	Threshold float64
+}
+
+// ParseMilestoneRule parses rules written as "metric>=threshold", for example
+// "viewers>=1000" or "rating>=0.9".
+func ParseMilestoneRule(rule string) (MilestoneRule, error) {
+	metric, threshold, ok := strings.Cut(rule, ">=")
+	if !ok {
+		return MilestoneRule{}, fmt.Errorf("milestone rule %q must look like metric>=threshold", rule)
+	}
+
+	r := MilestoneRule{Metric: strings.TrimSpace(metric)}
+	switch r.Metric {
+	case MetricLikes, MetricViewers, MetricRating:
+	default:
+		return MilestoneRule{}, fmt.Errorf("milestone rule %q: metric must be one of likes, viewers, rating", rule)
+	}
+
+	value, err := strconv.ParseFloat(strings.TrimSpace(threshold), 64)
+	if err != nil || value < 0 {
+		return MilestoneRule{}, fmt.Errorf("milestone rule %q: threshold must be a non-negative number", rule)
+	}
+	r.Threshold = value
+
+	return r, nil
+}
+
+// Name identifies the rule; a user reaches each named milestone once.
+func (r MilestoneRule) Name() string {
+	return r.Metric + ">=" + strconv.FormatFloat(r.Threshold, 'f', -1, 64)
+}
+
+func (r MilestoneRule) Value(user User) float64 {
+	switch r.Metric {
+	case MetricLikes:
+		return float64(user.Likes)
+	case MetricViewers:
+		return float64(user.Viewers)
+	default:
+		return user.Rating
+	}
+}
+
+func (r MilestoneRule) Reached(user User) bool {
+	return r.Value(user) >= r.Threshold
+}
+
+type Milestone struct {
+	Name      string    `json:"name"`
+	Metric    string    `json:"metric"`
+	Threshold float64   `json:"threshold"`
+	Value     float64   `json:"value"`
+	ReachedAt time.Time `json:"reached_at"`
+}
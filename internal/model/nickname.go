@@ -0,0 +1,86 @@
+package model
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"golang.org/x/text/cases"
+	"golang.org/x/text/unicode/norm"
+)
+
+// NicknameAlias is a nickname a user gave up by renaming or deletion. Current
+// is the nickname the user has now, empty with a zero UserId once the user is
+// deleted.
+type NicknameAlias struct {
+	Nickname   string    `json:"nickname"`
+	UserId     int64     `json:"-"`
+	Current    string    `json:"-"`
+	ReleasedAt time.Time `json:"released_at"`
+}
+
+// NicknameKey folds nickname for uniqueness checks: NFKC with case folding,
+// so "Alice", "alice" and "ａｌｉｃｅ" share a key.
+func NicknameKey(nickname string) string {
+	return norm.NFKC.String(cases.Fold().String(norm.NFKC.String(nickname)))
+}
+
+// NicknamePolicy decides which nicknames users may pick. The zero policy
+// only rejects empty nicknames.
+type NicknamePolicy struct {
+	minLength int
+	maxLength int
+	charset   *regexp.Regexp
+	reserved  map[string]struct{}
+}
+
+// NewNicknamePolicy builds a policy for nicknames of minLength to maxLength
+// characters, zero meaning unbounded. charset is the body of a regexp
+// character class, for example `\p{L}\p{N}_.-`, empty allowing anything.
+// Reserved nicknames are matched by NicknameKey.
+func NewNicknamePolicy(minLength, maxLength int, charset string, reserved []string) (NicknamePolicy, error) {
+	if minLength < 0 || maxLength < 0 || (maxLength > 0 && minLength > maxLength) {
+		return NicknamePolicy{}, fmt.Errorf("nickname length bounds %d..%d are invalid", minLength, maxLength)
+	}
+
+	p := NicknamePolicy{minLength: minLength, maxLength: maxLength, reserved: make(map[string]struct{}, len(reserved))}
+	if charset != "" {
+		re, err := regexp.Compile("^[" + charset + "]+$")
+		if err != nil {
+			return NicknamePolicy{}, fmt.Errorf("nickname charset %q is invalid: %w", charset, err)
+		}
+		p.charset = re
+	}
+	for _, word := range reserved {
+		p.reserved[NicknameKey(word)] = struct{}{}
+	}
+
+	return p, nil
+}
+
+// Normalize returns nickname trimmed and in NFKC form, or an ErrInvalidInput
+// error when the policy rejects it.
+func (p NicknamePolicy) Normalize(nickname string) (string, error) {
+	nickname = norm.NFKC.String(strings.TrimSpace(nickname))
+	if nickname == "" {
+		return "", fmt.Errorf("%w: nickname cannot be empty", ErrInvalidInput)
+	}
+
+	length := utf8.RuneCountInString(nickname)
+	if length < p.minLength {
+		return "", fmt.Errorf("%w: nickname must be at least %d characters long", ErrInvalidInput, p.minLength)
+	}
+	if p.maxLength > 0 && length > p.maxLength {
+		return "", fmt.Errorf("%w: nickname cannot be longer than %d characters", ErrInvalidInput, p.maxLength)
+	}
+	if p.charset != nil && !p.charset.MatchString(nickname) {
+		return "", fmt.Errorf("%w: nickname %s contains characters that are not allowed", ErrInvalidInput, nickname)
+	}
+	if _, ok := p.reserved[NicknameKey(nickname)]; ok {
+		return "", fmt.Errorf("%w: nickname %s is reserved", ErrInvalidInput, nickname)
+	}
+
+	return nickname, nil
+}
@@ -0,0 +1,42 @@
+package model
+
+import (
+	"fmt"
+	"rating/internal/dto/request"
+	"strings"
+	"time"
+)
+
+const (
+	QuarantinePending  = "pending"
+	QuarantineApproved = "approved"
+	QuarantineRejected = "rejected"
+)
+
+// QuarantinedChange is a user update held for review. Nickname is the
+// current nickname of the user, which may differ from the one the update
+// was sent to.
+type QuarantinedChange struct {
+	Id         int64                 `json:"id"`
+	UserId     int64                 `json:"user_id"`
+	Nickname   string                `json:"nickname"`
+	Change     request.UpdateUserDTO `json:"change"`
+	Reasons    []string              `json:"reasons"`
+	Status     string                `json:"status"`
+	CreatedAt  time.Time             `json:"created_at"`
+	ReviewedAt *time.Time            `json:"reviewed_at,omitempty"`
+}
+
+// QuarantinedError reports that an update was held for review instead of
+// applied. It matches ErrQuarantined.
+type QuarantinedError struct {
+	Change QuarantinedChange
+}
+
+func (e *QuarantinedError) Error() string {
+	return fmt.Sprintf("%s: change %d: %s", ErrQuarantined, e.Change.Id, strings.Join(e.Change.Reasons, "; "))
+}
+
+func (e *QuarantinedError) Is(target error) bool {
+	return target == ErrQuarantined
+}
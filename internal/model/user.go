@@ -9,6 +9,13 @@ type User struct {
 	Rating   float64 `json:"rating"`
 }
 
+// UserCount is the number of users a listing matched. Total is -1 when
+// counting was skipped, Exact is false for estimated or cached counts.
+type UserCount struct {
+	Total int
+	Exact bool
+}
+
 func NewUser(name, nickname string, likes, viewers int) *User {
 	return &User{
 		Name:     name,
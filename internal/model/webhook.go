@@ -0,0 +1,70 @@
+package model
+
+import (
+	"encoding/json"
+	"time"
+)
+
+const (
+	WebhookUserCreated   = "user.created"
+	WebhookUserDeleted   = "user.deleted"
+	WebhookRatingCrossed = "user.rating_crossed"
+	// WebhookRatingChanged is only recorded in the outbox, subscriptions
+	// receive it as WebhookRatingCrossed when it crosses their threshold.
+	WebhookRatingChanged = "user.rating_changed"
+)
+
+const (
+	DeliveryPending   = "pending"
+	DeliveryDelivered = "delivered"
+	DeliveryDead      = "dead"
+)
+
+type WebhookSubscription struct {
+	Id              int64     `json:"id"`
+	URL             string    `json:"url"`
+	EventTypes      []string  `json:"event_types"`
+	Secret          string    `json:"-"`
+	RatingThreshold *float64  `json:"rating_threshold,omitempty"`
+	CreatedAt       time.Time `json:"created_at"`
+	Tenant          string    `json:"-"`
+}
+
+// OutboxEvent is a user change recorded in the same transaction as the change.
+type OutboxEvent struct {
+	Id        int64
+	Tenant    string
+	Type      string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
+
+// RatingChange is the outbox payload of WebhookRatingChanged.
+type RatingChange struct {
+	User      User    `json:"user"`
+	OldRating float64 `json:"old_rating"`
+}
+
+type WebhookDelivery struct {
+	Id             int64      `json:"id"`
+	SubscriptionId int64      `json:"subscription_id"`
+	OutboxId       int64      `json:"outbox_id"`
+	EventType      string     `json:"event_type"`
+	Status         string     `json:"status"`
+	Attempts       int        `json:"attempts"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at"`
+	LastError      *string    `json:"last_error,omitempty"`
+	DeliveredAt    *time.Time `json:"delivered_at,omitempty"`
+	CreatedAt      time.Time  `json:"created_at"`
+}
+
+// PendingDelivery is a delivery claimed for sending with what is needed to send it.
+type PendingDelivery struct {
+	Id        int64
+	EventType string
+	Attempts  int
+	URL       string
+	Secret    string
+	Payload   json.RawMessage
+	CreatedAt time.Time
+}
@@ -0,0 +1,233 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+type category struct {
+	model.Category
+	members map[int64]struct{}
+}
+
+// categoryKey identifies a category: slugs are unique within a tenant.
+type categoryKey struct {
+	tenant string
+	slug   string
+}
+
+// CategoryRepo keeps categories and their members in memory. It registers
+// itself with users so GetAll can filter by category; members that were
+// deleted from users are ignored.
+type CategoryRepo struct {
+	mu         sync.RWMutex
+	users      *UserRepo
+	nextId     int64
+	categories map[categoryKey]*category
+}
+
+func NewCategoryRepo(users *UserRepo) *CategoryRepo {
+	r := &CategoryRepo{
+		users:      users,
+		nextId:     1,
+		categories: make(map[categoryKey]*category),
+	}
+	users.mu.Lock()
+	users.categories = r
+	users.mu.Unlock()
+
+	return r
+}
+
+// memberIds returns the ids of the members of a category, nil when it does
+// not exist.
+func (r *CategoryRepo) memberIds(tenantId, slug string) map[int64]struct{} {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	c, ok := r.categories[categoryKey{tenantId, slug}]
+	if !ok {
+		return nil
+	}
+
+	ids := make(map[int64]struct{}, len(c.members))
+	for id := range c.members {
+		ids[id] = struct{}{}
+	}
+
+	return ids
+}
+
+// members ranks the live members of a category like GetAll sorts users.
+func (r *CategoryRepo) members(ctx context.Context, slug string) ([]model.User, error) {
+	users, _, err := r.users.GetAll(ctx, request.PaginationQuery{Sort: "desc", Limit: math.MaxInt, CountMode: request.CountNone, Category: slug})
+	return users, err
+}
+
+func (r *CategoryRepo) CreateCategory(ctx context.Context, c model.Category) (*model.Category, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := categoryKey{tenant.ID(ctx), c.Slug}
+	if _, ok := r.categories[key]; ok {
+		return nil, fmt.Errorf("%w: category %s already exists", model.ErrAlreadyExists, c.Slug)
+	}
+
+	c.Id = r.nextId
+	c.Members = 0
+	c.CreatedAt = time.Now().UTC()
+	r.nextId++
+	r.categories[key] = &category{Category: c, members: make(map[int64]struct{})}
+
+	return &c, nil
+}
+
+func (r *CategoryRepo) ListCategories(ctx context.Context) ([]model.Category, error) {
+	id := tenant.ID(ctx)
+
+	r.mu.RLock()
+	slugs := make([]string, 0, len(r.categories))
+	for key := range r.categories {
+		if key.tenant == id {
+			slugs = append(slugs, key.slug)
+		}
+	}
+	r.mu.RUnlock()
+	slices.Sort(slugs)
+
+	categories := make([]model.Category, 0, len(slugs))
+	for _, slug := range slugs {
+		c, err := r.GetCategory(ctx, slug)
+		if err != nil {
+			// deleted since the slugs were listed
+			continue
+		}
+		categories = append(categories, *c)
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepo) GetCategory(ctx context.Context, slug string) (*model.Category, error) {
+	r.mu.RLock()
+	c, ok := r.categories[categoryKey{tenant.ID(ctx), slug}]
+	var result model.Category
+	if ok {
+		result = c.Category
+	}
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	members, err := r.members(ctx, slug)
+	if err != nil {
+		return nil, err
+	}
+	result.Members = len(members)
+
+	return &result, nil
+}
+
+func (r *CategoryRepo) UpdateCategory(ctx context.Context, slug, name string) (*model.Category, error) {
+	r.mu.Lock()
+	c, ok := r.categories[categoryKey{tenant.ID(ctx), slug}]
+	if ok {
+		c.Name = name
+	}
+	r.mu.Unlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	return r.GetCategory(ctx, slug)
+}
+
+func (r *CategoryRepo) DeleteCategory(ctx context.Context, slug string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := categoryKey{tenant.ID(ctx), slug}
+	if _, ok := r.categories[key]; !ok {
+		return fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+	delete(r.categories, key)
+
+	return nil
+}
+
+func (r *CategoryRepo) AddMember(ctx context.Context, slug string, userId int64) error {
+	if _, ok := r.users.nicknameById(tenant.ID(ctx), userId); !ok {
+		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.categories[categoryKey{tenant.ID(ctx), slug}]
+	if !ok {
+		return fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+	c.members[userId] = struct{}{}
+
+	return nil
+}
+
+func (r *CategoryRepo) RemoveMember(ctx context.Context, slug string, userId int64) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	c, ok := r.categories[categoryKey{tenant.ID(ctx), slug}]
+	if !ok {
+		return fmt.Errorf("%w: user is not in category %s", model.ErrNotFound, slug)
+	}
+	if _, ok := c.members[userId]; !ok {
+		return fmt.Errorf("%w: user is not in category %s", model.ErrNotFound, slug)
+	}
+	delete(c.members, userId)
+
+	return nil
+}
+
+func (r *CategoryRepo) UserCategories(ctx context.Context, userId int64) ([]model.CategoryRank, error) {
+	id := tenant.ID(ctx)
+
+	r.mu.RLock()
+	var categories []model.Category
+	for key, c := range r.categories {
+		if key.tenant != id {
+			continue
+		}
+		if _, ok := c.members[userId]; ok {
+			categories = append(categories, c.Category)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(categories, func(a, b model.Category) int {
+		return strings.Compare(a.Slug, b.Slug)
+	})
+
+	ranks := make([]model.CategoryRank, 0, len(categories))
+	for _, c := range categories {
+		members, err := r.members(ctx, c.Slug)
+		if err != nil {
+			return nil, err
+		}
+		i := slices.IndexFunc(members, func(u model.User) bool { return u.Id == userId })
+		if i < 0 {
+			continue
+		}
+		c.Members = len(members)
+		ranks = append(ranks, model.CategoryRank{Category: c, Rank: i + 1})
+	}
+
+	return ranks, nil
+}
@@ -0,0 +1,93 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/model"
+	"sync"
+	"time"
+)
+
+type jobSlot struct {
+	job         string
+	scheduledAt time.Time
+}
+
+type JobRunRepo struct {
+	mu     sync.RWMutex
+	nextId int64
+	runs   []model.JobRun
+	slots  map[jobSlot]struct{}
+}
+
+func NewJobRunRepo() *JobRunRepo {
+	return &JobRunRepo{
+		nextId: 1,
+		slots:  make(map[jobSlot]struct{}),
+	}
+}
+
+func (r *JobRunRepo) StartRun(ctx context.Context, run model.JobRun) (*model.JobRun, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	slot := jobSlot{job: run.Job, scheduledAt: run.ScheduledAt.UTC()}
+	if _, ok := r.slots[slot]; ok {
+		return nil, fmt.Errorf("%w: job %s already ran for %s", model.ErrConflict, run.Job, run.ScheduledAt.Format(time.RFC3339))
+	}
+	r.slots[slot] = struct{}{}
+
+	run.Id = r.nextId
+	r.nextId++
+	r.runs = append(r.runs, run)
+
+	return &run, nil
+}
+
+func (r *JobRunRepo) FinishRun(ctx context.Context, run model.JobRun) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	for i := range r.runs {
+		if r.runs[i].Id == run.Id {
+			r.runs[i].FinishedAt, r.runs[i].Status, r.runs[i].Error = run.FinishedAt, run.Status, run.Error
+			return nil
+		}
+	}
+
+	return fmt.Errorf("%w: job run not found", model.ErrNotFound)
+}
+
+// ListRuns returns the newest runs first, of every job when job is empty.
+func (r *JobRunRepo) ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	runs := make([]model.JobRun, 0)
+	for i := len(r.runs) - 1; i >= 0 && len(runs) < limit; i-- {
+		if job == "" || r.runs[i].Job == job {
+			runs = append(runs, r.runs[i])
+		}
+	}
+
+	return runs, nil
+}
+
+// PruneRuns deletes runs started before the given time.
+func (r *JobRunRepo) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	kept := r.runs[:0]
+	for _, run := range r.runs {
+		if run.StartedAt.Before(before) {
+			delete(r.slots, jobSlot{job: run.Job, scheduledAt: run.ScheduledAt.UTC()})
+			continue
+		}
+		kept = append(kept, run)
+	}
+	pruned := int64(len(r.runs) - len(kept))
+	r.runs = kept
+
+	return pruned, nil
+}
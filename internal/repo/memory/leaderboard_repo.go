@@ -0,0 +1,184 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"math"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
+	"sync"
+	"time"
+)
+
+type leaderboardEntry struct {
+	userId   int64
+	nickname string
+	rating   float64
+	rank     int
+}
+
+// leaderboardSnapshot holds the entries of each tenant by user id.
+type leaderboardSnapshot struct {
+	model.LeaderboardSnapshot
+	entries map[string]map[int64]leaderboardEntry
+}
+
+type LeaderboardRepo struct {
+	mu        sync.RWMutex
+	users     *UserRepo
+	nextId    int64
+	snapshots []leaderboardSnapshot
+}
+
+func NewLeaderboardRepo(users *UserRepo) *LeaderboardRepo {
+	return &LeaderboardRepo{
+		users:  users,
+		nextId: 1,
+	}
+}
+
+// TakeSnapshot stores the rank of every user within its tenant, ranked like
+// GetAll sorts them.
+func (r *LeaderboardRepo) TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error) {
+	snapshot := leaderboardSnapshot{
+		LeaderboardSnapshot: model.LeaderboardSnapshot{TakenAt: time.Now().UTC()},
+		entries:             make(map[string]map[int64]leaderboardEntry),
+	}
+	for _, id := range r.users.tenants() {
+		users, _, err := r.users.GetAll(tenant.WithID(ctx, id), request.PaginationQuery{Sort: "desc", Limit: math.MaxInt, CountMode: request.CountNone})
+		if err != nil {
+			return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+		}
+
+		entries := make(map[int64]leaderboardEntry, len(users))
+		for i, user := range users {
+			entries[user.Id] = leaderboardEntry{userId: user.Id, nickname: user.NickName, rating: user.Rating, rank: i + 1}
+		}
+		snapshot.entries[id] = entries
+		snapshot.Users += len(users)
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	snapshot.Id = r.nextId
+	r.nextId++
+	r.snapshots = append(r.snapshots, snapshot)
+
+	return &snapshot.LeaderboardSnapshot, nil
+}
+
+// ListSnapshots reports the number of users of the tenant in each snapshot.
+func (r *LeaderboardRepo) ListSnapshots(ctx context.Context, limit int) ([]model.LeaderboardSnapshot, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	id := tenant.ID(ctx)
+	snapshots := make([]model.LeaderboardSnapshot, 0, min(limit, len(r.snapshots)))
+	for i := len(r.snapshots) - 1; i >= 0 && len(snapshots) < limit; i-- {
+		snapshot := r.snapshots[i].LeaderboardSnapshot
+		snapshot.Users = len(r.snapshots[i].entries[id])
+		snapshots = append(snapshots, snapshot)
+	}
+
+	return snapshots, nil
+}
+
+// DiffSnapshots returns the users whose rank differs between the snapshots,
+// including users present in only one of them, ordered by their new rank.
+func (r *LeaderboardRepo) DiffSnapshots(ctx context.Context, from, to int64, limit int) ([]model.RankChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	fromSnapshot, err := r.find(from)
+	if err != nil {
+		return nil, err
+	}
+	toSnapshot, err := r.find(to)
+	if err != nil {
+		return nil, err
+	}
+
+	fromEntries, toEntries := fromSnapshot.entries[tenant.ID(ctx)], toSnapshot.entries[tenant.ID(ctx)]
+
+	changes := make([]model.RankChange, 0)
+	for id, entry := range toEntries {
+		toRank := entry.rank
+		if before, ok := fromEntries[id]; ok {
+			if before.rank != entry.rank {
+				fromRank := before.rank
+				changes = append(changes, model.NewRankChange(id, entry.nickname, &fromRank, &toRank))
+			}
+			continue
+		}
+		changes = append(changes, model.NewRankChange(id, entry.nickname, nil, &toRank))
+	}
+	for id, entry := range fromEntries {
+		if _, ok := toEntries[id]; !ok {
+			fromRank := entry.rank
+			changes = append(changes, model.NewRankChange(id, entry.nickname, &fromRank, nil))
+		}
+	}
+
+	slices.SortFunc(changes, func(a, b model.RankChange) int {
+		if c := compareRank(a.ToRank, b.ToRank); c != 0 {
+			return c
+		}
+		return compareRank(a.FromRank, b.FromRank)
+	})
+
+	return changes[:min(limit, len(changes))], nil
+}
+
+// compareRank orders ranks ascending with missing ranks last.
+func compareRank(a, b *int) int {
+	switch {
+	case a == nil && b == nil:
+		return 0
+	case a == nil:
+		return 1
+	case b == nil:
+		return -1
+	default:
+		return cmp.Compare(*a, *b)
+	}
+}
+
+func (r *LeaderboardRepo) UserRank(ctx context.Context, userId, snapshotId int64) (*model.SnapshotRank, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	snapshot, err := r.find(snapshotId)
+	if err != nil {
+		return nil, err
+	}
+	entry, ok := snapshot.entries[tenant.ID(ctx)][userId]
+	if !ok {
+		return nil, fmt.Errorf("%w: user is not in leaderboard snapshot %d", model.ErrNotFound, snapshotId)
+	}
+
+	return &model.SnapshotRank{SnapshotId: snapshotId, TakenAt: snapshot.TakenAt, Rank: entry.rank, Rating: entry.rating}, nil
+}
+
+// PruneSnapshots deletes all but the newest keep snapshots.
+func (r *LeaderboardRepo) PruneSnapshots(ctx context.Context, keep int) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	pruned := max(len(r.snapshots)-keep, 0)
+	r.snapshots = slices.Delete(r.snapshots, 0, pruned)
+
+	return int64(pruned), nil
+}
+
+func (r *LeaderboardRepo) find(id int64) (leaderboardSnapshot, error) {
+	i := slices.IndexFunc(r.snapshots, func(s leaderboardSnapshot) bool { return s.Id == id })
+	if i < 0 {
+		return leaderboardSnapshot{}, fmt.Errorf("%w: leaderboard snapshot not found", model.ErrNotFound)
+	}
+
+	return r.snapshots[i], nil
+}
@@ -0,0 +1,66 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"rating/internal/model"
+	"slices"
+	"sync"
+)
+
+// MilestoneRepo keeps reached milestones per user id. Milestones of deleted
+// users are never listed because ids are not reused.
+type MilestoneRepo struct {
+	mu         sync.RWMutex
+	milestones map[int64]map[string]model.Milestone
+}
+
+func NewMilestoneRepo() *MilestoneRepo {
+	return &MilestoneRepo{
+		milestones: make(map[int64]map[string]model.Milestone),
+	}
+}
+
+func (r *MilestoneRepo) RecordMilestones(ctx context.Context, userId int64, milestones []model.Milestone) ([]model.Milestone, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	reached, ok := r.milestones[userId]
+	if !ok {
+		reached = make(map[string]model.Milestone)
+		r.milestones[userId] = reached
+	}
+
+	var recorded []model.Milestone
+	for _, m := range milestones {
+		if _, ok := reached[m.Name]; ok {
+			continue
+		}
+		reached[m.Name] = m
+		recorded = append(recorded, m)
+	}
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		for _, m := range recorded {
+			delete(r.milestones[userId], m.Name)
+		}
+	})
+
+	return recorded, nil
+}
+
+func (r *MilestoneRepo) ListMilestones(ctx context.Context, userId int64) ([]model.Milestone, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	milestones := make([]model.Milestone, 0, len(r.milestones[userId]))
+	for _, m := range r.milestones[userId] {
+		milestones = append(milestones, m)
+	}
+	slices.SortFunc(milestones, func(a, b model.Milestone) int {
+		return cmp.Or(a.ReachedAt.Compare(b.ReachedAt), cmp.Compare(a.Name, b.Name))
+	})
+
+	return milestones, nil
+}
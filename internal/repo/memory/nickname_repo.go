@@ -0,0 +1,107 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
+	"sync"
+	"time"
+)
+
+// NicknameRepo keeps released nicknames in memory. Current nicknames are
+// resolved through users, aliases of deleted users lose their user like the
+// ON DELETE SET NULL of the SQL stores. Aliases are
+// keyed by NicknameKey, like nickname_key in the SQL stores.
+type NicknameRepo struct {
+	mu      sync.RWMutex
+	users   *UserRepo
+	aliases map[userKey]model.NicknameAlias
+}
+
+func NewNicknameRepo(users *UserRepo) *NicknameRepo {
+	return &NicknameRepo{
+		users:   users,
+		aliases: make(map[userKey]model.NicknameAlias),
+	}
+}
+
+// RecordRename releases from, taking it over from whichever user released it
+// before.
+func (r *NicknameRepo) RecordRename(ctx context.Context, userId int64, from string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := newUserKey(tenant.ID(ctx), from)
+	before, existed := r.aliases[key]
+	r.aliases[key] = model.NicknameAlias{Nickname: from, UserId: userId, ReleasedAt: time.Now().UTC()}
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if existed {
+			r.aliases[key] = before
+		} else {
+			delete(r.aliases, key)
+		}
+	})
+
+	return nil
+}
+
+func (r *NicknameRepo) GetAlias(ctx context.Context, nickname string) (*model.NicknameAlias, error) {
+	r.mu.RLock()
+	alias, ok := r.aliases[newUserKey(tenant.ID(ctx), nickname)]
+	r.mu.RUnlock()
+	if !ok {
+		return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+	// ids are not reused, a user that is gone was deleted
+	if current, ok := r.users.nicknameById(tenant.ID(ctx), alias.UserId); ok {
+		alias.Current = current
+	} else {
+		alias.UserId = 0
+	}
+
+	return &alias, nil
+}
+
+func (r *NicknameRepo) ListAliases(ctx context.Context, userId int64) ([]model.NicknameAlias, error) {
+	current, ok := r.users.nicknameById(tenant.ID(ctx), userId)
+	if !ok {
+		return []model.NicknameAlias{}, nil
+	}
+
+	r.mu.RLock()
+	aliases := make([]model.NicknameAlias, 0)
+	for key, alias := range r.aliases {
+		if key.tenant == tenant.ID(ctx) && alias.UserId == userId {
+			alias.Current = current
+			aliases = append(aliases, alias)
+		}
+	}
+	r.mu.RUnlock()
+	slices.SortFunc(aliases, func(a, b model.NicknameAlias) int {
+		return cmp.Or(b.ReleasedAt.Compare(a.ReleasedAt), cmp.Compare(a.Nickname, b.Nickname))
+	})
+
+	return aliases, nil
+}
+
+// PurgeDeleted deletes the nicknames released by deleted users before the
+// given time, in every tenant.
+func (r *NicknameRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	var purged int64
+	for key, alias := range r.aliases {
+		if _, ok := r.users.nicknameById(key.tenant, alias.UserId); !ok && alias.ReleasedAt.Before(before) {
+			delete(r.aliases, key)
+			purged++
+		}
+	}
+
+	return purged, nil
+}
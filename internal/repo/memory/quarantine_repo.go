@@ -0,0 +1,133 @@
+package memory
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
+	"sync"
+	"time"
+)
+
+// QuarantineRepo keeps held changes in memory. Nicknames are resolved through
+// users so renamed users are reported under their current nickname.
+type QuarantineRepo struct {
+	mu      sync.RWMutex
+	users   *UserRepo
+	nextId  int64
+	changes []model.QuarantinedChange
+}
+
+func NewQuarantineRepo(users *UserRepo) *QuarantineRepo {
+	return &QuarantineRepo{
+		users:  users,
+		nextId: 1,
+	}
+}
+
+func (r *QuarantineRepo) Quarantine(ctx context.Context, change model.QuarantinedChange) (*model.QuarantinedChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	change.Id = r.nextId
+	change.Status = model.QuarantinePending
+	change.CreatedAt = time.Now().UTC()
+	change.ReviewedAt = nil
+	r.nextId++
+	r.changes = append(r.changes, change)
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if i, err := r.find(change.Id); err == nil {
+			r.changes = slices.Delete(r.changes, i, i+1)
+		}
+	})
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	changes := make([]model.QuarantinedChange, 0)
+	for _, change := range r.changes {
+		if len(changes) == limit {
+			break
+		}
+		if status != "" && change.Status != status {
+			continue
+		}
+		if change, ok := r.withNickname(ctx, change); ok {
+			changes = append(changes, change)
+		}
+	}
+
+	return changes, nil
+}
+
+func (r *QuarantineRepo) GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	i, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	change, ok := r.withNickname(ctx, r.changes[i])
+	if !ok {
+		return nil, fmt.Errorf("%w: quarantined change not found", model.ErrNotFound)
+	}
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ResolveQuarantined(ctx context.Context, id int64, status string) (*model.QuarantinedChange, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	i, err := r.find(id)
+	if err != nil {
+		return nil, err
+	}
+	change, ok := r.withNickname(ctx, r.changes[i])
+	if !ok {
+		return nil, fmt.Errorf("%w: quarantined change not found", model.ErrNotFound)
+	}
+	if change.Status != model.QuarantinePending {
+		return nil, fmt.Errorf("%w: change %d is already %s", model.ErrConflict, id, change.Status)
+	}
+
+	before := r.changes[i]
+	reviewedAt := time.Now().UTC()
+	change.Status, change.ReviewedAt = status, &reviewedAt
+	r.changes[i] = change
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		if i, err := r.find(id); err == nil {
+			r.changes[i] = before
+		}
+	})
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) find(id int64) (int, error) {
+	i := slices.IndexFunc(r.changes, func(c model.QuarantinedChange) bool { return c.Id == id })
+	if i < 0 {
+		return -1, fmt.Errorf("%w: quarantined change not found", model.ErrNotFound)
+	}
+
+	return i, nil
+}
+
+// withNickname fills in the current nickname and reports false once the user
+// is gone, mirroring the cascading delete of the SQL stores, or belongs to
+// another tenant than ctx.
+func (r *QuarantineRepo) withNickname(ctx context.Context, change model.QuarantinedChange) (model.QuarantinedChange, bool) {
+	nickname, ok := r.users.nicknameById(tenant.ID(ctx), change.UserId)
+	change.Nickname = nickname
+	return change, ok
+}
@@ -0,0 +1,71 @@
+package memory
+
+import (
+	"context"
+	"rating/internal/service"
+	"sync"
+)
+
+// TxManager serialises transactions and, when fn fails, undoes the writes fn
+// made through the memory repos, newest first. Only the keys fn touched are
+// restored, so writes made outside WithinTx meanwhile are kept, but they are
+// not isolated from a running transaction.
+type TxManager struct {
+	mu   sync.Mutex
+	repo *UserRepo
+}
+
+func NewTxManager(repo *UserRepo) *TxManager {
+	return &TxManager{
+		repo: repo,
+	}
+}
+
+type txKey struct{}
+
+// undoLog holds the inverse of every write made inside a transaction.
+type undoLog struct {
+	mu    sync.Mutex
+	steps []func()
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	if ctx.Value(txKey{}) != nil {
+		return fn(ctx, m.repo)
+	}
+
+	m.mu.Lock()
+	defer m.mu.Unlock()
+
+	log := &undoLog{}
+	if err := fn(context.WithValue(ctx, txKey{}, log), m.repo); err != nil {
+		log.rollback()
+		return err
+	}
+
+	return nil
+}
+
+// onRollback registers undo to run when the transaction carried by ctx fails.
+// It is called with the lock of the writing repo held, undo takes it again.
+// Writes outside a transaction record nothing.
+func onRollback(ctx context.Context, undo func()) {
+	log, ok := ctx.Value(txKey{}).(*undoLog)
+	if !ok {
+		return
+	}
+
+	log.mu.Lock()
+	defer log.mu.Unlock()
+	log.steps = append(log.steps, undo)
+}
+
+func (l *undoLog) rollback() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	for i := len(l.steps) - 1; i >= 0; i-- {
+		l.steps[i]()
+	}
+	l.steps = nil
+}
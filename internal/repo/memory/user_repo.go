@@ -0,0 +1,258 @@
+package memory
+
+import (
+	"cmp"
+	"context"
+	"fmt"
+	"maps"
+	"math"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
+	"sync"
+)
+
+// userKey identifies a user by the NicknameKey of its nickname, which is
+// unique within a tenant like the index on nickname_key.
+type userKey struct {
+	tenant      string
+	nicknameKey string
+}
+
+func newUserKey(tenantId, nickname string) userKey {
+	return userKey{tenantId, model.NicknameKey(nickname)}
+}
+
+type UserRepo struct {
+	mu         sync.RWMutex
+	nextId     int64
+	users      map[userKey]model.User
+	categories *CategoryRepo
+}
+
+func NewUserRepo() *UserRepo {
+	return &UserRepo{
+		nextId: 1,
+		users:  make(map[userKey]model.User),
+	}
+}
+
+// rating mirrors the generated column: ROUND(likes / viewers, 3), 0 without viewers.
+func rating(likes, viewers int) float64 {
+	if viewers <= 0 {
+		return 0
+	}
+
+	return math.Round(float64(likes)/float64(viewers)*1000) / 1000
+}
+
+func validate(likes, viewers int) error {
+	if likes < 0 || viewers < 0 || likes > viewers {
+		return fmt.Errorf("%w: likes can't be more than viewers or negative", model.ErrInvalidInput)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) Create(ctx context.Context, user model.User) error {
+	if err := validate(user.Likes, user.Viewers); err != nil {
+		return err
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := newUserKey(tenant.ID(ctx), user.NickName)
+	if _, ok := r.users[key]; ok {
+		return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, user.NickName)
+	}
+
+	user.Id = r.nextId
+	user.Rating = rating(user.Likes, user.Viewers)
+	r.nextId++
+	r.users[key] = user
+	// ids are not handed out again, like a sequence
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.deleteIf(key, user.Id)
+	})
+
+	return nil
+}
+
+func (r *UserRepo) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
+	id := tenant.ID(ctx)
+
+	r.mu.RLock()
+	users := make([]model.User, 0, len(r.users))
+	for key, u := range r.users {
+		if key.tenant == id {
+			users = append(users, u)
+		}
+	}
+	categories := r.categories
+	r.mu.RUnlock()
+
+	if params.Category != "" {
+		var members map[int64]struct{}
+		if categories != nil {
+			members = categories.memberIds(id, params.Category)
+		}
+		users = slices.DeleteFunc(users, func(u model.User) bool {
+			_, ok := members[u.Id]
+			return !ok
+		})
+	}
+
+	slices.SortFunc(users, func(a, b model.User) int {
+		var c int
+		switch params.Sort {
+		case "desc":
+			c = cmp.Compare(b.Rating, a.Rating)
+		case "asc":
+			c = cmp.Compare(a.Rating, b.Rating)
+		}
+		if c != 0 {
+			return c
+		}
+		return cmp.Compare(a.Id, b.Id)
+	})
+
+	start := min(max(params.Offset, 0), len(users))
+	end := min(start+max(params.Limit, 0), len(users))
+
+	if params.CountMode == request.CountNone {
+		return users[start:end], model.UserCount{Total: -1}, nil
+	}
+
+	return users[start:end], model.UserCount{Total: len(users), Exact: true}, nil
+}
+
+func (r *UserRepo) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	user, ok := r.users[newUserKey(tenant.ID(ctx), nickname)]
+	if !ok {
+		return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	return &user, nil
+}
+
+// GetUserForUpdate is GetUser, TxManager runs one transaction at a time.
+func (r *UserRepo) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	return r.GetUser(ctx, nickname)
+}
+
+func (r *UserRepo) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := newUserKey(tenant.ID(ctx), nickname)
+	user, ok := r.users[key]
+	if !ok {
+		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+	before := user
+
+	if dto.Name != nil {
+		user.Name = *dto.Name
+	}
+	if dto.Likes != nil {
+		user.Likes = *dto.Likes
+	}
+	if dto.Viewers != nil {
+		user.Viewers = *dto.Viewers
+	}
+	if dto.Nickname != nil {
+		user.NickName = *dto.Nickname
+	}
+
+	if err := validate(user.Likes, user.Viewers); err != nil {
+		return fmt.Errorf("%w: likes can't be more than viewers", model.ErrInvalidInput)
+	}
+
+	renamed := newUserKey(key.tenant, user.NickName)
+	if renamed != key {
+		if _, ok := r.users[renamed]; ok {
+			return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, user.NickName)
+		}
+		delete(r.users, key)
+	}
+
+	user.Rating = rating(user.Likes, user.Viewers)
+	r.users[renamed] = user
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.deleteIf(renamed, before.Id)
+		r.users[key] = before
+	})
+
+	return nil
+}
+
+func (r *UserRepo) Delete(ctx context.Context, nickname string) error {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	key := newUserKey(tenant.ID(ctx), nickname)
+	user, ok := r.users[key]
+	if !ok {
+		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+	delete(r.users, key)
+	onRollback(ctx, func() {
+		r.mu.Lock()
+		defer r.mu.Unlock()
+		r.users[key] = user
+	})
+
+	return nil
+}
+
+// deleteIf removes key while it still holds the user with id, an undo must not
+// drop a user written outside the transaction since.
+func (r *UserRepo) deleteIf(key userKey, id int64) {
+	if user, ok := r.users[key]; ok && user.Id == id {
+		delete(r.users, key)
+	}
+}
+
+// Count returns the number of users across all tenants.
+func (r *UserRepo) Count(ctx context.Context) (int, error) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	return len(r.users), nil
+}
+
+// nicknameById reports false when the user is gone or belongs to another tenant.
+func (r *UserRepo) nicknameById(tenantId string, id int64) (string, bool) {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	for key, user := range r.users {
+		if user.Id == id {
+			return user.NickName, key.tenant == tenantId
+		}
+	}
+
+	return "", false
+}
+
+// tenants returns every tenant that has users, sorted.
+func (r *UserRepo) tenants() []string {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+
+	seen := make(map[string]struct{})
+	for key := range r.users {
+		seen[key.tenant] = struct{}{}
+	}
+
+	return slices.Sorted(maps.Keys(seen))
+}
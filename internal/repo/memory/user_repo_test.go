@@ -0,0 +1,175 @@
+package memory
+
+import (
+	"context"
+	"errors"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/repo/storetest"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepo_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) service.UserStore {
+		return NewUserRepo()
+	})
+}
+
+func TestTxManager_WithinTx(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepo()
+	tm := NewTxManager(repo)
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+
+	t.Run("rollback on error", func(t *testing.T) {
+		rollbackErr := errors.New("rollback")
+		err := tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+			require.NoError(t, store.Create(ctx, *model.NewUser("name", "created", 1, 2)))
+			require.NoError(t, store.Delete(ctx, "nickname"))
+			return rollbackErr
+		})
+		require.ErrorIs(t, err, rollbackErr)
+
+		_, err = repo.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		_, err = repo.GetUser(ctx, "created")
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("commit", func(t *testing.T) {
+		err := tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+			return store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)})
+		})
+		require.NoError(t, err)
+
+		user, err := repo.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 2, user.Likes)
+	})
+}
+
+func TestTxManager_RollbackTouchedKeys(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepo()
+	milestones := NewMilestoneRepo()
+	quarantine := NewQuarantineRepo(repo)
+	nicknames := NewNicknameRepo(repo)
+	tm := NewTxManager(repo)
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+	user, err := repo.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	held, err := quarantine.Quarantine(ctx, model.QuarantinedChange{UserId: user.Id})
+	require.NoError(t, err)
+
+	rollbackErr := errors.New("rollback")
+	err = tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+		require.NoError(t, store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed"), Likes: ptrInt(2)}))
+		require.NoError(t, nicknames.RecordRename(ctx, user.Id, "nickname"))
+		_, err := milestones.RecordMilestones(ctx, user.Id, []model.Milestone{{Name: "first"}})
+		require.NoError(t, err)
+		_, err = quarantine.Quarantine(ctx, model.QuarantinedChange{UserId: user.Id})
+		require.NoError(t, err)
+		_, err = quarantine.ResolveQuarantined(ctx, held.Id, model.QuarantineApproved)
+		require.NoError(t, err)
+
+		// written outside the transaction, must survive the rollback
+		require.NoError(t, repo.Create(context.Background(), *model.NewUser("name", "outside", 1, 2)))
+		return rollbackErr
+	})
+	require.ErrorIs(t, err, rollbackErr)
+
+	restored, err := repo.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, *user, *restored)
+	_, err = repo.GetUser(ctx, "renamed")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = repo.GetUser(ctx, "outside")
+	require.NoError(t, err)
+
+	_, err = nicknames.GetAlias(ctx, "nickname")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	reached, err := milestones.ListMilestones(ctx, user.Id)
+	require.NoError(t, err)
+	require.Empty(t, reached)
+	pending, err := quarantine.ListQuarantined(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, model.QuarantinePending, pending[0].Status)
+}
+
+func TestCategoryRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepo()
+	categories := NewCategoryRepo(repo)
+	svc := service.NewCategoryService(categories, repo)
+
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "first", 90, 100)))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "second", 50, 100)))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "outsider", 99, 100)))
+	_, err := svc.CreateCategory(ctx, request.CategoryDTO{Slug: "music", Name: "Music"})
+	require.NoError(t, err)
+	require.NoError(t, svc.AddMember(ctx, "music", "second"))
+	require.NoError(t, svc.AddMember(ctx, "music", "first"))
+
+	params := request.NewPaginationQuery(10, 0, "desc")
+	params.Category = "music"
+	users, total, err := repo.GetAll(ctx, params)
+	require.NoError(t, err)
+	require.Equal(t, 2, total.Total)
+	require.Equal(t, "first", users[0].NickName)
+
+	require.NoError(t, repo.Delete(ctx, "first"))
+	ranks, err := svc.UserCategories(ctx, "second")
+	require.NoError(t, err)
+	require.Len(t, ranks, 1)
+	require.Equal(t, 1, ranks[0].Rank)
+	require.Equal(t, 1, ranks[0].Category.Members)
+}
+
+func ptrString(s string) *string { return &s }
+func ptrInt(i int) *int          { return &i }
+
+func TestNicknameRepo(t *testing.T) {
+	ctx := context.Background()
+	repo := NewUserRepo()
+	nicknames := NewNicknameRepo(repo)
+	svc := service.NewUserService(repo, service.WithTxManager(NewTxManager(repo)), service.WithNicknameHistory(nicknames, time.Hour))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "first", Likes: 1, Viewers: 10}))
+	renamed := "second"
+	require.NoError(t, svc.ChangeData(ctx, "first", request.UpdateUserDTO{Nickname: &renamed}))
+
+	alias, err := nicknames.GetAlias(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, "second", alias.Current)
+
+	_, err = nicknames.GetAlias(tenant.WithID(ctx, "acme"), "first")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, svc.Delete(ctx, "second"))
+	for _, nickname := range []string{"first", "second"} {
+		alias, err := nicknames.GetAlias(ctx, nickname)
+		require.NoError(t, err)
+		require.Equal(t, model.NicknameAlias{Nickname: nickname, ReleasedAt: alias.ReleasedAt}, *alias)
+
+		_, err = svc.ResolveNickname(ctx, nickname)
+		require.ErrorIs(t, err, model.ErrNotFound)
+		err = svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: nickname, Likes: 1, Viewers: 10})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+	}
+
+	purged, err := nicknames.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Zero(t, purged)
+
+	purged, err = nicknames.PurgeDeleted(ctx, time.Now().Add(time.Second))
+	require.NoError(t, err)
+	require.Equal(t, int64(2), purged)
+	_, err = nicknames.GetAlias(ctx, "first")
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,65 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const unlockTimeout = 5 * time.Second
+
+// AdvisoryLocker elects a single replica per name with session level
+// advisory locks. The lock is held on a dedicated pool connection for as
+// long as the caller keeps it, and is released if that connection dies.
+type AdvisoryLocker struct {
+	pool   *pgxpool.Pool
+	prefix string
+	logger *slog.Logger
+}
+
+// NewAdvisoryLocker namespaces lock names with prefix so they do not collide
+// with other users of advisory locks in the same database.
+func NewAdvisoryLocker(pool *pgxpool.Pool, prefix string, log *slog.Logger) *AdvisoryLocker {
+	return &AdvisoryLocker{
+		pool:   pool,
+		prefix: prefix,
+		logger: log,
+	}
+}
+
+func (l *AdvisoryLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	key := l.prefix + name
+
+	conn, err := l.pool.Acquire(ctx)
+	if err != nil {
+		return nil, false, fmt.Errorf("failed to acquire connection: %w", err)
+	}
+
+	var acquired bool
+	if err := conn.QueryRow(ctx, "SELECT pg_try_advisory_lock(hashtextextended($1, 0))", key).Scan(&acquired); err != nil {
+		conn.Release()
+		return nil, false, fmt.Errorf("failed to take advisory lock: %w", err)
+	}
+	if !acquired {
+		conn.Release()
+		return nil, false, nil
+	}
+
+	unlock := func() {
+		ctx, cancel := context.WithTimeout(context.Background(), unlockTimeout)
+		defer cancel()
+
+		if _, err := conn.Exec(ctx, "SELECT pg_advisory_unlock(hashtextextended($1, 0))", key); err != nil {
+			// Closing the connection is the only other way to drop the lock
+			// before it goes back to the pool.
+			l.logger.WarnContext(ctx, "advisory lock", slog.String("key", key), slog.Any("failed to unlock", err))
+			conn.Conn().Close(ctx)
+		}
+		conn.Release()
+	}
+
+	return unlock, true, nil
+}
@@ -0,0 +1,39 @@
+package postgres
+
+import (
+	"context"
+	"io"
+	"log/slog"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestAdvisoryLocker(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	first := NewAdvisoryLocker(pool, "test:", log)
+	second := NewAdvisoryLocker(pool, "test:", log)
+
+	unlock, acquired, err := first.TryLock(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, acquired)
+
+	_, acquired, err = second.TryLock(ctx, "job")
+	require.NoError(t, err)
+	require.False(t, acquired)
+
+	otherUnlock, acquired, err := second.TryLock(ctx, "other")
+	require.NoError(t, err)
+	require.True(t, acquired)
+	otherUnlock()
+
+	unlock()
+	unlock, acquired, err = second.TryLock(ctx, "job")
+	require.NoError(t, err)
+	require.True(t, acquired)
+	unlock()
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const categoryQuery = `SELECT c.id, c.slug, c.name, c.created_at,
+	(SELECT COUNT(*) FROM user_categories uc WHERE uc.category_id = c.id)
+	FROM categories c`
+
+type CategoryRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewCategoryRepo(pool *pgxpool.Pool) *CategoryRepo {
+	return &CategoryRepo{
+		pool: pool,
+	}
+}
+
+func scanCategory(row pgx.Row) (model.Category, error) {
+	var c model.Category
+	err := row.Scan(&c.Id, &c.Slug, &c.Name, &c.CreatedAt, &c.Members)
+	return c, err
+}
+
+func (r *CategoryRepo) CreateCategory(ctx context.Context, category model.Category) (*model.Category, error) {
+	query := "INSERT INTO categories (tenant_id, slug, name) VALUES ($1, $2, $3) RETURNING id, created_at"
+
+	err := r.pool.QueryRow(ctx, query, tenant.ID(ctx), category.Slug, category.Name).Scan(&category.Id, &category.CreatedAt)
+	if err != nil {
+		var pgxErr *pgconn.PgError
+		if errors.As(err, &pgxErr) && pgxErr.Code == "23505" {
+			return nil, fmt.Errorf("%w: category %s already exists", model.ErrAlreadyExists, category.Slug)
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepo) ListCategories(ctx context.Context) ([]model.Category, error) {
+	rows, err := r.pool.Query(ctx, categoryQuery+" WHERE c.tenant_id = $1 ORDER BY c.slug", tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+
+	categories, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Category, error) {
+		return scanCategory(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan categories: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepo) GetCategory(ctx context.Context, slug string) (*model.Category, error) {
+	category, err := scanCategory(r.pool.QueryRow(ctx, categoryQuery+" WHERE c.tenant_id = $1 AND c.slug = $2", tenant.ID(ctx), slug))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepo) UpdateCategory(ctx context.Context, slug, name string) (*model.Category, error) {
+	cmdTag, err := r.pool.Exec(ctx, "UPDATE categories SET name = $3 WHERE tenant_id = $1 AND slug = $2", tenant.ID(ctx), slug, name)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	return r.GetCategory(ctx, slug)
+}
+
+func (r *CategoryRepo) DeleteCategory(ctx context.Context, slug string) error {
+	cmdTag, err := r.pool.Exec(ctx, "DELETE FROM categories WHERE tenant_id = $1 AND slug = $2", tenant.ID(ctx), slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepo) AddMember(ctx context.Context, slug string, userId int64) error {
+	query := `INSERT INTO user_categories (user_id, category_id) SELECT $3, id FROM categories WHERE tenant_id = $1 AND slug = $2
+		ON CONFLICT DO NOTHING RETURNING category_id`
+
+	var categoryId int64
+	err := r.pool.QueryRow(ctx, query, tenant.ID(ctx), slug, userId).Scan(&categoryId)
+	if err != nil {
+		var pgxErr *pgconn.PgError
+		switch {
+		case errors.As(err, &pgxErr) && pgxErr.Code == "23503":
+			return fmt.Errorf("%w: user not found", model.ErrNotFound)
+		case errors.Is(err, pgx.ErrNoRows):
+			// either the category is missing or the user already belongs to it
+			if _, err := r.GetCategory(ctx, slug); err != nil {
+				return err
+			}
+			return nil
+		}
+		return fmt.Errorf("failed to add category member: %w", err)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepo) RemoveMember(ctx context.Context, slug string, userId int64) error {
+	query := "DELETE FROM user_categories WHERE user_id = $3 AND category_id = (SELECT id FROM categories WHERE tenant_id = $1 AND slug = $2)"
+
+	cmdTag, err := r.pool.Exec(ctx, query, tenant.ID(ctx), slug, userId)
+	if err != nil {
+		return fmt.Errorf("failed to remove category member: %w", err)
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: user is not in category %s", model.ErrNotFound, slug)
+	}
+
+	return nil
+}
+
+// UserCategories ranks the user within each of its categories with the
+// leaderboard order used by GetAll.
+func (r *CategoryRepo) UserCategories(ctx context.Context, userId int64) ([]model.CategoryRank, error) {
+	query := `SELECT c.id, c.slug, c.name, c.created_at, ranked.members, ranked.rank FROM (
+			SELECT uc.category_id, uc.user_id,
+				ROW_NUMBER() OVER (PARTITION BY uc.category_id ORDER BY ` + leaderboardOrder + `) AS rank,
+				COUNT(*) OVER (PARTITION BY uc.category_id) AS members
+			FROM user_categories uc JOIN users ON users.id = uc.user_id
+			WHERE uc.category_id IN (SELECT category_id FROM user_categories WHERE user_id = $1)
+		) ranked JOIN categories c ON c.id = ranked.category_id
+		WHERE ranked.user_id = $1
+		ORDER BY c.slug`
+
+	rows, err := r.pool.Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user categories: %w", err)
+	}
+
+	ranks, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.CategoryRank, error) {
+		var rank model.CategoryRank
+		err := row.Scan(&rank.Category.Id, &rank.Category.Slug, &rank.Category.Name, &rank.Category.CreatedAt, &rank.Category.Members, &rank.Rank)
+		return rank, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan user categories: %w", err)
+	}
+
+	return ranks, nil
+}
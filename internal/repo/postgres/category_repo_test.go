@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRepo(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	repo := NewUserRepo(pool)
+	categories := NewCategoryRepo(pool)
+	svc := service.NewCategoryService(categories, repo)
+
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "first", 90, 100)))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "second", 50, 100)))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "outsider", 99, 100)))
+
+	_, err := svc.CreateCategory(ctx, request.CategoryDTO{Slug: "music", Name: "Music"})
+	require.NoError(t, err)
+	_, err = svc.CreateCategory(ctx, request.CategoryDTO{Slug: "music", Name: "Music"})
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+	require.NoError(t, svc.AddMember(ctx, "music", "second"))
+	require.NoError(t, svc.AddMember(ctx, "music", "first"))
+	require.NoError(t, svc.AddMember(ctx, "music", "first"))
+	require.ErrorIs(t, svc.AddMember(ctx, "missing", "first"), model.ErrNotFound)
+
+	users, total, err := svc.Leaderboard(ctx, "music", 0, 0)
+	require.NoError(t, err)
+	require.Equal(t, 2, total)
+	require.Len(t, users, 2)
+	require.Equal(t, "first", users[0].User.NickName)
+	require.Equal(t, 2, users[1].Rank)
+
+	ranks, err := svc.UserCategories(ctx, "second")
+	require.NoError(t, err)
+	require.Len(t, ranks, 1)
+	require.Equal(t, 2, ranks[0].Rank)
+	require.Equal(t, 2, ranks[0].Category.Members)
+
+	require.NoError(t, svc.RemoveMember(ctx, "music", "second"))
+	require.ErrorIs(t, svc.RemoveMember(ctx, "music", "second"), model.ErrNotFound)
+	require.NoError(t, svc.DeleteCategory(ctx, "music"))
+	ranks, err = svc.UserCategories(ctx, "first")
+	require.NoError(t, err)
+	require.Empty(t, ranks)
+}
@@ -0,0 +1,164 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	exactCountQuery     = "SELECT COUNT(*) FROM users WHERE tenant_id = $1"
+	estimatedCountQuery = "SELECT reltuples::bigint FROM pg_class WHERE oid = 'users'::regclass"
+	categoryCountQuery  = "SELECT COUNT(*) FROM user_categories WHERE category_id = (SELECT id FROM categories WHERE tenant_id = $1 AND slug = $2)"
+)
+
+// CountCache keeps exact user counts per tenant refreshed in the background
+// so list requests in cached mode do not scan the table.
+type CountCache struct {
+	repo     *UserRepo
+	interval time.Duration
+	logger   *slog.Logger
+	counts   atomic.Pointer[map[string]int]
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func NewCountCache(repo *UserRepo, interval time.Duration, log *slog.Logger) *CountCache {
+	c := &CountCache{
+		repo:     repo,
+		interval: interval,
+		logger:   log,
+	}
+
+	return c
+}
+
+func (c *CountCache) Start(ctx context.Context) {
+	ctx, c.cancel = context.WithCancel(ctx)
+	c.done = make(chan struct{})
+
+	go func() {
+		defer close(c.done)
+		c.Refresh(ctx)
+
+		ticker := time.NewTicker(c.interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				c.Refresh(ctx)
+			}
+		}
+	}()
+}
+
+func (c *CountCache) Stop() {
+	if c.cancel == nil {
+		return
+	}
+	c.cancel()
+	<-c.done
+}
+
+func (c *CountCache) Refresh(ctx context.Context) error {
+	counts, err := c.repo.countByTenant(ctx)
+	if err != nil {
+		c.logger.WarnContext(ctx, "count cache", slog.Any("failed to refresh user count", err))
+		return err
+	}
+	c.counts.Store(&counts)
+
+	return nil
+}
+
+// Load returns the cached count of a tenant, false until the first refresh
+// succeeded.
+func (c *CountCache) Load(id string) (int, bool) {
+	counts := c.counts.Load()
+	if counts == nil {
+		return -1, false
+	}
+
+	return (*counts)[id], true
+}
+
+func (r *UserRepo) countByTenant(ctx context.Context) (map[string]int, error) {
+	ctx = tenant.WithAll(ctx)
+	counts := make(map[string]int)
+	err := r.read(ctx, func(q querier) error {
+		rows, err := q.Query(ctx, "SELECT tenant_id, COUNT(*) FROM users GROUP BY tenant_id")
+		if err != nil {
+			return err
+		}
+		defer rows.Close()
+
+		for rows.Next() {
+			var id string
+			var count int
+			if err := rows.Scan(&id, &count); err != nil {
+				return err
+			}
+			counts[id] = count
+		}
+		return rows.Err()
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to count users per tenant: %w", err)
+	}
+
+	return counts, nil
+}
+
+func (r *UserRepo) totalCount(ctx context.Context, q querier, params request.PaginationQuery) (model.UserCount, error) {
+	// members of a category are always counted exactly
+	if params.Category != "" && params.CountMode != request.CountNone {
+		var totalCount int
+		if err := q.QueryRow(ctx, categoryCountQuery, tenant.ID(ctx), params.Category).Scan(&totalCount); err != nil {
+			return model.UserCount{Total: -1}, fmt.Errorf("failed to get total count users: %w", err)
+		}
+		return model.UserCount{Total: totalCount, Exact: true}, nil
+	}
+
+	mode := params.CountMode
+	if _, named := tenant.FromContext(ctx); named && mode == request.CountEstimated {
+		// estimates cover the whole table, only requests that name no tenant,
+		// as in a single tenant deployment, may use them
+		mode = request.CountCached
+	}
+
+	switch mode {
+	case request.CountNone:
+		return model.UserCount{Total: -1}, nil
+	case request.CountCached:
+		if r.countCache != nil {
+			if total, ok := r.countCache.Load(tenant.ID(ctx)); ok {
+				return model.UserCount{Total: total}, nil
+			}
+		}
+	case request.CountEstimated:
+		var estimate int64
+		if err := q.QueryRow(ctx, estimatedCountQuery).Scan(&estimate); err != nil {
+			return model.UserCount{Total: -1}, fmt.Errorf("failed to get estimated count users: %w", err)
+		}
+		// reltuples is -1 until the table has been vacuumed or analyzed
+		if estimate >= 0 {
+			return model.UserCount{Total: int(estimate)}, nil
+		}
+	}
+
+	// cached and estimated counts fall back to an exact one when unavailable
+	var totalCount int
+	if err := q.QueryRow(ctx, exactCountQuery, tenant.ID(ctx)).Scan(&totalCount); err != nil {
+		return model.UserCount{Total: -1}, fmt.Errorf("failed to get total count users: %w", err)
+	}
+
+	return model.UserCount{Total: totalCount, Exact: true}, nil
+}
@@ -0,0 +1,56 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestUserRepo_GetAllCountModes(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	cache := NewCountCache(NewUserRepo(pool), time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	repo := NewUserRepo(pool, WithCountCache(cache))
+	for i := range 3 {
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", fmt.Sprintf("nickname%d", i), 1, 2)))
+	}
+
+	params := request.NewPaginationQuery(10, 0, "")
+
+	t.Run("cached before refresh falls back to exact", func(t *testing.T) {
+		params.CountMode = request.CountCached
+		_, total, err := repo.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, model.UserCount{Total: 3, Exact: true}, total)
+	})
+
+	t.Run("cached after refresh", func(t *testing.T) {
+		require.NoError(t, cache.Refresh(ctx))
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", "late", 1, 2)))
+
+		params.CountMode = request.CountCached
+		_, total, err := repo.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, model.UserCount{Total: 3}, total)
+	})
+
+	t.Run("estimated after analyze", func(t *testing.T) {
+		_, err := pool.Exec(ctx, "ANALYZE users")
+		require.NoError(t, err)
+
+		params.CountMode = request.CountEstimated
+		_, total, err := repo.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, model.UserCount{Total: 4}, total)
+	})
+}
@@ -0,0 +1,97 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/event"
+	"rating/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// EventOutbox stores domain events in the event_outbox table. Append joins the
+// transaction carried by ctx, so events commit together with the write.
+type EventOutbox struct {
+	pool *pgxpool.Pool
+}
+
+func NewEventOutbox(pool *pgxpool.Pool) *EventOutbox {
+	return &EventOutbox{
+		pool: pool,
+	}
+}
+
+func (o *EventOutbox) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return o.pool
+}
+
+// Append records events under the tenant of ctx.
+func (o *EventOutbox) Append(ctx context.Context, events ...event.Event) error {
+	batch := &pgx.Batch{}
+	for _, e := range events {
+		batch.Queue("INSERT INTO event_outbox (tenant_id, event_type, nickname, payload, occurred_at) VALUES ($1, $2, $3, $4, $5)", tenant.ID(ctx), e.Type, e.Nickname, e.Data, e.OccurredAt)
+	}
+
+	if err := o.conn(ctx).SendBatch(ctx, batch).Close(); err != nil {
+		return fmt.Errorf("failed to append events: %w", err)
+	}
+
+	return nil
+}
+
+// Relay locks the oldest unpublished events without skipping locked rows, so
+// concurrent relays wait for each other instead of publishing an event twice.
+// Ids are taken on insert, not on commit: an event can leave after one with a
+// higher id whose transaction committed first.
+func (o *EventOutbox) Relay(ctx context.Context, limit int, fn func([]event.Event) error) (int, error) {
+	var relayed int
+	err := pgx.BeginFunc(ctx, o.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT id, tenant_id, event_type, nickname, payload, occurred_at FROM event_outbox WHERE published_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE", limit)
+		if err != nil {
+			return fmt.Errorf("failed to read event outbox: %w", err)
+		}
+		events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (event.Event, error) {
+			var e event.Event
+			err := row.Scan(&e.Id, &e.Tenant, &e.Type, &e.Nickname, &e.Data, &e.OccurredAt)
+			return e, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan event outbox: %w", err)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		if err := fn(events); err != nil {
+			return fmt.Errorf("failed to publish events: %w", err)
+		}
+
+		ids := make([]int64, 0, len(events))
+		for _, e := range events {
+			ids = append(ids, e.Id)
+		}
+		if _, err := tx.Exec(ctx, "UPDATE event_outbox SET published_at = now() WHERE id = ANY($1)", ids); err != nil {
+			return fmt.Errorf("failed to mark events published: %w", err)
+		}
+		relayed = len(events)
+
+		return nil
+	})
+
+	return relayed, err
+}
+
+func (o *EventOutbox) Prune(ctx context.Context, before time.Time) (int64, error) {
+	cmdTag, err := o.pool.Exec(ctx, "DELETE FROM event_outbox WHERE published_at < $1", before)
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune event outbox: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,52 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"rating/internal/dto/request"
+	"rating/internal/event"
+	"rating/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventOutbox(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	tx, err := NewTxManager(pool, "", 3)
+	require.NoError(t, err)
+	outbox := NewEventOutbox(pool)
+	svc := service.NewUserService(NewUserRepo(pool), service.WithTxManager(tx), service.WithEventOutbox(outbox))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 2}))
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)}))
+	require.NoError(t, svc.Delete(ctx, "nickname"))
+	require.Error(t, svc.Delete(ctx, "nickname"))
+
+	publishErr := errors.New("publish failed")
+	_, err = outbox.Relay(ctx, 10, func([]event.Event) error { return publishErr })
+	require.ErrorIs(t, err, publishErr)
+
+	var types []string
+	n, err := outbox.Relay(ctx, 10, func(events []event.Event) error {
+		for _, e := range events {
+			types = append(types, e.Type)
+		}
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 4, n)
+	require.Equal(t, []string{event.TypeUserCreated, event.TypeUserUpdated, event.TypeRatingChanged, event.TypeUserDeleted}, types)
+
+	n, err = outbox.Relay(ctx, 10, func([]event.Event) error { return nil })
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	pruned, err := outbox.Prune(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(4), pruned)
+}
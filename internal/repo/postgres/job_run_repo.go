@@ -0,0 +1,78 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type JobRunRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewJobRunRepo(pool *pgxpool.Pool) *JobRunRepo {
+	return &JobRunRepo{
+		pool: pool,
+	}
+}
+
+func (r *JobRunRepo) StartRun(ctx context.Context, run model.JobRun) (*model.JobRun, error) {
+	query := `INSERT INTO job_runs (job, scheduled_at, started_at, status, instance) VALUES ($1, $2, $3, $4, $5)
+		ON CONFLICT (job, scheduled_at) DO NOTHING RETURNING id`
+
+	err := r.pool.QueryRow(ctx, query, run.Job, run.ScheduledAt, run.StartedAt, run.Status, run.Instance).Scan(&run.Id)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: job %s already ran for %s", model.ErrConflict, run.Job, run.ScheduledAt.Format(time.RFC3339))
+		}
+		return nil, fmt.Errorf("failed to start job run: %w", err)
+	}
+
+	return &run, nil
+}
+
+func (r *JobRunRepo) FinishRun(ctx context.Context, run model.JobRun) error {
+	_, err := r.pool.Exec(ctx, "UPDATE job_runs SET finished_at = $2, status = $3, error = $4 WHERE id = $1", run.Id, run.FinishedAt, run.Status, run.Error)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns the newest runs first, of every job when job is empty.
+func (r *JobRunRepo) ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error) {
+	query := `SELECT id, job, scheduled_at, started_at, finished_at, status, error, instance FROM job_runs
+		WHERE ($1 = '' OR job = $1) ORDER BY id DESC LIMIT $2`
+
+	rows, err := r.pool.Query(ctx, query, job, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+
+	runs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.JobRun, error) {
+		var run model.JobRun
+		err := row.Scan(&run.Id, &run.Job, &run.ScheduledAt, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error, &run.Instance)
+		return run, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan job runs: %w", err)
+	}
+
+	return runs, nil
+}
+
+// PruneRuns deletes runs started before the given time.
+func (r *JobRunRepo) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	cmdTag, err := r.pool.Exec(ctx, "DELETE FROM job_runs WHERE started_at < $1", before)
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune job runs: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
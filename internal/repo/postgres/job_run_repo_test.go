@@ -0,0 +1,37 @@
+package postgres
+
+import (
+	"context"
+	"rating/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRunRepo(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	repo := NewJobRunRepo(pool)
+	run := model.JobRun{Job: "job", ScheduledAt: time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC), StartedAt: time.Now(), Status: model.JobRunning, Instance: "a"}
+
+	started, err := repo.StartRun(ctx, run)
+	require.NoError(t, err)
+	_, err = repo.StartRun(ctx, run)
+	require.ErrorIs(t, err, model.ErrConflict)
+
+	finished := time.Now()
+	started.FinishedAt, started.Status = &finished, model.JobSucceeded
+	require.NoError(t, repo.FinishRun(ctx, *started))
+
+	runs, err := repo.ListRuns(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, model.JobSucceeded, runs[0].Status)
+
+	pruned, err := repo.PruneRuns(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pruned)
+}
@@ -0,0 +1,135 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type LeaderboardRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewLeaderboardRepo(pool *pgxpool.Pool) *LeaderboardRepo {
+	return &LeaderboardRepo{
+		pool: pool,
+	}
+}
+
+// TakeSnapshot stores the rank of every user within its tenant, ranked like
+// GetAll sorts them.
+func (r *LeaderboardRepo) TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error) {
+	ctx = tenant.WithAll(ctx)
+	var snapshot model.LeaderboardSnapshot
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		if err := tx.QueryRow(ctx, "INSERT INTO leaderboard_snapshots DEFAULT VALUES RETURNING id, taken_at").Scan(&snapshot.Id, &snapshot.TakenAt); err != nil {
+			return err
+		}
+
+		cmdTag, err := tx.Exec(ctx, `INSERT INTO leaderboard_entries (snapshot_id, tenant_id, user_id, nickname, rating, rank)
+			SELECT $1, tenant_id, id, nickname, rating, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY `+leaderboardOrder+`) FROM users`, snapshot.Id)
+		if err != nil {
+			return err
+		}
+		snapshot.Users = int(cmdTag.RowsAffected())
+
+		_, err = tx.Exec(ctx, "UPDATE leaderboard_snapshots SET users = $2 WHERE id = $1", snapshot.Id, snapshot.Users)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ListSnapshots reports the number of users of the tenant in each snapshot.
+func (r *LeaderboardRepo) ListSnapshots(ctx context.Context, limit int) ([]model.LeaderboardSnapshot, error) {
+	query := `SELECT s.id, s.taken_at, (SELECT count(*) FROM leaderboard_entries e WHERE e.snapshot_id = s.id AND e.tenant_id = $2)
+		FROM leaderboard_snapshots s ORDER BY s.id DESC LIMIT $1`
+
+	rows, err := r.pool.Query(ctx, query, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard snapshots: %w", err)
+	}
+
+	snapshots, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.LeaderboardSnapshot, error) {
+		var s model.LeaderboardSnapshot
+		err := row.Scan(&s.Id, &s.TakenAt, &s.Users)
+		return s, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan leaderboard snapshots: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// DiffSnapshots returns the users whose rank differs between the snapshots,
+// including users present in only one of them, ordered by their new rank.
+func (r *LeaderboardRepo) DiffSnapshots(ctx context.Context, from, to int64, limit int) ([]model.RankChange, error) {
+	var found int
+	if err := r.pool.QueryRow(ctx, "SELECT count(*) FROM leaderboard_snapshots WHERE id = $1 OR id = $2", from, to).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to find leaderboard snapshots: %w", err)
+	}
+	if found == 0 || (from != to && found < 2) {
+		return nil, fmt.Errorf("%w: leaderboard snapshot not found", model.ErrNotFound)
+	}
+
+	query := `SELECT COALESCE(t.user_id, f.user_id), COALESCE(t.nickname, f.nickname), f.rank, t.rank
+		FROM (SELECT user_id, nickname, rank FROM leaderboard_entries WHERE snapshot_id = $1 AND tenant_id = $4) f
+		FULL OUTER JOIN (SELECT user_id, nickname, rank FROM leaderboard_entries WHERE snapshot_id = $2 AND tenant_id = $4) t ON t.user_id = f.user_id
+		WHERE f.rank IS DISTINCT FROM t.rank
+		ORDER BY t.rank NULLS LAST, f.rank
+		LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, from, to, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff leaderboard snapshots: %w", err)
+	}
+
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.RankChange, error) {
+		var userId int64
+		var nickname string
+		var fromRank, toRank *int
+		err := row.Scan(&userId, &nickname, &fromRank, &toRank)
+		return model.NewRankChange(userId, nickname, fromRank, toRank), err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan leaderboard diff: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *LeaderboardRepo) UserRank(ctx context.Context, userId, snapshotId int64) (*model.SnapshotRank, error) {
+	query := `SELECT e.snapshot_id, s.taken_at, e.rank, e.rating FROM leaderboard_entries e
+		JOIN leaderboard_snapshots s ON s.id = e.snapshot_id
+		WHERE e.user_id = $1 AND e.snapshot_id = $2`
+
+	var rank model.SnapshotRank
+	err := r.pool.QueryRow(ctx, query, userId, snapshotId).Scan(&rank.SnapshotId, &rank.TakenAt, &rank.Rank, &rank.Rating)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user is not in leaderboard snapshot %d", model.ErrNotFound, snapshotId)
+		}
+		return nil, fmt.Errorf("failed to get user rank: %w", err)
+	}
+
+	return &rank, nil
+}
+
+// PruneSnapshots deletes all but the newest keep snapshots.
+func (r *LeaderboardRepo) PruneSnapshots(ctx context.Context, keep int) (int64, error) {
+	cmdTag, err := r.pool.Exec(ctx, "DELETE FROM leaderboard_snapshots WHERE id NOT IN (SELECT id FROM leaderboard_snapshots ORDER BY id DESC LIMIT $1)", keep)
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune leaderboard snapshots: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
@@ -0,0 +1,50 @@
+package postgres
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardRepo(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	repo := NewUserRepo(pool)
+	leaderboard := NewLeaderboardRepo(pool)
+	svc := service.NewLeaderboardService(leaderboard, repo)
+
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "first", 90, 100)))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "second", 50, 100)))
+	_, err := leaderboard.TakeSnapshot(ctx)
+	require.NoError(t, err)
+
+	require.NoError(t, repo.ChangeData(ctx, "second", request.UpdateUserDTO{Likes: ptrInt(95)}))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "third", 10, 100)))
+	_, err = leaderboard.TakeSnapshot(ctx)
+	require.NoError(t, err)
+
+	changes, err := svc.Diff(ctx, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 3)
+	require.Equal(t, "second", changes[0].Nickname)
+	require.Equal(t, 1, *changes[0].Delta)
+	require.Equal(t, "first", changes[1].Nickname)
+	require.Equal(t, -1, *changes[1].Delta)
+	require.Equal(t, "third", changes[2].Nickname)
+	require.Nil(t, changes[2].FromRank)
+
+	rank, err := svc.UserRank(ctx, "second")
+	require.NoError(t, err)
+	require.Equal(t, 1, rank.Current.Rank)
+	require.Equal(t, 2, rank.Previous.Rank)
+
+	pruned, err := leaderboard.PruneSnapshots(ctx, 1)
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pruned)
+}
@@ -0,0 +1,168 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"log/slog"
+	"rating/internal/service"
+	"sync"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+// ChangesChannel is the channel the users table trigger notifies on.
+const ChangesChannel = "user_changes"
+
+const (
+	ChangeInsert = "INSERT"
+	ChangeUpdate = "UPDATE"
+	ChangeDelete = "DELETE"
+	// ChangeResync is dispatched after the listener reconnected, changes made
+	// while it was disconnected are lost and subscribers must drop their state.
+	ChangeResync = "RESYNC"
+)
+
+const (
+	listenerMinBackoff = 100 * time.Millisecond
+	listenerMaxBackoff = 30 * time.Second
+)
+
+type UserChange struct {
+	Op          string `json:"op"`
+	Tenant      string `json:"tenant"`
+	Nickname    string `json:"nickname"`
+	OldNickname string `json:"old_nickname,omitempty"`
+}
+
+// Nicknames returns every nickname affected by the change, both sides of a rename.
+func (c UserChange) Nicknames() []string {
+	if c.OldNickname != "" && c.OldNickname != c.Nickname {
+		return []string{c.OldNickname, c.Nickname}
+	}
+
+	return []string{c.Nickname}
+}
+
+// ServiceChange converts the notification into the service representation.
+func (c UserChange) ServiceChange() service.Change {
+	change := service.Change{Tenant: c.Tenant, Nickname: c.Nickname}
+	switch c.Op {
+	case ChangeInsert:
+		change.Op = service.ChangeCreated
+	case ChangeUpdate:
+		change.Op = service.ChangeUpdated
+		if c.OldNickname != c.Nickname {
+			change.OldNickname = c.OldNickname
+		}
+	case ChangeDelete:
+		change.Op = service.ChangeDeleted
+	}
+
+	return change
+}
+
+// Listener receives user change notifications on a dedicated connection,
+// outside the pool, and dispatches them to subscribers. It reconnects with
+// backoff when the connection is lost.
+type Listener struct {
+	connConfig  *pgx.ConnConfig
+	logger      *slog.Logger
+	mu          sync.RWMutex
+	subscribers []func(UserChange)
+	cancel      context.CancelFunc
+	done        chan struct{}
+}
+
+func NewListener(pool *pgxpool.Pool, log *slog.Logger) *Listener {
+	return &Listener{
+		connConfig: pool.Config().ConnConfig.Copy(),
+		logger:     log,
+	}
+}
+
+// Subscribe registers fn for every change. fn runs on the listener goroutine
+// and must not block.
+func (l *Listener) Subscribe(fn func(UserChange)) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.subscribers = append(l.subscribers, fn)
+}
+
+func (l *Listener) Start(ctx context.Context) {
+	ctx, l.cancel = context.WithCancel(ctx)
+	l.done = make(chan struct{})
+
+	go func() {
+		defer close(l.done)
+
+		backoff := listenerMinBackoff
+		for connected := false; ; {
+			started := time.Now()
+			err := l.listen(ctx, func() {
+				if connected {
+					l.dispatch(UserChange{Op: ChangeResync})
+				}
+				connected = true
+			})
+			if ctx.Err() != nil {
+				return
+			}
+
+			if time.Since(started) > listenerMaxBackoff {
+				backoff = listenerMinBackoff
+			}
+			l.logger.WarnContext(ctx, "change listener disconnected", slog.Any("error", err), slog.Duration("retry_in", backoff))
+			if sleep(ctx, backoff) != nil {
+				return
+			}
+			backoff = min(backoff*2, listenerMaxBackoff)
+		}
+	}()
+}
+
+func (l *Listener) Stop() {
+	if l.cancel == nil {
+		return
+	}
+	l.cancel()
+	<-l.done
+}
+
+func (l *Listener) listen(ctx context.Context, onListening func()) error {
+	conn, err := pgx.ConnectConfig(ctx, l.connConfig)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.WithoutCancel(ctx))
+
+	if _, err := conn.Exec(ctx, "LISTEN "+ChangesChannel); err != nil {
+		return err
+	}
+	onListening()
+
+	for {
+		n, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var change UserChange
+		if err := json.Unmarshal([]byte(n.Payload), &change); err != nil {
+			l.logger.WarnContext(ctx, "change listener", slog.Any("malformed notification", err), slog.String("payload", n.Payload))
+			continue
+		}
+		l.dispatch(change)
+	}
+}
+
+func (l *Listener) dispatch(change UserChange) {
+	l.mu.RLock()
+	defer l.mu.RUnlock()
+
+	for _, fn := range l.subscribers {
+		fn(change)
+	}
+}
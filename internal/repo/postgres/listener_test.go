@@ -0,0 +1,62 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestListener(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	repo := NewUserRepo(pool)
+
+	changes := make(chan UserChange, 10)
+	listener := NewListener(pool, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	listener.Subscribe(func(change UserChange) { changes <- change })
+	listener.Start(ctx)
+	t.Cleanup(listener.Stop)
+
+	next := func() UserChange {
+		for {
+			select {
+			case change := <-changes:
+				if strings.HasPrefix(change.Nickname, "probe") {
+					continue
+				}
+				return change
+			case <-ctx.Done():
+				t.Fatal("no change notification received")
+				return UserChange{}
+			}
+		}
+	}
+
+	// LISTEN is issued asynchronously, write probes until one is delivered
+	probes := 0
+	require.Eventually(t, func() bool {
+		probes++
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", fmt.Sprintf("probe%d", probes), 1, 2)))
+		return len(changes) > 0
+	}, 5*time.Second, 50*time.Millisecond)
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+	require.Equal(t, UserChange{Op: ChangeInsert, Nickname: "nickname"}, next())
+
+	require.NoError(t, repo.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+	change := next()
+	require.Equal(t, UserChange{Op: ChangeUpdate, Nickname: "renamed", OldNickname: "nickname"}, change)
+	require.Equal(t, []string{"nickname", "renamed"}, change.Nicknames())
+
+	require.NoError(t, repo.Delete(ctx, "renamed"))
+	require.Equal(t, UserChange{Op: ChangeDelete, Nickname: "renamed"}, next())
+}
@@ -0,0 +1,66 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type MilestoneRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewMilestoneRepo(pool *pgxpool.Pool) *MilestoneRepo {
+	return &MilestoneRepo{
+		pool: pool,
+	}
+}
+
+func (r *MilestoneRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return r.pool
+}
+
+func (r *MilestoneRepo) RecordMilestones(ctx context.Context, userId int64, milestones []model.Milestone) ([]model.Milestone, error) {
+	query := `INSERT INTO user_milestones (user_id, name, metric, threshold, value, reached_at) VALUES ($1, $2, $3, $4, $5, $6)
+		ON CONFLICT (user_id, name) DO NOTHING RETURNING reached_at`
+
+	var recorded []model.Milestone
+	for _, m := range milestones {
+		err := r.conn(ctx).QueryRow(ctx, query, userId, m.Name, m.Metric, m.Threshold, m.Value, m.ReachedAt).Scan(&m.ReachedAt)
+		if errors.Is(err, pgx.ErrNoRows) {
+			continue
+		}
+		if err != nil {
+			return nil, fmt.Errorf("failed to record milestone %s: %w", m.Name, err)
+		}
+		recorded = append(recorded, m)
+	}
+
+	return recorded, nil
+}
+
+func (r *MilestoneRepo) ListMilestones(ctx context.Context, userId int64) ([]model.Milestone, error) {
+	rows, err := r.conn(ctx).Query(ctx, "SELECT name, metric, threshold, value, reached_at FROM user_milestones WHERE user_id = $1 ORDER BY reached_at, name", userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+
+	milestones, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.Milestone, error) {
+		var m model.Milestone
+		err := row.Scan(&m.Name, &m.Metric, &m.Threshold, &m.Value, &m.ReachedAt)
+		return m, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan milestones: %w", err)
+	}
+
+	return milestones, nil
+}
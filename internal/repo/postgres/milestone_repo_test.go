@@ -0,0 +1,33 @@
+package postgres
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMilestoneRepo(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	tx, err := NewTxManager(pool, "", 3)
+	require.NoError(t, err)
+	milestones := NewMilestoneRepo(pool)
+	rules := []model.MilestoneRule{{Metric: model.MetricViewers, Threshold: 1000}, {Metric: model.MetricRating, Threshold: 0.9}}
+	svc := service.NewUserService(NewUserRepo(pool), service.WithTxManager(tx), service.WithMilestones(milestones, rules))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 10}))
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: ptrInt(1500)}))
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: ptrInt(2000)}))
+
+	reached, err := svc.ListMilestones(ctx, "nickname")
+	require.NoError(t, err)
+	require.Len(t, reached, 1)
+	require.Equal(t, "viewers>=1000", reached[0].Name)
+	require.Equal(t, float64(1500), reached[0].Value)
+}
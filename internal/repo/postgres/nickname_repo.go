@@ -0,0 +1,93 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+type NicknameRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewNicknameRepo(pool *pgxpool.Pool) *NicknameRepo {
+	return &NicknameRepo{
+		pool: pool,
+	}
+}
+
+func (r *NicknameRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return r.pool
+}
+
+// RecordRename releases from, taking it over from whichever user released it
+// before.
+func (r *NicknameRepo) RecordRename(ctx context.Context, userId int64, from string) error {
+	query := `INSERT INTO nickname_aliases (tenant_id, nickname, nickname_key, user_id) VALUES ($1, $2, $3, $4)
+		ON CONFLICT (tenant_id, nickname_key) DO UPDATE SET nickname = EXCLUDED.nickname, user_id = EXCLUDED.user_id, released_at = now()`
+
+	if _, err := r.conn(ctx).Exec(ctx, query, tenant.ID(ctx), from, model.NicknameKey(from), userId); err != nil {
+		return fmt.Errorf("failed to record nickname alias: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NicknameRepo) GetAlias(ctx context.Context, nickname string) (*model.NicknameAlias, error) {
+	query := `SELECT a.nickname, COALESCE(a.user_id, 0), COALESCE(u.nickname, ''), a.released_at FROM nickname_aliases a LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.tenant_id = $1 AND a.nickname_key = $2`
+
+	var alias model.NicknameAlias
+	err := r.conn(ctx).QueryRow(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&alias.Nickname, &alias.UserId, &alias.Current, &alias.ReleasedAt)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get nickname alias: %w", err)
+	}
+
+	return &alias, nil
+}
+
+func (r *NicknameRepo) ListAliases(ctx context.Context, userId int64) ([]model.NicknameAlias, error) {
+	query := `SELECT a.nickname, a.user_id, u.nickname, a.released_at FROM nickname_aliases a JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = $1 ORDER BY a.released_at DESC, a.nickname`
+
+	rows, err := r.conn(ctx).Query(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nickname aliases: %w", err)
+	}
+
+	aliases, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.NicknameAlias, error) {
+		var a model.NicknameAlias
+		err := row.Scan(&a.Nickname, &a.UserId, &a.Current, &a.ReleasedAt)
+		return a, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan nickname aliases: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// PurgeDeleted deletes the nicknames released by deleted users before the
+// given time, in every tenant.
+func (r *NicknameRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	ctx = tenant.WithAll(ctx)
+	cmdTag, err := r.conn(ctx).Exec(ctx, "DELETE FROM nickname_aliases WHERE user_id IS NULL AND released_at < $1", before)
+	if err != nil {
+		return -1, fmt.Errorf("failed to purge nickname aliases: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
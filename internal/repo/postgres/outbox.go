@@ -0,0 +1,19 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/tenant"
+)
+
+func (r *UserRepo) recordOutbox(ctx context.Context, q querier, eventType string, payload any) error {
+	if !r.outbox {
+		return nil
+	}
+
+	if _, err := q.Exec(ctx, "INSERT INTO webhook_outbox (tenant_id, event_type, payload) VALUES ($1, $2, $3)", tenant.ID(ctx), eventType, payload); err != nil {
+		return fmt.Errorf("failed to record %s in outbox: %w", eventType, err)
+	}
+
+	return nil
+}
@@ -0,0 +1,101 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/db"
+	"rating/internal/model"
+	"rating/internal/tenant"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const quarantineQuery = `SELECT q.id, q.user_id, u.nickname, q.change, q.reasons, q.status, q.created_at, q.reviewed_at
+	FROM quarantined_changes q JOIN users u ON u.id = q.user_id`
+
+type QuarantineRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewQuarantineRepo(pool *pgxpool.Pool) *QuarantineRepo {
+	return &QuarantineRepo{
+		pool: pool,
+	}
+}
+
+func (r *QuarantineRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return r.pool
+}
+
+func scanQuarantined(row pgx.Row) (model.QuarantinedChange, error) {
+	var c model.QuarantinedChange
+	err := row.Scan(&c.Id, &c.UserId, &c.Nickname, &c.Change, &c.Reasons, &c.Status, &c.CreatedAt, &c.ReviewedAt)
+	return c, err
+}
+
+func (r *QuarantineRepo) Quarantine(ctx context.Context, change model.QuarantinedChange) (*model.QuarantinedChange, error) {
+	query := "INSERT INTO quarantined_changes (user_id, change, reasons) VALUES ($1, $2, $3) RETURNING id, status, created_at"
+
+	db.MarkWrite(ctx)
+	err := r.conn(ctx).QueryRow(ctx, query, change.UserId, change.Change, change.Reasons).Scan(&change.Id, &change.Status, &change.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quarantine change: %w", err)
+	}
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error) {
+	rows, err := r.conn(ctx).Query(ctx, quarantineQuery+" WHERE u.tenant_id = $3 AND ($1 = '' OR q.status = $1) ORDER BY q.id LIMIT $2", status, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined changes: %w", err)
+	}
+
+	changes, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.QuarantinedChange, error) {
+		return scanQuarantined(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan quarantined changes: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *QuarantineRepo) GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	change, err := scanQuarantined(r.conn(ctx).QueryRow(ctx, quarantineQuery+" WHERE q.id = $1 AND u.tenant_id = $2", id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: quarantined change not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quarantined change: %w", err)
+	}
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ResolveQuarantined(ctx context.Context, id int64, status string) (*model.QuarantinedChange, error) {
+	db.MarkWrite(ctx)
+	query := `UPDATE quarantined_changes SET status = $2, reviewed_at = now()
+		WHERE id = $1 AND status = 'pending' AND user_id IN (SELECT id FROM users WHERE tenant_id = $3)`
+
+	cmdTag, err := r.conn(ctx).Exec(ctx, query, id, status, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quarantined change: %w", err)
+	}
+
+	change, err := r.GetQuarantined(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if cmdTag.RowsAffected() == 0 {
+		return nil, fmt.Errorf("%w: change %d is already %s", model.ErrConflict, id, change.Status)
+	}
+
+	return change, nil
+}
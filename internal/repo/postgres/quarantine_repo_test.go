@@ -0,0 +1,44 @@
+package postgres
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/fraud"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineRepo(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx := context.Background()
+
+	tx, err := NewTxManager(pool, "", 3)
+	require.NoError(t, err)
+	quarantine := NewQuarantineRepo(pool)
+	detector := fraud.NewDetector(fraud.Config{JumpMultiple: 10, JumpMinDelta: 100, RatioThreshold: 1, RatioMinViewers: 1000000})
+	svc := service.NewUserService(NewUserRepo(pool), service.WithTxManager(tx), service.WithFraudCheck(detector, quarantine))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 10, Viewers: 100000}))
+	err = svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(10000)})
+	var held *model.QuarantinedError
+	require.ErrorAs(t, err, &held)
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+
+	pending, err := svc.ListQuarantined(ctx, model.QuarantinePending, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "renamed", pending[0].Nickname)
+	require.Equal(t, 10000, *pending[0].Change.Likes)
+
+	approved, err := svc.ApproveQuarantined(ctx, held.Change.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.QuarantineApproved, approved.Status)
+	require.NotNil(t, approved.ReviewedAt)
+
+	_, err = svc.RejectQuarantined(ctx, held.Change.Id)
+	require.ErrorIs(t, err, model.ErrConflict)
+}
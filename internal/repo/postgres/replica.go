@@ -0,0 +1,139 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"rating/internal/db"
+	"sync/atomic"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const lagQuery = `SELECT COALESCE(
+	CASE WHEN pg_last_wal_receive_lsn() = pg_last_wal_replay_lsn() THEN 0
+	     ELSE EXTRACT(EPOCH FROM now() - pg_last_xact_replay_timestamp())
+	END, 0)::float8`
+
+// Replica tracks whether a read replica is reachable and within the allowed
+// replication lag. Reads fall back to the primary while it is not.
+type Replica struct {
+	pool          *pgxpool.Pool
+	maxLag        time.Duration
+	checkInterval time.Duration
+	logger        *slog.Logger
+	healthy       atomic.Bool
+	cancel        context.CancelFunc
+	done          chan struct{}
+}
+
+func NewReplica(pool *pgxpool.Pool, maxLag, checkInterval time.Duration, log *slog.Logger) *Replica {
+	return &Replica{
+		pool:          pool,
+		maxLag:        maxLag,
+		checkInterval: checkInterval,
+		logger:        log,
+	}
+}
+
+func (r *Replica) Start(ctx context.Context) {
+	ctx, r.cancel = context.WithCancel(ctx)
+	r.done = make(chan struct{})
+	r.check(ctx)
+
+	go func() {
+		defer close(r.done)
+		ticker := time.NewTicker(r.checkInterval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+				r.check(ctx)
+			}
+		}
+	}()
+}
+
+func (r *Replica) Stop() {
+	if r.cancel == nil {
+		return
+	}
+	r.cancel()
+	<-r.done
+}
+
+func (r *Replica) Healthy() bool {
+	return r.healthy.Load()
+}
+
+func (r *Replica) Pool() *pgxpool.Pool {
+	return r.pool
+}
+
+func (r *Replica) check(ctx context.Context) {
+	ctx, cancel := context.WithTimeout(ctx, r.checkInterval)
+	defer cancel()
+
+	var lagSeconds float64
+	if err := r.pool.QueryRow(ctx, lagQuery).Scan(&lagSeconds); err != nil {
+		r.setHealthy(false, slog.Any("replica check failed", err))
+		return
+	}
+
+	lag := time.Duration(lagSeconds * float64(time.Second))
+	if lag > r.maxLag {
+		r.setHealthy(false, slog.Duration("replica lag", lag))
+		return
+	}
+
+	r.setHealthy(true, slog.Duration("replica lag", lag))
+}
+
+func (r *Replica) markDown(err error) {
+	r.setHealthy(false, slog.Any("replica query failed", err))
+}
+
+func (r *Replica) setHealthy(healthy bool, attr slog.Attr) {
+	if r.healthy.Swap(healthy) != healthy {
+		if healthy {
+			r.logger.Info("replica available, routing reads to replica", attr)
+		} else {
+			r.logger.Warn("replica unavailable, routing reads to primary", attr)
+		}
+	}
+}
+
+// read runs fn on the replica when it is healthy and the request has not
+// written yet, retrying on the primary if the replica connection fails.
+func (r *UserRepo) read(ctx context.Context, fn func(q querier) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok || r.replica == nil || !r.replica.Healthy() || db.PrimaryForced(ctx) {
+		return fn(r.conn(ctx))
+	}
+
+	err := fn(r.replica.Pool())
+	if isConnError(err) {
+		r.replica.markDown(err)
+		return fn(r.pool)
+	}
+
+	return err
+}
+
+// isConnError reports errors that come from the connection rather than the
+// query, which are worth retrying on the primary.
+func isConnError(err error) bool {
+	if err == nil || errors.Is(err, pgx.ErrNoRows) {
+		return false
+	}
+	var pgErr *pgconn.PgError
+	if errors.As(err, &pgErr) {
+		return false
+	}
+	return !errors.Is(err, context.Canceled) && !errors.Is(err, context.DeadlineExceeded)
+}
@@ -0,0 +1,82 @@
+package postgres
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/config"
+	"rating/internal/db"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+	"github.com/stretchr/testify/require"
+)
+
+func TestRowLevelSecurity(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	// the container user is a superuser, which always bypasses row-level
+	// security, so the tables are handed to an ordinary role like the service's
+	for _, stmt := range []string{
+		"CREATE ROLE rating_app LOGIN PASSWORD 'app'",
+		"GRANT ALL ON ALL TABLES IN SCHEMA public TO rating_app",
+		"GRANT ALL ON ALL SEQUENCES IN SCHEMA public TO rating_app",
+		"ALTER TABLE users OWNER TO rating_app",
+	} {
+		_, err := pool.Exec(ctx, stmt)
+		require.NoError(t, err)
+	}
+
+	cc := pool.Config().ConnConfig
+	appURL := fmt.Sprintf("postgres://rating_app:app@%s:%d/%s?sslmode=disable", cc.Host, cc.Port, cc.Database)
+	dbConfig := config.Default().Database
+	dbConfig.RowLevelSecurity = true
+	scoped, err := db.NewPool(ctx, appURL, dbConfig)
+	require.NoError(t, err)
+	t.Cleanup(scoped.Close)
+
+	acme := tenant.WithID(ctx, "acme")
+	repo := NewUserRepo(scoped)
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "default-user", 1, 1)))
+	require.NoError(t, repo.Create(acme, *model.NewUser("name", "acme-user", 1, 1)))
+
+	// no tenant_id filter: only the policies keep the other tenant out
+	nicknames := func(ctx context.Context) []string {
+		rows, err := scoped.Query(ctx, "SELECT nickname FROM users ORDER BY nickname")
+		require.NoError(t, err)
+		names, err := pgx.CollectRows(rows, pgx.RowTo[string])
+		require.NoError(t, err)
+		return names
+	}
+	require.Equal(t, []string{"default-user"}, nicknames(ctx))
+	require.Equal(t, []string{"acme-user"}, nicknames(acme))
+	require.Equal(t, []string{"acme-user", "default-user"}, nicknames(tenant.WithAll(ctx)))
+
+	// a connection that binds no tenant sees nothing, and one that opts out
+	// of the policies sees every tenant
+	bare, err := pgxpool.New(ctx, appURL)
+	require.NoError(t, err)
+	t.Cleanup(bare.Close)
+	var visible int
+	require.NoError(t, bare.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&visible))
+	require.Zero(t, visible)
+
+	unscoped, err := db.NewPool(ctx, appURL, config.Default().Database)
+	require.NoError(t, err)
+	t.Cleanup(unscoped.Close)
+	require.NoError(t, unscoped.QueryRow(ctx, "SELECT count(*) FROM users").Scan(&visible))
+	require.Equal(t, 2, visible)
+
+	_, err = scoped.Exec(acme, "INSERT INTO users (tenant_id, name, nickname, nickname_key) VALUES ('default', 'name', 'intruder', 'intruder')")
+	require.Error(t, err)
+
+	count, err := repo.Count(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 2, count)
+}
@@ -0,0 +1,152 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"math/rand/v2"
+	"rating/internal/service"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const (
+	serializationFailureCode = "40001"
+	deadlockDetectedCode     = "40P01"
+)
+
+const retryBaseDelay = 10 * time.Millisecond
+
+type querier interface {
+	Exec(ctx context.Context, sql string, arguments ...any) (pgconn.CommandTag, error)
+	Query(ctx context.Context, sql string, args ...any) (pgx.Rows, error)
+	QueryRow(ctx context.Context, sql string, args ...any) pgx.Row
+	SendBatch(ctx context.Context, b *pgx.Batch) pgx.BatchResults
+}
+
+type txKey struct{}
+
+func (r *UserRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return tx
+	}
+
+	return r.pool
+}
+
+type TxManager struct {
+	pool       *pgxpool.Pool
+	repo       *UserRepo
+	isoLevel   pgx.TxIsoLevel
+	maxRetries int
+}
+
+// NewTxManager creates a TxManager whose store is a UserRepo built with opts.
+func NewTxManager(pool *pgxpool.Pool, isoLevel string, maxRetries int, opts ...UserRepoOption) (*TxManager, error) {
+	level, err := ParseIsoLevel(isoLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	return &TxManager{
+		pool:       pool,
+		repo:       NewUserRepo(pool, opts...),
+		isoLevel:   level,
+		maxRetries: maxRetries,
+	}, nil
+}
+
+func ParseIsoLevel(level string) (pgx.TxIsoLevel, error) {
+	switch pgx.TxIsoLevel(level) {
+	case "":
+		return pgx.ReadCommitted, nil
+	case pgx.ReadCommitted, pgx.RepeatableRead, pgx.Serializable, pgx.ReadUncommitted:
+		return pgx.TxIsoLevel(level), nil
+	default:
+		return "", fmt.Errorf("unknown transaction isolation level %q", level)
+	}
+}
+
+// WithinTx runs fn in a transaction carried by ctx, so every repository call
+// made with that ctx joins it. Nested calls reuse the outer transaction.
+// Serialization failures and deadlocks roll back and rerun fn.
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok {
+		return fn(ctx, m.repo)
+	}
+
+	var err error
+	for attempt := 0; attempt <= m.maxRetries; attempt++ {
+		if attempt > 0 {
+			if waitErr := sleep(ctx, backoff(attempt)); waitErr != nil {
+				return errors.Join(err, waitErr)
+			}
+		}
+
+		err = m.run(ctx, fn)
+		if !isRetryable(err) {
+			return err
+		}
+	}
+
+	return fmt.Errorf("transaction failed after %d retries: %w", m.maxRetries, err)
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	tx, err := m.pool.BeginTx(ctx, pgx.TxOptions{IsoLevel: m.isoLevel})
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback(ctx)
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx), m.repo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(ctx); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
+
+func isRetryable(err error) bool {
+	var pgxErr *pgconn.PgError
+	if errors.As(err, &pgxErr) {
+		return pgxErr.Code == serializationFailureCode || pgxErr.Code == deadlockDetectedCode
+	}
+
+	return false
+}
+
+func backoff(attempt int) time.Duration {
+	d := retryBaseDelay << (attempt - 1)
+	return d/2 + rand.N(d/2+1)
+}
+
+func sleep(ctx context.Context, d time.Duration) error {
+	timer := time.NewTimer(d)
+	defer timer.Stop()
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// write runs fn in the ctx transaction, or in a transaction of its own when
+// the outbox has to be written along with the change.
+func (r *UserRepo) write(ctx context.Context, fn func(q querier) error) error {
+	if _, ok := ctx.Value(txKey{}).(pgx.Tx); ok || !r.outbox {
+		return fn(r.conn(ctx))
+	}
+
+	return pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		return fn(tx)
+	})
+}
@@ -0,0 +1,55 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+	"time"
+
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/stretchr/testify/require"
+)
+
+func TestTxManager_WithinTx(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	repo := NewUserRepo(pool)
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+
+	t.Run("rollback on error", func(t *testing.T) {
+		tm, err := NewTxManager(pool, "read committed", 0)
+		require.NoError(t, err)
+
+		rollbackErr := errors.New("rollback")
+		err = tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+			require.NoError(t, store.Create(ctx, *model.NewUser("name", "created", 1, 2)))
+			return rollbackErr
+		})
+		require.ErrorIs(t, err, rollbackErr)
+
+		_, err = repo.GetUser(ctx, "created")
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("retry on serialization failure", func(t *testing.T) {
+		tm, err := NewTxManager(pool, "serializable", 3)
+		require.NoError(t, err)
+
+		attempts := 0
+		err = tm.WithinTx(ctx, func(ctx context.Context, store service.UserStore) error {
+			attempts++
+			if attempts == 1 {
+				return fmt.Errorf("wrapped: %w", &pgconn.PgError{Code: serializationFailureCode})
+			}
+			return store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)})
+		})
+		require.NoError(t, err)
+		require.Equal(t, 2, attempts)
+	})
+}
@@ -4,8 +4,10 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"rating/internal/db"
 	"rating/internal/dto/request"
 	"rating/internal/model"
+	"rating/internal/tenant"
 	"strings"
 
 	"github.com/jackc/pgx/v5"
@@ -13,14 +15,51 @@ import (
 	"github.com/jackc/pgx/v5/pgxpool"
 )
 
+// leaderboardOrder ranks users; leaderboard snapshots use the same order.
+const leaderboardOrder = "rating DESC, id ASC"
+
+// categoryJoin keeps the members of the category whose slug is bound to $4,
+// within the tenant bound to $3.
+const categoryJoin = " JOIN user_categories uc ON uc.user_id = users.id AND uc.category_id = (SELECT id FROM categories WHERE tenant_id = $3 AND slug = $4)"
+
 type UserRepo struct {
-	pool *pgxpool.Pool
+	pool       *pgxpool.Pool
+	replica    *Replica
+	countCache *CountCache
+	outbox     bool
+}
+
+type UserRepoOption func(*UserRepo)
+
+func WithReplica(replica *Replica) UserRepoOption {
+	return func(r *UserRepo) {
+		r.replica = replica
+	}
 }
 
-func NewUserRepo(pool *pgxpool.Pool) *UserRepo {
-	return &UserRepo{
+func WithCountCache(cache *CountCache) UserRepoOption {
+	return func(r *UserRepo) {
+		r.countCache = cache
+	}
+}
+
+// WithWebhookOutbox records created and deleted users and rating changes in
+// the webhook outbox, in the same transaction as the change.
+func WithWebhookOutbox() UserRepoOption {
+	return func(r *UserRepo) {
+		r.outbox = true
+	}
+}
+
+func NewUserRepo(pool *pgxpool.Pool, opts ...UserRepoOption) *UserRepo {
+	r := &UserRepo{
 		pool: pool,
 	}
+	for _, opt := range opts {
+		opt(r)
+	}
+
+	return r
 }
 
 func (r *UserRepo) scanUser(rows pgx.Rows) ([]model.User, error) {
@@ -43,9 +82,15 @@ func (r *UserRepo) scanUser(rows pgx.Rows) ([]model.User, error) {
 }
 
 func (r *UserRepo) Create(ctx context.Context, user model.User) error {
-	query := "INSERT INTO users (name, nickname, likes, viewers) VALUES($1, $2, $3, $4)"
+	query := "INSERT INTO users (tenant_id, name, nickname, nickname_key, likes, viewers) VALUES($1, $2, $3, $4, $5, $6) RETURNING id, rating"
 
-	_, err := r.pool.Exec(ctx, query, user.Name, user.NickName, user.Likes, user.Viewers)
+	db.MarkWrite(ctx)
+	err := r.write(ctx, func(q querier) error {
+		if err := q.QueryRow(ctx, query, tenant.ID(ctx), user.Name, user.NickName, model.NicknameKey(user.NickName), user.Likes, user.Viewers).Scan(&user.Id, &user.Rating); err != nil {
+			return err
+		}
+		return r.recordOutbox(ctx, q, model.WebhookUserCreated, user)
+	})
 
 	var pgxErr *pgconn.PgError
 	if err != nil {
@@ -53,6 +98,9 @@ func (r *UserRepo) Create(ctx context.Context, user model.User) error {
 			if pgxErr.Code == "23505" {
 				return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, user.NickName)
 			}
+			if pgxErr.Code == "23514" {
+				return fmt.Errorf("%w: likes can't be more than viewers or negative", model.ErrInvalidInput)
+			}
 		}
 		return fmt.Errorf("failed to create user: %w", err)
 	}
@@ -60,40 +108,58 @@ func (r *UserRepo) Create(ctx context.Context, user model.User) error {
 	return nil
 }
 
-func (r *UserRepo) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error) {
+func (r *UserRepo) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
 	query := "SELECT id, name, nickname, likes, viewers, rating FROM users"
+	args := []any{params.Limit, params.Offset, tenant.ID(ctx)}
 
-	var totalCount int
-	if err := r.pool.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&totalCount); err != nil {
-		return nil, -1, fmt.Errorf("failed to get total count users: %w", err)
+	if params.Category != "" {
+		query += categoryJoin
+		args = append(args, params.Category)
 	}
+	query += " WHERE users.tenant_id = $3"
 
 	if params.Sort == "desc" {
-		query += " ORDER BY rating DESC"
+		query += " ORDER BY " + leaderboardOrder
 	} else if params.Sort == "asc" {
-		query += " ORDER BY rating ASC"
+		query += " ORDER BY rating ASC, id ASC"
 	} else {
 		query += " ORDER BY id ASC"
 	}
 
 	query += " LIMIT $1 OFFSET $2"
 
-	rows, err := r.pool.Query(ctx, query, params.Limit, params.Offset)
+	var totalCount model.UserCount
+	var userList []model.User
+	err := r.read(ctx, func(q querier) error {
+		var err error
+		totalCount, err = r.totalCount(ctx, q, params)
+		if err != nil {
+			return err
+		}
+
+		rows, err := q.Query(ctx, query, args...)
+		if err != nil {
+			return fmt.Errorf("failed to get all users: %w", err)
+		}
+		defer rows.Close()
+
+		userList, err = r.scanUser(rows)
+		return err
+	})
 	if err != nil {
-		return nil, -1, fmt.Errorf("failed to get all users: %w", err)
+		return nil, model.UserCount{Total: -1}, err
 	}
-	defer rows.Close()
 
-	userList, err := r.scanUser(rows)
-
-	return userList, totalCount, err
+	return userList, totalCount, nil
 }
 
 func (r *UserRepo) GetUser(ctx context.Context, nickname string) (*model.User, error) {
-	query := "SELECT id, name, nickname, likes, viewers, rating FROM users WHERE nickname = $1"
+	query := "SELECT id, name, nickname, likes, viewers, rating FROM users WHERE tenant_id = $1 AND nickname_key = $2"
 
 	var user model.User
-	err := r.pool.QueryRow(ctx, query, nickname).Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating)
+	err := r.read(ctx, func(q querier) error {
+		return q.QueryRow(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating)
+	})
 	if err != nil {
 		if errors.Is(err, pgx.ErrNoRows) {
 			return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
@@ -104,6 +170,23 @@ func (r *UserRepo) GetUser(ctx context.Context, nickname string) (*model.User, e
 	return &user, nil
 }
 
+// GetUserForUpdate reads the user from the primary and locks its row until
+// the transaction carried by ctx ends.
+func (r *UserRepo) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	query := "SELECT id, name, nickname, likes, viewers, rating FROM users WHERE tenant_id = $1 AND nickname_key = $2 FOR UPDATE"
+
+	var user model.User
+	err := r.conn(ctx).QueryRow(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating)
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get user for update: %w", err)
+	}
+
+	return &user, nil
+}
+
 func (r *UserRepo) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
 	var args []any
 	var sets []string
@@ -124,44 +207,92 @@ func (r *UserRepo) ChangeData(ctx context.Context, nickname string, dto request.
 	}
 
 	if dto.Nickname != nil {
-		sets = append(sets, fmt.Sprintf("nickname = $%d", len(args)+1))
-		args = append(args, *dto.Nickname)
+		sets = append(sets, fmt.Sprintf("nickname = $%d, nickname_key = $%d", len(args)+1, len(args)+2))
+		args = append(args, *dto.Nickname, model.NicknameKey(*dto.Nickname))
 	}
 
-	args = append(args, nickname)
+	args = append(args, tenant.ID(ctx), model.NicknameKey(nickname))
 
-	query := fmt.Sprintf("UPDATE users SET %s WHERE %s ", strings.Join(sets, ", "), fmt.Sprintf("nickname = $%d", len(args)))
+	// the old rating is read under the same row lock so rating changes can be recorded
+	query := fmt.Sprintf(`UPDATE users u SET %s
+		FROM (SELECT id, rating FROM users WHERE tenant_id = $%d AND nickname_key = $%d FOR UPDATE) old
+		WHERE u.id = old.id
+		RETURNING u.id, u.name, u.nickname, u.likes, u.viewers, u.rating, old.rating`, strings.Join(sets, ", "), len(args)-1, len(args))
 
-	cmdTag, err := r.pool.Exec(ctx, query, args...)
+	db.MarkWrite(ctx)
+	err := r.write(ctx, func(q querier) error {
+		var change model.RatingChange
+		err := q.QueryRow(ctx, query, args...).Scan(&change.User.Id, &change.User.Name, &change.User.NickName, &change.User.Likes, &change.User.Viewers, &change.User.Rating, &change.OldRating)
+		if err != nil || change.User.Rating == change.OldRating {
+			return err
+		}
+		return r.recordOutbox(ctx, q, model.WebhookRatingChanged, change)
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
 		var pgxErr *pgconn.PgError
 		if errors.As(err, &pgxErr) {
 			if pgxErr.Code == "23514" {
 				return fmt.Errorf("%w: likes can't be more than viewers", model.ErrInvalidInput)
 			}
+			if pgxErr.Code == "23505" {
+				return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, *dto.Nickname)
+			}
 		}
 		return fmt.Errorf("failed to update data: %w", err)
 	}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("%w: user not found", model.ErrNotFound)
-	}
-
 	return nil
 }
 
 func (r *UserRepo) Delete(ctx context.Context, nickname string) error {
-	query := "DELETE FROM users WHERE nickname = $1"
+	query := "DELETE FROM users WHERE tenant_id = $1 AND nickname_key = $2 RETURNING id, name, nickname, likes, viewers, rating"
 
-	cmdTag, err := r.pool.Exec(ctx, query, nickname)
+	db.MarkWrite(ctx)
+	err := r.write(ctx, func(q querier) error {
+		var user model.User
+		if err := q.QueryRow(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating); err != nil {
+			return err
+		}
+		return r.recordOutbox(ctx, q, model.WebhookUserDeleted, user)
+	})
 	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
 		return fmt.Errorf("failed to delete user: %w", err)
 	}
 
-	if cmdTag.RowsAffected() == 0 {
-		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	return nil
+}
+
+// Count returns the number of users across all tenants.
+func (r *UserRepo) Count(ctx context.Context) (int, error) {
+	ctx = tenant.WithAll(ctx)
+	var totalCount int
+	err := r.read(ctx, func(q querier) error {
+		return q.QueryRow(ctx, "SELECT COUNT(*) FROM users").Scan(&totalCount)
+	})
+	if err != nil {
+		return -1, fmt.Errorf("failed to get total count users: %w", err)
 	}
 
-	return nil
+	return totalCount, nil
+}
+
+func (r *UserRepo) RecomputeRatings(ctx context.Context) (int64, error) {
+	ctx = tenant.WithAll(ctx)
+	// rating is a stored generated column, touching a row makes Postgres
+	// recompute it; only rows whose stored rating disagrees with the formula
+	// are touched, which normally is none
+	db.MarkWrite(ctx)
+	cmdTag, err := r.conn(ctx).Exec(ctx, `UPDATE users SET viewers = viewers
+		WHERE rating IS DISTINCT FROM (CASE WHEN viewers > 0 THEN ROUND(likes::NUMERIC / viewers, 3) ELSE 0 END)`)
+	if err != nil {
+		return -1, fmt.Errorf("failed to recompute ratings: %w", err)
+	}
 
+	return cmdTag.RowsAffected(), nil
 }
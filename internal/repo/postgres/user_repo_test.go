@@ -2,11 +2,11 @@ package postgres
 
 import (
 	"context"
-	"os"
-	"path/filepath"
 	"rating/internal/dto/request"
+	"rating/internal/migrate"
 	"rating/internal/model"
-	"strings"
+	"rating/internal/repo/storetest"
+	"rating/internal/service"
 	"testing"
 	"time"
 
@@ -46,12 +46,11 @@ func setupTestDB(t *testing.T) *pgxpool.Pool {
 	pool, err := pgxpool.New(ctx, connStr)
 	require.NoError(t, err)
 
-	migrationPath := filepath.Join("..", "..", "..", "migrations", "20260225160021_init_users_table.sql")
-	migrationSQL, err := os.ReadFile(migrationPath)
-	require.NoError(t, err, "failed to read migration file")
-	upSQL := strings.Split(string(migrationSQL), "-- +goose Down")[0]
+	runner, err := migrate.NewRunner(pool)
+	require.NoError(t, err)
+	t.Cleanup(func() { runner.Close() })
 
-	_, err = pool.Exec(ctx, string(upSQL))
+	err = runner.Up(ctx)
 	require.NoError(t, err, "failed to execute migration")
 
 	return pool
@@ -131,7 +130,7 @@ func TestUserRepo_GetAll(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, expectedList, list)
-		require.Equal(t, 3, total)
+		require.Equal(t, 3, total.Total)
 	})
 
 	t.Run("pagination limit", func(t *testing.T) {
@@ -143,7 +142,7 @@ func TestUserRepo_GetAll(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, expectedList[:2], list)
-		require.Equal(t, 3, total)
+		require.Equal(t, 3, total.Total)
 	})
 
 	t.Run("pagination offset", func(t *testing.T) {
@@ -155,7 +154,7 @@ func TestUserRepo_GetAll(t *testing.T) {
 		require.NoError(t, err)
 
 		require.Equal(t, expectedList[2:], list)
-		require.Equal(t, 3, total)
+		require.Equal(t, 3, total.Total)
 	})
 }
 
@@ -271,3 +270,14 @@ func TestUserRepo_Delete(t *testing.T) {
 		require.ErrorIs(t, err, model.ErrNotFound)
 	})
 }
+
+func TestUserRepo_Conformance(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+
+	storetest.Run(t, func(t *testing.T) service.UserStore {
+		_, err := pool.Exec(context.Background(), "TRUNCATE users RESTART IDENTITY CASCADE")
+		require.NoError(t, err)
+		return NewUserRepo(pool)
+	})
+}
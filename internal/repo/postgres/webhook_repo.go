@@ -0,0 +1,241 @@
+package postgres
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/db"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgxpool"
+)
+
+const subscriptionColumns = "id, url, event_types, secret, rating_threshold::float8, created_at, tenant_id"
+
+const deliveryColumns = "id, subscription_id, outbox_id, event_type, status, attempts, next_attempt_at, last_error, delivered_at, created_at"
+
+type WebhookRepo struct {
+	pool *pgxpool.Pool
+}
+
+func NewWebhookRepo(pool *pgxpool.Pool) *WebhookRepo {
+	return &WebhookRepo{
+		pool: pool,
+	}
+}
+
+func scanSubscription(row pgx.Row) (model.WebhookSubscription, error) {
+	var sub model.WebhookSubscription
+	err := row.Scan(&sub.Id, &sub.URL, &sub.EventTypes, &sub.Secret, &sub.RatingThreshold, &sub.CreatedAt, &sub.Tenant)
+	return sub, err
+}
+
+func scanDelivery(row pgx.Row) (model.WebhookDelivery, error) {
+	var d model.WebhookDelivery
+	err := row.Scan(&d.Id, &d.SubscriptionId, &d.OutboxId, &d.EventType, &d.Status, &d.Attempts, &d.NextAttemptAt, &d.LastError, &d.DeliveredAt, &d.CreatedAt)
+	return d, err
+}
+
+func (r *WebhookRepo) CreateSubscription(ctx context.Context, sub model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	query := "INSERT INTO webhook_subscriptions (tenant_id, url, event_types, secret, rating_threshold) VALUES ($1, $2, $3, $4, $5) RETURNING " + subscriptionColumns
+
+	db.MarkWrite(ctx)
+	created, err := scanSubscription(r.pool.QueryRow(ctx, query, tenant.ID(ctx), sub.URL, sub.EventTypes, sub.Secret, sub.RatingThreshold))
+	if err != nil {
+		return nil, fmt.Errorf("failed to create webhook subscription: %w", err)
+	}
+
+	return &created, nil
+}
+
+func (r *WebhookRepo) ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error) {
+	rows, err := r.pool.Query(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions WHERE tenant_id = $1 ORDER BY id", tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook subscriptions: %w", err)
+	}
+
+	subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.WebhookSubscription, error) {
+		return scanSubscription(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook subscriptions: %w", err)
+	}
+
+	return subs, nil
+}
+
+func (r *WebhookRepo) DeleteSubscription(ctx context.Context, id int64) error {
+	db.MarkWrite(ctx)
+	cmdTag, err := r.pool.Exec(ctx, "DELETE FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $2", id, tenant.ID(ctx))
+	if err != nil {
+		return fmt.Errorf("failed to delete webhook subscription: %w", err)
+	}
+
+	if cmdTag.RowsAffected() == 0 {
+		return fmt.Errorf("%w: webhook subscription not found", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// ListDeliveries returns the newest deliveries of a subscription, optionally
+// only those with status.
+func (r *WebhookRepo) ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) ([]model.WebhookDelivery, error) {
+	query := `SELECT ` + deliveryColumns + ` FROM webhook_deliveries
+		WHERE subscription_id = (SELECT id FROM webhook_subscriptions WHERE id = $1 AND tenant_id = $4) AND ($2 = '' OR status = $2)
+		ORDER BY id DESC LIMIT $3`
+
+	rows, err := r.pool.Query(ctx, query, subscriptionId, status, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list webhook deliveries: %w", err)
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.WebhookDelivery, error) {
+		return scanDelivery(row)
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+// ReplayDelivery queues a delivery again from scratch, whatever its status.
+func (r *WebhookRepo) ReplayDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error) {
+	query := `UPDATE webhook_deliveries SET status = 'pending', attempts = 0, next_attempt_at = now(), last_error = NULL, delivered_at = NULL
+		WHERE id = $1 AND subscription_id IN (SELECT id FROM webhook_subscriptions WHERE tenant_id = $2)
+		RETURNING ` + deliveryColumns
+
+	db.MarkWrite(ctx)
+	delivery, err := scanDelivery(r.pool.QueryRow(ctx, query, id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, pgx.ErrNoRows) {
+			return nil, fmt.Errorf("%w: webhook delivery not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to replay webhook delivery: %w", err)
+	}
+
+	return &delivery, nil
+}
+
+// FanOut only offers events to the subscriptions of the same tenant.
+func (r *WebhookRepo) FanOut(ctx context.Context, limit int, match func(model.WebhookSubscription, model.OutboxEvent) (string, bool)) (int, error) {
+	var processed int
+	err := pgx.BeginFunc(ctx, r.pool, func(tx pgx.Tx) error {
+		rows, err := tx.Query(ctx, "SELECT id, tenant_id, event_type, payload, created_at FROM webhook_outbox WHERE processed_at IS NULL ORDER BY id LIMIT $1 FOR UPDATE SKIP LOCKED", limit)
+		if err != nil {
+			return fmt.Errorf("failed to read webhook outbox: %w", err)
+		}
+		events, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.OutboxEvent, error) {
+			var e model.OutboxEvent
+			err := row.Scan(&e.Id, &e.Tenant, &e.Type, &e.Payload, &e.CreatedAt)
+			return e, err
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan webhook outbox: %w", err)
+		}
+		if len(events) == 0 {
+			return nil
+		}
+
+		rows, err = tx.Query(ctx, "SELECT "+subscriptionColumns+" FROM webhook_subscriptions")
+		if err != nil {
+			return fmt.Errorf("failed to list webhook subscriptions: %w", err)
+		}
+		subs, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.WebhookSubscription, error) {
+			return scanSubscription(row)
+		})
+		if err != nil {
+			return fmt.Errorf("failed to scan webhook subscriptions: %w", err)
+		}
+
+		batch := &pgx.Batch{}
+		ids := make([]int64, 0, len(events))
+		for _, event := range events {
+			ids = append(ids, event.Id)
+			for _, sub := range subs {
+				if sub.Tenant != event.Tenant {
+					continue
+				}
+				if eventType, ok := match(sub, event); ok {
+					batch.Queue("INSERT INTO webhook_deliveries (subscription_id, outbox_id, event_type) VALUES ($1, $2, $3) ON CONFLICT DO NOTHING", sub.Id, event.Id, eventType)
+				}
+			}
+		}
+		batch.Queue("UPDATE webhook_outbox SET processed_at = now() WHERE id = ANY($1)", ids)
+
+		if err := tx.SendBatch(ctx, batch).Close(); err != nil {
+			return fmt.Errorf("failed to queue webhook deliveries: %w", err)
+		}
+		processed = len(events)
+
+		return nil
+	})
+
+	return processed, err
+}
+
+func (r *WebhookRepo) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.PendingDelivery, error) {
+	query := `UPDATE webhook_deliveries d
+		SET attempts = d.attempts + 1, next_attempt_at = now() + $2 * interval '1 millisecond'
+		FROM webhook_subscriptions s, webhook_outbox o
+		WHERE d.id IN (
+			SELECT id FROM webhook_deliveries
+			WHERE status = 'pending' AND next_attempt_at <= now()
+			ORDER BY next_attempt_at
+			LIMIT $1
+			FOR UPDATE SKIP LOCKED
+		) AND s.id = d.subscription_id AND o.id = d.outbox_id
+		RETURNING d.id, d.event_type, d.attempts, s.url, s.secret, o.payload, o.created_at`
+
+	rows, err := r.pool.Query(ctx, query, limit, lease.Milliseconds())
+	if err != nil {
+		return nil, fmt.Errorf("failed to claim webhook deliveries: %w", err)
+	}
+
+	deliveries, err := pgx.CollectRows(rows, func(row pgx.CollectableRow) (model.PendingDelivery, error) {
+		var d model.PendingDelivery
+		err := row.Scan(&d.Id, &d.EventType, &d.Attempts, &d.URL, &d.Secret, &d.Payload, &d.CreatedAt)
+		return d, err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to scan webhook deliveries: %w", err)
+	}
+
+	return deliveries, nil
+}
+
+func (r *WebhookRepo) MarkDelivered(ctx context.Context, id int64) error {
+	_, err := r.pool.Exec(ctx, "UPDATE webhook_deliveries SET status = 'delivered', delivered_at = now(), last_error = NULL WHERE id = $1", id)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery delivered: %w", err)
+	}
+
+	return nil
+}
+
+func (r *WebhookRepo) Prune(ctx context.Context, before time.Time) (int64, error) {
+	query := `DELETE FROM webhook_outbox o WHERE o.processed_at < $1
+		AND NOT EXISTS (SELECT 1 FROM webhook_deliveries d WHERE d.outbox_id = o.id AND d.status = 'pending')`
+
+	cmdTag, err := r.pool.Exec(ctx, query, before)
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune webhook outbox: %w", err)
+	}
+
+	return cmdTag.RowsAffected(), nil
+}
+
+func (r *WebhookRepo) MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time, dead bool) error {
+	query := "UPDATE webhook_deliveries SET status = CASE WHEN $4 THEN 'dead' ELSE 'pending' END, last_error = $2, next_attempt_at = $3 WHERE id = $1"
+
+	_, err := r.pool.Exec(ctx, query, id, reason, retryAt, dead)
+	if err != nil {
+		return fmt.Errorf("failed to mark webhook delivery failed: %w", err)
+	}
+
+	return nil
+}
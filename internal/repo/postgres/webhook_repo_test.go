@@ -0,0 +1,88 @@
+package postgres
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/webhook"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebhookRepo_Outbox(t *testing.T) {
+	pool := setupTestDB(t)
+	t.Cleanup(func() { pool.Close() })
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+
+	const secret = "0123456789abcdef"
+	received := make(chan webhook.Payload, 10)
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+		require.True(t, webhook.Verify(secret, r.Header.Get(webhook.HeaderTimestamp), r.Header.Get(webhook.HeaderSignature), body))
+
+		var payload webhook.Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		received <- payload
+	}))
+	t.Cleanup(receiver.Close)
+
+	webhooks := NewWebhookRepo(pool)
+	threshold := 0.5
+	sub, err := webhooks.CreateSubscription(ctx, model.WebhookSubscription{
+		URL:             receiver.URL,
+		EventTypes:      []string{model.WebhookUserCreated, model.WebhookRatingCrossed},
+		Secret:          secret,
+		RatingThreshold: &threshold,
+	})
+	require.NoError(t, err)
+
+	repo := NewUserRepo(pool, WithWebhookOutbox())
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "nickname", 1, 10)))
+	require.NoError(t, repo.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)}))
+	require.NoError(t, repo.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(8)}))
+	require.NoError(t, repo.Delete(ctx, "nickname"))
+
+	t.Run("failed write records nothing", func(t *testing.T) {
+		err := repo.ChangeData(ctx, "missing", request.UpdateUserDTO{Likes: ptrInt(1)})
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	dispatcher := webhook.NewDispatcher(webhooks, webhook.DispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    2,
+		Workers:      1,
+		MaxAttempts:  3,
+		Timeout:      time.Second,
+		BackoffBase:  time.Second,
+		BackoffMax:   time.Second,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	require.NoError(t, dispatcher.RunOnce(ctx))
+
+	var events []string
+	for range 2 {
+		events = append(events, (<-received).Event)
+	}
+	require.ElementsMatch(t, []string{model.WebhookUserCreated, model.WebhookRatingCrossed}, events)
+
+	deliveries, err := webhooks.ListDeliveries(ctx, sub.Id, model.DeliveryDelivered, 10)
+	require.NoError(t, err)
+	require.Len(t, deliveries, 2)
+
+	replayed, err := webhooks.ReplayDelivery(ctx, deliveries[0].Id)
+	require.NoError(t, err)
+	require.Equal(t, model.DeliveryPending, replayed.Status)
+	require.NoError(t, dispatcher.RunOnce(ctx))
+	require.Equal(t, deliveries[0].Id, (<-received).Id)
+
+	_, err = webhooks.ReplayDelivery(ctx, 999)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
@@ -0,0 +1,177 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const categoryQuery = `SELECT c.id, c.slug, c.name, c.created_at,
+	(SELECT COUNT(*) FROM user_categories uc WHERE uc.category_id = c.id)
+	FROM categories c`
+
+type CategoryRepo struct {
+	db *sql.DB
+}
+
+func NewCategoryRepo(db *sql.DB) *CategoryRepo {
+	return &CategoryRepo{
+		db: db,
+	}
+}
+
+func scanCategory(row interface{ Scan(...any) error }) (model.Category, error) {
+	var c model.Category
+	err := row.Scan(&c.Id, &c.Slug, &c.Name, &c.CreatedAt, &c.Members)
+	return c, err
+}
+
+func (r *CategoryRepo) CreateCategory(ctx context.Context, category model.Category) (*model.Category, error) {
+	category.CreatedAt = time.Now().UTC()
+
+	result, err := r.db.ExecContext(ctx, "INSERT INTO categories (tenant_id, slug, name, created_at) VALUES (?, ?, ?, ?)", tenant.ID(ctx), category.Slug, category.Name, category.CreatedAt)
+	if err != nil {
+		if errorCode(err) == sqlite3.SQLITE_CONSTRAINT_UNIQUE {
+			return nil, fmt.Errorf("%w: category %s already exists", model.ErrAlreadyExists, category.Slug)
+		}
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+	if category.Id, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to create category: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepo) ListCategories(ctx context.Context) ([]model.Category, error) {
+	rows, err := r.db.QueryContext(ctx, categoryQuery+" WHERE c.tenant_id = ? ORDER BY c.slug", tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list categories: %w", err)
+	}
+	defer rows.Close()
+
+	categories := make([]model.Category, 0)
+	for rows.Next() {
+		category, err := scanCategory(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan category: %w", err)
+		}
+		categories = append(categories, category)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return categories, nil
+}
+
+func (r *CategoryRepo) GetCategory(ctx context.Context, slug string) (*model.Category, error) {
+	category, err := scanCategory(r.db.QueryRowContext(ctx, categoryQuery+" WHERE c.tenant_id = ? AND c.slug = ?", tenant.ID(ctx), slug))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get category: %w", err)
+	}
+
+	return &category, nil
+}
+
+func (r *CategoryRepo) UpdateCategory(ctx context.Context, slug, name string) (*model.Category, error) {
+	result, err := r.db.ExecContext(ctx, "UPDATE categories SET name = ? WHERE tenant_id = ? AND slug = ?", name, tenant.ID(ctx), slug)
+	if err != nil {
+		return nil, fmt.Errorf("failed to update category: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	return r.GetCategory(ctx, slug)
+}
+
+func (r *CategoryRepo) DeleteCategory(ctx context.Context, slug string) error {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM categories WHERE tenant_id = ? AND slug = ?", tenant.ID(ctx), slug)
+	if err != nil {
+		return fmt.Errorf("failed to delete category: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("%w: category not found", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *CategoryRepo) AddMember(ctx context.Context, slug string, userId int64) error {
+	query := `INSERT INTO user_categories (user_id, category_id) SELECT ?, id FROM categories WHERE tenant_id = ? AND slug = ?
+		ON CONFLICT DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, userId, tenant.ID(ctx), slug)
+	if err != nil {
+		if errorCode(err) == sqlite3.SQLITE_CONSTRAINT_FOREIGNKEY {
+			return fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
+		return fmt.Errorf("failed to add category member: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		// either the category is missing or the user already belongs to it
+		if _, err := r.GetCategory(ctx, slug); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (r *CategoryRepo) RemoveMember(ctx context.Context, slug string, userId int64) error {
+	query := "DELETE FROM user_categories WHERE user_id = ? AND category_id = (SELECT id FROM categories WHERE tenant_id = ? AND slug = ?)"
+
+	result, err := r.db.ExecContext(ctx, query, userId, tenant.ID(ctx), slug)
+	if err != nil {
+		return fmt.Errorf("failed to remove category member: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return fmt.Errorf("%w: user is not in category %s", model.ErrNotFound, slug)
+	}
+
+	return nil
+}
+
+// UserCategories ranks the user within each of its categories with the
+// leaderboard order used by GetAll.
+func (r *CategoryRepo) UserCategories(ctx context.Context, userId int64) ([]model.CategoryRank, error) {
+	query := `SELECT c.id, c.slug, c.name, c.created_at, ranked.members, ranked.rank FROM (
+			SELECT uc.category_id, uc.user_id,
+				ROW_NUMBER() OVER (PARTITION BY uc.category_id ORDER BY ` + leaderboardOrder + `) AS rank,
+				COUNT(*) OVER (PARTITION BY uc.category_id) AS members
+			FROM user_categories uc JOIN users ON users.id = uc.user_id
+			WHERE uc.category_id IN (SELECT category_id FROM user_categories WHERE user_id = ?1)
+		) ranked JOIN categories c ON c.id = ranked.category_id
+		WHERE ranked.user_id = ?1
+		ORDER BY c.slug`
+
+	rows, err := r.db.QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get user categories: %w", err)
+	}
+	defer rows.Close()
+
+	ranks := make([]model.CategoryRank, 0)
+	for rows.Next() {
+		var rank model.CategoryRank
+		if err := rows.Scan(&rank.Category.Id, &rank.Category.Slug, &rank.Category.Name, &rank.Category.CreatedAt, &rank.Category.Members, &rank.Rank); err != nil {
+			return nil, fmt.Errorf("failed to scan user category: %w", err)
+		}
+		ranks = append(ranks, rank)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return ranks, nil
+}
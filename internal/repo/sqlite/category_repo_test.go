@@ -0,0 +1,102 @@
+package sqlite
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCategoryRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	users := NewUserRepo(sqlDB)
+	repo := NewCategoryRepo(sqlDB)
+
+	ids := make(map[string]int64)
+	for _, user := range []struct {
+		nickname string
+		likes    int
+	}{{"low", 10}, {"high", 90}, {"mid", 50}, {"outsider", 99}} {
+		require.NoError(t, users.Create(ctx, *model.NewUser("name", user.nickname, user.likes, 100)))
+		created, err := users.GetUser(ctx, user.nickname)
+		require.NoError(t, err)
+		ids[user.nickname] = created.Id
+	}
+
+	music, err := repo.CreateCategory(ctx, model.Category{Slug: "music", Name: "Music"})
+	require.NoError(t, err)
+	require.NotZero(t, music.Id)
+	_, err = repo.CreateCategory(ctx, model.Category{Slug: "music", Name: "Again"})
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+	_, err = repo.CreateCategory(ctx, model.Category{Slug: "gaming", Name: "Gaming"})
+	require.NoError(t, err)
+
+	for _, nickname := range []string{"low", "high", "mid"} {
+		require.NoError(t, repo.AddMember(ctx, "music", ids[nickname]))
+	}
+	require.NoError(t, repo.AddMember(ctx, "music", ids["mid"]))
+	require.NoError(t, repo.AddMember(ctx, "gaming", ids["low"]))
+	require.ErrorIs(t, repo.AddMember(ctx, "missing", ids["low"]), model.ErrNotFound)
+	require.ErrorIs(t, repo.AddMember(ctx, "music", 1000), model.ErrNotFound)
+
+	t.Run("filtered list", func(t *testing.T) {
+		params := request.NewPaginationQuery(2, 0, "desc")
+		params.Category = "music"
+		list, total, err := users.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, model.UserCount{Total: 3, Exact: true}, total)
+		require.Len(t, list, 2)
+		require.Equal(t, "high", list[0].NickName)
+		require.Equal(t, "mid", list[1].NickName)
+
+		params.Category = "missing"
+		list, total, err = users.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Zero(t, total.Total)
+		require.Empty(t, list)
+	})
+
+	t.Run("ranks", func(t *testing.T) {
+		ranks, err := repo.UserCategories(ctx, ids["low"])
+		require.NoError(t, err)
+		require.Len(t, ranks, 2)
+		require.Equal(t, "gaming", ranks[0].Category.Slug)
+		require.Equal(t, 1, ranks[0].Rank)
+		require.Equal(t, 1, ranks[0].Category.Members)
+		require.Equal(t, "music", ranks[1].Category.Slug)
+		require.Equal(t, 3, ranks[1].Rank)
+		require.Equal(t, 3, ranks[1].Category.Members)
+
+		ranks, err = repo.UserCategories(ctx, ids["outsider"])
+		require.NoError(t, err)
+		require.Empty(t, ranks)
+	})
+
+	t.Run("crud", func(t *testing.T) {
+		updated, err := repo.UpdateCategory(ctx, "music", "Music & Audio")
+		require.NoError(t, err)
+		require.Equal(t, "Music & Audio", updated.Name)
+		require.Equal(t, 3, updated.Members)
+
+		require.NoError(t, repo.RemoveMember(ctx, "music", ids["low"]))
+		require.ErrorIs(t, repo.RemoveMember(ctx, "music", ids["low"]), model.ErrNotFound)
+
+		require.NoError(t, users.Delete(ctx, "mid"))
+		categories, err := repo.ListCategories(ctx)
+		require.NoError(t, err)
+		require.Len(t, categories, 2)
+		require.Equal(t, "music", categories[1].Slug)
+		require.Equal(t, 1, categories[1].Members)
+
+		require.NoError(t, repo.DeleteCategory(ctx, "gaming"))
+		require.ErrorIs(t, repo.DeleteCategory(ctx, "gaming"), model.ErrNotFound)
+		ranks, err := repo.UserCategories(ctx, ids["low"])
+		require.NoError(t, err)
+		require.Empty(t, ranks)
+	})
+}
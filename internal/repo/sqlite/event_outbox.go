@@ -0,0 +1,98 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rating/internal/event"
+	"rating/internal/tenant"
+	"strings"
+	"time"
+)
+
+// EventOutbox stores domain events in the event_outbox table. Append joins the
+// transaction carried by ctx, so events commit together with the write.
+type EventOutbox struct {
+	db *sql.DB
+}
+
+func NewEventOutbox(db *sql.DB) *EventOutbox {
+	return &EventOutbox{
+		db: db,
+	}
+}
+
+func (o *EventOutbox) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return o.db
+}
+
+// Append records events under the tenant of ctx.
+func (o *EventOutbox) Append(ctx context.Context, events ...event.Event) error {
+	for _, e := range events {
+		_, err := o.conn(ctx).ExecContext(ctx, "INSERT INTO event_outbox (tenant_id, event_type, nickname, payload, occurred_at) VALUES (?, ?, ?, ?, ?)", tenant.ID(ctx), e.Type, e.Nickname, string(e.Data), e.OccurredAt.UTC())
+		if err != nil {
+			return fmt.Errorf("failed to append event: %w", err)
+		}
+	}
+
+	return nil
+}
+
+// Relay publishes outside a transaction: the database has a single connection
+// and a single relay, so holding it while fn runs would only stall writers.
+func (o *EventOutbox) Relay(ctx context.Context, limit int, fn func([]event.Event) error) (int, error) {
+	rows, err := o.db.QueryContext(ctx, "SELECT id, tenant_id, event_type, nickname, payload, occurred_at FROM event_outbox WHERE published_at IS NULL ORDER BY id LIMIT ?", limit)
+	if err != nil {
+		return 0, fmt.Errorf("failed to read event outbox: %w", err)
+	}
+	defer rows.Close()
+
+	var events []event.Event
+	for rows.Next() {
+		var e event.Event
+		var payload string
+		if err := rows.Scan(&e.Id, &e.Tenant, &e.Type, &e.Nickname, &payload, &e.OccurredAt); err != nil {
+			return 0, fmt.Errorf("failed to scan event outbox: %w", err)
+		}
+		e.Data = []byte(payload)
+		events = append(events, e)
+	}
+	if err := rows.Err(); err != nil {
+		return 0, fmt.Errorf("rows iteration error: %w", err)
+	}
+	rows.Close()
+
+	if len(events) == 0 {
+		return 0, nil
+	}
+
+	if err := fn(events); err != nil {
+		return 0, fmt.Errorf("failed to publish events: %w", err)
+	}
+
+	placeholders := make([]string, 0, len(events))
+	args := []any{time.Now().UTC()}
+	for _, e := range events {
+		placeholders = append(placeholders, "?")
+		args = append(args, e.Id)
+	}
+	query := fmt.Sprintf("UPDATE event_outbox SET published_at = ? WHERE id IN (%s)", strings.Join(placeholders, ", "))
+	if _, err := o.db.ExecContext(ctx, query, args...); err != nil {
+		return 0, fmt.Errorf("failed to mark events published: %w", err)
+	}
+
+	return len(events), nil
+}
+
+func (o *EventOutbox) Prune(ctx context.Context, before time.Time) (int64, error) {
+	result, err := o.db.ExecContext(ctx, "DELETE FROM event_outbox WHERE published_at < ?", before.UTC())
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune event outbox: %w", err)
+	}
+
+	return result.RowsAffected()
+}
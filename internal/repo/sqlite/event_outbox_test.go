@@ -0,0 +1,57 @@
+package sqlite
+
+import (
+	"context"
+	"errors"
+	"rating/internal/dto/request"
+	"rating/internal/event"
+	"rating/internal/service"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestEventOutbox(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	outbox := NewEventOutbox(sqlDB)
+	svc := service.NewUserService(NewUserRepo(sqlDB), service.WithTxManager(NewTxManager(sqlDB, 3)), service.WithEventOutbox(outbox))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 2}))
+	likes := 2
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: &likes}))
+
+	t.Run("rolled back writes record nothing", func(t *testing.T) {
+		tooMany := 10
+		require.Error(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: &tooMany}))
+	})
+
+	publishErr := errors.New("publish failed")
+	_, err := outbox.Relay(ctx, 10, func([]event.Event) error { return publishErr })
+	require.ErrorIs(t, err, publishErr)
+
+	var relayed []event.Event
+	n, err := outbox.Relay(ctx, 10, func(events []event.Event) error {
+		relayed = append(relayed, events...)
+		return nil
+	})
+	require.NoError(t, err)
+	require.Equal(t, 3, n)
+	require.Equal(t, event.TypeUserCreated, relayed[0].Type)
+	require.Equal(t, event.TypeUserUpdated, relayed[1].Type)
+	require.Equal(t, event.TypeRatingChanged, relayed[2].Type)
+	require.Less(t, relayed[0].Id, relayed[1].Id)
+	require.JSONEq(t, `{"nickname":"nickname","old_rating":0.5,"new_rating":1}`, string(relayed[2].Data))
+	require.WithinDuration(t, time.Now(), relayed[0].OccurredAt, time.Minute)
+
+	n, err = outbox.Relay(ctx, 10, func([]event.Event) error { return nil })
+	require.NoError(t, err)
+	require.Zero(t, n)
+
+	pruned, err := outbox.Prune(ctx, time.Now().Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, int64(3), pruned)
+}
@@ -0,0 +1,88 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rating/internal/model"
+	"time"
+)
+
+type JobRunRepo struct {
+	db *sql.DB
+}
+
+func NewJobRunRepo(db *sql.DB) *JobRunRepo {
+	return &JobRunRepo{
+		db: db,
+	}
+}
+
+func (r *JobRunRepo) StartRun(ctx context.Context, run model.JobRun) (*model.JobRun, error) {
+	query := `INSERT INTO job_runs (job, scheduled_at, started_at, status, instance) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (job, scheduled_at) DO NOTHING`
+
+	result, err := r.db.ExecContext(ctx, query, run.Job, run.ScheduledAt.UTC(), run.StartedAt.UTC(), run.Status, run.Instance)
+	if err != nil {
+		return nil, fmt.Errorf("failed to start job run: %w", err)
+	}
+	if n, err := result.RowsAffected(); err != nil || n == 0 {
+		return nil, fmt.Errorf("%w: job %s already ran for %s", model.ErrConflict, run.Job, run.ScheduledAt.Format(time.RFC3339))
+	}
+	if run.Id, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to start job run: %w", err)
+	}
+
+	return &run, nil
+}
+
+func (r *JobRunRepo) FinishRun(ctx context.Context, run model.JobRun) error {
+	var finishedAt *time.Time
+	if run.FinishedAt != nil {
+		utc := run.FinishedAt.UTC()
+		finishedAt = &utc
+	}
+
+	_, err := r.db.ExecContext(ctx, "UPDATE job_runs SET finished_at = ?, status = ?, error = ? WHERE id = ?", finishedAt, run.Status, run.Error, run.Id)
+	if err != nil {
+		return fmt.Errorf("failed to finish job run: %w", err)
+	}
+
+	return nil
+}
+
+// ListRuns returns the newest runs first, of every job when job is empty.
+func (r *JobRunRepo) ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error) {
+	query := `SELECT id, job, scheduled_at, started_at, finished_at, status, error, instance FROM job_runs
+		WHERE (?1 = '' OR job = ?1) ORDER BY id DESC LIMIT ?2`
+
+	rows, err := r.db.QueryContext(ctx, query, job, limit)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list job runs: %w", err)
+	}
+	defer rows.Close()
+
+	runs := make([]model.JobRun, 0)
+	for rows.Next() {
+		var run model.JobRun
+		if err := rows.Scan(&run.Id, &run.Job, &run.ScheduledAt, &run.StartedAt, &run.FinishedAt, &run.Status, &run.Error, &run.Instance); err != nil {
+			return nil, fmt.Errorf("failed to scan job run: %w", err)
+		}
+		runs = append(runs, run)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return runs, nil
+}
+
+// PruneRuns deletes runs started before the given time.
+func (r *JobRunRepo) PruneRuns(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM job_runs WHERE started_at < ?", before.UTC())
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune job runs: %w", err)
+	}
+
+	return result.RowsAffected()
+}
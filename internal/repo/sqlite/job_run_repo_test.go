@@ -0,0 +1,54 @@
+package sqlite
+
+import (
+	"context"
+	"rating/internal/model"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestJobRunRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	repo := NewJobRunRepo(sqlDB)
+	slot := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+	run := model.JobRun{Job: "job", ScheduledAt: slot, StartedAt: time.Now(), Status: model.JobRunning, Instance: "a"}
+
+	started, err := repo.StartRun(ctx, run)
+	require.NoError(t, err)
+	require.NotZero(t, started.Id)
+
+	_, err = repo.StartRun(ctx, run)
+	require.ErrorIs(t, err, model.ErrConflict)
+
+	finished := time.Now()
+	started.FinishedAt, started.Status, started.Error = &finished, model.JobFailed, "boom"
+	require.NoError(t, repo.FinishRun(ctx, *started))
+
+	other := run
+	other.Job, other.StartedAt = "other", time.Now().Add(-48*time.Hour)
+	_, err = repo.StartRun(ctx, other)
+	require.NoError(t, err)
+
+	runs, err := repo.ListRuns(ctx, "job", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 1)
+	require.Equal(t, model.JobFailed, runs[0].Status)
+	require.Equal(t, "boom", runs[0].Error)
+	require.True(t, slot.Equal(runs[0].ScheduledAt))
+	require.NotNil(t, runs[0].FinishedAt)
+
+	runs, err = repo.ListRuns(ctx, "", 10)
+	require.NoError(t, err)
+	require.Len(t, runs, 2)
+	require.Equal(t, "other", runs[0].Job)
+	require.Nil(t, runs[0].FinishedAt)
+
+	pruned, err := repo.PruneRuns(ctx, time.Now().Add(-time.Hour))
+	require.NoError(t, err)
+	require.Equal(t, int64(1), pruned)
+}
@@ -0,0 +1,155 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+)
+
+type LeaderboardRepo struct {
+	db *sql.DB
+}
+
+func NewLeaderboardRepo(db *sql.DB) *LeaderboardRepo {
+	return &LeaderboardRepo{
+		db: db,
+	}
+}
+
+// TakeSnapshot stores the rank of every user within its tenant, ranked like
+// GetAll sorts them.
+func (r *LeaderboardRepo) TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error) {
+	tx, err := r.db.BeginTx(ctx, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	snapshot := model.LeaderboardSnapshot{TakenAt: time.Now().UTC()}
+	result, err := tx.ExecContext(ctx, "INSERT INTO leaderboard_snapshots (taken_at) VALUES (?)", snapshot.TakenAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+	if snapshot.Id, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+
+	result, err = tx.ExecContext(ctx, `INSERT INTO leaderboard_entries (snapshot_id, tenant_id, user_id, nickname, rating, rank)
+		SELECT ?, tenant_id, id, nickname, rating, ROW_NUMBER() OVER (PARTITION BY tenant_id ORDER BY `+leaderboardOrder+`) FROM users`, snapshot.Id)
+	if err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+	users, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+	snapshot.Users = int(users)
+
+	if _, err := tx.ExecContext(ctx, "UPDATE leaderboard_snapshots SET users = ? WHERE id = ?", snapshot.Users, snapshot.Id); err != nil {
+		return nil, fmt.Errorf("failed to take leaderboard snapshot: %w", err)
+	}
+
+	if err := tx.Commit(); err != nil {
+		return nil, fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return &snapshot, nil
+}
+
+// ListSnapshots reports the number of users of the tenant in each snapshot.
+func (r *LeaderboardRepo) ListSnapshots(ctx context.Context, limit int) ([]model.LeaderboardSnapshot, error) {
+	query := `SELECT s.id, s.taken_at, (SELECT count(*) FROM leaderboard_entries e WHERE e.snapshot_id = s.id AND e.tenant_id = ?2)
+		FROM leaderboard_snapshots s ORDER BY s.id DESC LIMIT ?1`
+
+	rows, err := r.db.QueryContext(ctx, query, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list leaderboard snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	snapshots := make([]model.LeaderboardSnapshot, 0)
+	for rows.Next() {
+		var s model.LeaderboardSnapshot
+		if err := rows.Scan(&s.Id, &s.TakenAt, &s.Users); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard snapshot: %w", err)
+		}
+		snapshots = append(snapshots, s)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return snapshots, nil
+}
+
+// DiffSnapshots returns the users whose rank differs between the snapshots,
+// including users present in only one of them, ordered by their new rank.
+func (r *LeaderboardRepo) DiffSnapshots(ctx context.Context, from, to int64, limit int) ([]model.RankChange, error) {
+	var found int
+	if err := r.db.QueryRowContext(ctx, "SELECT count(*) FROM leaderboard_snapshots WHERE id = ? OR id = ?", from, to).Scan(&found); err != nil {
+		return nil, fmt.Errorf("failed to find leaderboard snapshots: %w", err)
+	}
+	if found == 0 || (from != to && found < 2) {
+		return nil, fmt.Errorf("%w: leaderboard snapshot not found", model.ErrNotFound)
+	}
+
+	query := `SELECT COALESCE(t.user_id, f.user_id), COALESCE(t.nickname, f.nickname), f.rank, t.rank
+		FROM (SELECT user_id, nickname, rank FROM leaderboard_entries WHERE snapshot_id = ?1 AND tenant_id = ?4) f
+		FULL OUTER JOIN (SELECT user_id, nickname, rank FROM leaderboard_entries WHERE snapshot_id = ?2 AND tenant_id = ?4) t ON t.user_id = f.user_id
+		WHERE f.rank IS NOT t.rank
+		ORDER BY t.rank NULLS LAST, f.rank
+		LIMIT ?3`
+
+	rows, err := r.db.QueryContext(ctx, query, from, to, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to diff leaderboard snapshots: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]model.RankChange, 0)
+	for rows.Next() {
+		var userId int64
+		var nickname string
+		var fromRank, toRank *int
+		if err := rows.Scan(&userId, &nickname, &fromRank, &toRank); err != nil {
+			return nil, fmt.Errorf("failed to scan leaderboard diff: %w", err)
+		}
+		changes = append(changes, model.NewRankChange(userId, nickname, fromRank, toRank))
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *LeaderboardRepo) UserRank(ctx context.Context, userId, snapshotId int64) (*model.SnapshotRank, error) {
+	query := `SELECT e.snapshot_id, s.taken_at, e.rank, e.rating FROM leaderboard_entries e
+		JOIN leaderboard_snapshots s ON s.id = e.snapshot_id
+		WHERE e.user_id = ? AND e.snapshot_id = ?`
+
+	var rank model.SnapshotRank
+	err := r.db.QueryRowContext(ctx, query, userId, snapshotId).Scan(&rank.SnapshotId, &rank.TakenAt, &rank.Rank, &rank.Rating)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user is not in leaderboard snapshot %d", model.ErrNotFound, snapshotId)
+		}
+		return nil, fmt.Errorf("failed to get user rank: %w", err)
+	}
+
+	return &rank, nil
+}
+
+// PruneSnapshots deletes all but the newest keep snapshots.
+func (r *LeaderboardRepo) PruneSnapshots(ctx context.Context, keep int) (int64, error) {
+	result, err := r.db.ExecContext(ctx, "DELETE FROM leaderboard_snapshots WHERE id NOT IN (SELECT id FROM leaderboard_snapshots ORDER BY id DESC LIMIT ?)", keep)
+	if err != nil {
+		return -1, fmt.Errorf("failed to prune leaderboard snapshots: %w", err)
+	}
+
+	return result.RowsAffected()
+}
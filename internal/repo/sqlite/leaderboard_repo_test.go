@@ -0,0 +1,120 @@
+package sqlite
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLeaderboardRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	repo := NewUserRepo(sqlDB)
+	leaderboard := NewLeaderboardRepo(sqlDB)
+	svc := service.NewLeaderboardService(leaderboard, repo)
+
+	for _, user := range []struct {
+		nickname string
+		likes    int
+	}{{"first", 90}, {"second", 50}, {"third", 10}} {
+		require.NoError(t, repo.Create(ctx, *model.NewUser("name", user.nickname, user.likes, 100)))
+	}
+
+	_, err := svc.UserRank(ctx, "first")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	first, err := leaderboard.TakeSnapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, first.Users)
+
+	rank, err := svc.UserRank(ctx, "second")
+	require.NoError(t, err)
+	require.Equal(t, 2, rank.Current.Rank)
+	require.Nil(t, rank.Previous)
+	require.Nil(t, rank.Delta)
+
+	likes := 95
+	require.NoError(t, repo.ChangeData(ctx, "third", request.UpdateUserDTO{Likes: &likes}))
+	require.NoError(t, repo.Delete(ctx, "second"))
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "fourth", 5, 100)))
+
+	second, err := leaderboard.TakeSnapshot(ctx)
+	require.NoError(t, err)
+	require.Equal(t, 3, second.Users)
+
+	changes, err := svc.Diff(ctx, 0, 0, 0)
+	require.NoError(t, err)
+	require.Len(t, changes, 4)
+	require.Equal(t, "third", changes[0].Nickname)
+	require.Equal(t, 3, *changes[0].FromRank)
+	require.Equal(t, 1, *changes[0].ToRank)
+	require.Equal(t, 2, *changes[0].Delta)
+	require.Equal(t, "first", changes[1].Nickname)
+	require.Equal(t, -1, *changes[1].Delta)
+	require.Equal(t, "fourth", changes[2].Nickname)
+	require.Nil(t, changes[2].FromRank)
+	require.Nil(t, changes[2].Delta)
+	require.Equal(t, "second", changes[3].Nickname)
+	require.Nil(t, changes[3].ToRank)
+
+	rank, err = svc.UserRank(ctx, "third")
+	require.NoError(t, err)
+	require.Equal(t, second.Id, rank.Current.SnapshotId)
+	require.Equal(t, 1, rank.Current.Rank)
+	require.Equal(t, 3, rank.Previous.Rank)
+	require.Equal(t, 2, *rank.Delta)
+
+	rank, err = svc.UserRank(ctx, "fourth")
+	require.NoError(t, err)
+	require.Nil(t, rank.Previous)
+
+	_, err = svc.Diff(ctx, first.Id, second.Id+1, 0)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	t.Run("prune keeps the newest", func(t *testing.T) {
+		pruned, err := leaderboard.PruneSnapshots(ctx, 1)
+		require.NoError(t, err)
+		require.Equal(t, int64(1), pruned)
+
+		snapshots, err := svc.ListSnapshots(ctx, 0)
+		require.NoError(t, err)
+		require.Len(t, snapshots, 1)
+		require.Equal(t, second.Id, snapshots[0].Id)
+
+		_, err = svc.UserRank(ctx, "third")
+		require.NoError(t, err)
+	})
+
+	t.Run("ranks within the tenant", func(t *testing.T) {
+		acme := tenant.WithID(ctx, "acme")
+		require.NoError(t, repo.Create(acme, *model.NewUser("name", "top", 100, 100)))
+
+		third, err := leaderboard.TakeSnapshot(ctx)
+		require.NoError(t, err)
+		require.Equal(t, 4, third.Users)
+
+		rank, err := svc.UserRank(acme, "top")
+		require.NoError(t, err)
+		require.Equal(t, 1, rank.Current.Rank)
+
+		rank, err = svc.UserRank(ctx, "third")
+		require.NoError(t, err)
+		require.Equal(t, 1, rank.Current.Rank)
+
+		snapshots, err := svc.ListSnapshots(acme, 0)
+		require.NoError(t, err)
+		require.Equal(t, 1, snapshots[0].Users)
+
+		changes, err := svc.Diff(acme, 0, 0, 0)
+		require.NoError(t, err)
+		require.Len(t, changes, 1)
+		require.Equal(t, "top", changes[0].Nickname)
+	})
+}
@@ -0,0 +1,66 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rating/internal/model"
+)
+
+type MilestoneRepo struct {
+	db *sql.DB
+}
+
+func NewMilestoneRepo(db *sql.DB) *MilestoneRepo {
+	return &MilestoneRepo{
+		db: db,
+	}
+}
+
+func (r *MilestoneRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.db
+}
+
+func (r *MilestoneRepo) RecordMilestones(ctx context.Context, userId int64, milestones []model.Milestone) ([]model.Milestone, error) {
+	query := "INSERT INTO user_milestones (user_id, name, metric, threshold, value, reached_at) VALUES (?, ?, ?, ?, ?, ?) ON CONFLICT (user_id, name) DO NOTHING"
+
+	var recorded []model.Milestone
+	for _, m := range milestones {
+		result, err := r.conn(ctx).ExecContext(ctx, query, userId, m.Name, m.Metric, m.Threshold, m.Value, m.ReachedAt.UTC())
+		if err != nil {
+			return nil, fmt.Errorf("failed to record milestone %s: %w", m.Name, err)
+		}
+		if n, err := result.RowsAffected(); err != nil || n == 0 {
+			continue
+		}
+		recorded = append(recorded, m)
+	}
+
+	return recorded, nil
+}
+
+func (r *MilestoneRepo) ListMilestones(ctx context.Context, userId int64) ([]model.Milestone, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, "SELECT name, metric, threshold, value, reached_at FROM user_milestones WHERE user_id = ? ORDER BY reached_at, name", userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list milestones: %w", err)
+	}
+	defer rows.Close()
+
+	milestones := make([]model.Milestone, 0)
+	for rows.Next() {
+		var m model.Milestone
+		if err := rows.Scan(&m.Name, &m.Metric, &m.Threshold, &m.Value, &m.ReachedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan milestone: %w", err)
+		}
+		milestones = append(milestones, m)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return milestones, nil
+}
@@ -0,0 +1,45 @@
+package sqlite
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMilestoneRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	repo := NewUserRepo(sqlDB)
+	milestones := NewMilestoneRepo(sqlDB)
+	rules := []model.MilestoneRule{{Metric: model.MetricViewers, Threshold: 1000}}
+	svc := service.NewUserService(repo, service.WithTxManager(NewTxManager(sqlDB, 3)), service.WithMilestones(milestones, rules))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 10}))
+	viewers := 1500
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: &viewers}))
+	viewers = 2000
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: &viewers}))
+
+	reached, err := svc.ListMilestones(ctx, "nickname")
+	require.NoError(t, err)
+	require.Len(t, reached, 1)
+	require.Equal(t, "viewers>=1000", reached[0].Name)
+	require.Equal(t, float64(1500), reached[0].Value)
+	require.False(t, reached[0].ReachedAt.IsZero())
+
+	t.Run("deleted with the user", func(t *testing.T) {
+		user, err := repo.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.NoError(t, svc.Delete(ctx, "nickname"))
+
+		reached, err := milestones.ListMilestones(ctx, user.Id)
+		require.NoError(t, err)
+		require.Empty(t, reached)
+	})
+}
@@ -0,0 +1,94 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+)
+
+type NicknameRepo struct {
+	db *sql.DB
+}
+
+func NewNicknameRepo(db *sql.DB) *NicknameRepo {
+	return &NicknameRepo{
+		db: db,
+	}
+}
+
+func (r *NicknameRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.db
+}
+
+// RecordRename releases from, taking it over from whichever user released it
+// before.
+func (r *NicknameRepo) RecordRename(ctx context.Context, userId int64, from string) error {
+	query := `INSERT INTO nickname_aliases (tenant_id, nickname, nickname_key, user_id, released_at) VALUES (?, ?, ?, ?, ?)
+		ON CONFLICT (tenant_id, nickname_key) DO UPDATE SET nickname = excluded.nickname, user_id = excluded.user_id, released_at = excluded.released_at`
+
+	if _, err := r.conn(ctx).ExecContext(ctx, query, tenant.ID(ctx), from, model.NicknameKey(from), userId, time.Now().UTC()); err != nil {
+		return fmt.Errorf("failed to record nickname alias: %w", err)
+	}
+
+	return nil
+}
+
+func (r *NicknameRepo) GetAlias(ctx context.Context, nickname string) (*model.NicknameAlias, error) {
+	query := `SELECT a.nickname, COALESCE(a.user_id, 0), COALESCE(u.nickname, ''), a.released_at FROM nickname_aliases a LEFT JOIN users u ON u.id = a.user_id
+		WHERE a.tenant_id = ? AND a.nickname_key = ?`
+
+	var alias model.NicknameAlias
+	err := r.conn(ctx).QueryRowContext(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&alias.Nickname, &alias.UserId, &alias.Current, &alias.ReleasedAt)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get nickname alias: %w", err)
+	}
+
+	return &alias, nil
+}
+
+func (r *NicknameRepo) ListAliases(ctx context.Context, userId int64) ([]model.NicknameAlias, error) {
+	query := `SELECT a.nickname, a.user_id, u.nickname, a.released_at FROM nickname_aliases a JOIN users u ON u.id = a.user_id
+		WHERE a.user_id = ? ORDER BY a.released_at DESC, a.nickname`
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, userId)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list nickname aliases: %w", err)
+	}
+	defer rows.Close()
+
+	aliases := make([]model.NicknameAlias, 0)
+	for rows.Next() {
+		var a model.NicknameAlias
+		if err := rows.Scan(&a.Nickname, &a.UserId, &a.Current, &a.ReleasedAt); err != nil {
+			return nil, fmt.Errorf("failed to scan nickname alias: %w", err)
+		}
+		aliases = append(aliases, a)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return aliases, nil
+}
+
+// PurgeDeleted deletes the nicknames released by deleted users before the
+// given time, in every tenant.
+func (r *NicknameRepo) PurgeDeleted(ctx context.Context, before time.Time) (int64, error) {
+	result, err := r.conn(ctx).ExecContext(ctx, "DELETE FROM nickname_aliases WHERE user_id IS NULL AND released_at < ?", before.UTC())
+	if err != nil {
+		return -1, fmt.Errorf("failed to purge nickname aliases: %w", err)
+	}
+
+	return result.RowsAffected()
+}
@@ -0,0 +1,117 @@
+package sqlite
+
+import (
+	"context"
+	"path/filepath"
+	"rating/internal/db"
+	"rating/internal/dto/request"
+	"rating/internal/migrate"
+	"rating/internal/model"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestNicknameRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	repo := NewUserRepo(sqlDB)
+	nicknames := NewNicknameRepo(sqlDB)
+	svc := service.NewUserService(repo, service.WithTxManager(NewTxManager(sqlDB, 3)), service.WithNicknameHistory(nicknames, time.Hour))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "first", Likes: 1, Viewers: 10}))
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "other", Likes: 1, Viewers: 10}))
+	second, third, first := "second", "third", "first"
+	require.NoError(t, svc.ChangeData(ctx, "first", request.UpdateUserDTO{Nickname: &second}))
+	require.NoError(t, svc.ChangeData(ctx, "second", request.UpdateUserDTO{Nickname: &third}))
+
+	current, err := svc.ResolveNickname(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, "third", current)
+
+	history, err := svc.ListNicknames(ctx, "third")
+	require.NoError(t, err)
+	require.Len(t, history, 2)
+	require.Equal(t, "third", history[0].Current)
+
+	err = svc.ChangeData(ctx, "other", request.UpdateUserDTO{Nickname: &first})
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+	t.Run("matched by folded key", func(t *testing.T) {
+		current, err := svc.ResolveNickname(ctx, "ＦＩＲＳＴ")
+		require.NoError(t, err)
+		require.Equal(t, "third", current)
+
+		upper := "First"
+		err = svc.ChangeData(ctx, "other", request.UpdateUserDTO{Nickname: &upper})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+	})
+
+	_, err = nicknames.GetAlias(tenant.WithID(ctx, "acme"), "first")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	t.Run("kept released after the user is deleted", func(t *testing.T) {
+		require.NoError(t, svc.Delete(ctx, "third"))
+
+		for _, nickname := range []string{"first", "third"} {
+			alias, err := nicknames.GetAlias(ctx, nickname)
+			require.NoError(t, err)
+			require.Zero(t, alias.UserId)
+
+			_, err = svc.ResolveNickname(ctx, nickname)
+			require.ErrorIs(t, err, model.ErrNotFound)
+			err = svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: nickname, Likes: 1, Viewers: 10})
+			require.ErrorIs(t, err, model.ErrAlreadyExists)
+		}
+	})
+
+	t.Run("purged after the cooldown", func(t *testing.T) {
+		require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "kept", Likes: 1, Viewers: 10}))
+		renamed := "renamed"
+		require.NoError(t, svc.ChangeData(ctx, "kept", request.UpdateUserDTO{Nickname: &renamed}))
+
+		purged, err := nicknames.PurgeDeleted(ctx, time.Now().Add(-time.Hour))
+		require.NoError(t, err)
+		require.Zero(t, purged)
+
+		purged, err = nicknames.PurgeDeleted(ctx, time.Now().Add(time.Second))
+		require.NoError(t, err)
+		require.Equal(t, int64(3), purged)
+
+		_, err = nicknames.GetAlias(ctx, "first")
+		require.ErrorIs(t, err, model.ErrNotFound)
+		_, err = nicknames.GetAlias(ctx, "kept")
+		require.NoError(t, err)
+	})
+}
+
+func TestNicknameKeys_Backfill(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB, err := db.NewSQLite(ctx, filepath.Join(t.TempDir(), "rating.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	runner, err := migrate.NewSQLiteRunner(sqlDB)
+	require.NoError(t, err)
+	require.NoError(t, runner.To(ctx, 20261019190000))
+
+	_, err = sqlDB.ExecContext(ctx, "INSERT INTO users (tenant_id, name, nickname, likes, viewers) VALUES ('default', 'name', 'Ｓｔｒａßｅ', 1, 10)")
+	require.NoError(t, err)
+	require.NoError(t, runner.Up(ctx))
+
+	var key string
+	require.NoError(t, sqlDB.QueryRowContext(ctx, "SELECT nickname_key FROM users WHERE nickname = 'Ｓｔｒａßｅ'").Scan(&key))
+	require.Equal(t, model.NicknameKey("STRASSE"), key)
+
+	err = NewUserRepo(sqlDB).Create(ctx, *model.NewUser("name", "strasse", 1, 10))
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+	require.NoError(t, runner.To(ctx, 20261019180000))
+	require.NoError(t, runner.Up(ctx))
+}
@@ -0,0 +1,135 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"time"
+)
+
+const quarantineQuery = `SELECT q.id, q.user_id, u.nickname, q.change, q.reasons, q.status, q.created_at, q.reviewed_at
+	FROM quarantined_changes q JOIN users u ON u.id = q.user_id`
+
+type QuarantineRepo struct {
+	db *sql.DB
+}
+
+func NewQuarantineRepo(db *sql.DB) *QuarantineRepo {
+	return &QuarantineRepo{
+		db: db,
+	}
+}
+
+func (r *QuarantineRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.db
+}
+
+type scanner interface {
+	Scan(dest ...any) error
+}
+
+func scanQuarantined(row scanner) (model.QuarantinedChange, error) {
+	var c model.QuarantinedChange
+	var change, reasons string
+	var reviewedAt sql.NullTime
+	if err := row.Scan(&c.Id, &c.UserId, &c.Nickname, &change, &reasons, &c.Status, &c.CreatedAt, &reviewedAt); err != nil {
+		return c, err
+	}
+	if reviewedAt.Valid {
+		c.ReviewedAt = &reviewedAt.Time
+	}
+	if err := json.Unmarshal([]byte(change), &c.Change); err != nil {
+		return c, err
+	}
+
+	return c, json.Unmarshal([]byte(reasons), &c.Reasons)
+}
+
+func (r *QuarantineRepo) Quarantine(ctx context.Context, change model.QuarantinedChange) (*model.QuarantinedChange, error) {
+	data, err := json.Marshal(change.Change)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal change: %w", err)
+	}
+	reasons, err := json.Marshal(change.Reasons)
+	if err != nil {
+		return nil, fmt.Errorf("failed to marshal reasons: %w", err)
+	}
+
+	change.Status = model.QuarantinePending
+	change.CreatedAt = time.Now().UTC()
+	result, err := r.conn(ctx).ExecContext(ctx, "INSERT INTO quarantined_changes (user_id, change, reasons, created_at) VALUES (?, ?, ?, ?)", change.UserId, string(data), string(reasons), change.CreatedAt)
+	if err != nil {
+		return nil, fmt.Errorf("failed to quarantine change: %w", err)
+	}
+	if change.Id, err = result.LastInsertId(); err != nil {
+		return nil, fmt.Errorf("failed to quarantine change: %w", err)
+	}
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error) {
+	rows, err := r.conn(ctx).QueryContext(ctx, quarantineQuery+" WHERE u.tenant_id = ?3 AND (?1 = '' OR q.status = ?1) ORDER BY q.id LIMIT ?2", status, limit, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to list quarantined changes: %w", err)
+	}
+	defer rows.Close()
+
+	changes := make([]model.QuarantinedChange, 0)
+	for rows.Next() {
+		change, err := scanQuarantined(rows)
+		if err != nil {
+			return nil, fmt.Errorf("failed to scan quarantined change: %w", err)
+		}
+		changes = append(changes, change)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return changes, nil
+}
+
+func (r *QuarantineRepo) GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	change, err := scanQuarantined(r.conn(ctx).QueryRowContext(ctx, quarantineQuery+" WHERE q.id = ? AND u.tenant_id = ?", id, tenant.ID(ctx)))
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: quarantined change not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get quarantined change: %w", err)
+	}
+
+	return &change, nil
+}
+
+func (r *QuarantineRepo) ResolveQuarantined(ctx context.Context, id int64, status string) (*model.QuarantinedChange, error) {
+	query := `UPDATE quarantined_changes SET status = ?, reviewed_at = ?
+		WHERE id = ? AND status = 'pending' AND user_id IN (SELECT id FROM users WHERE tenant_id = ?)`
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, status, time.Now().UTC(), id, tenant.ID(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quarantined change: %w", err)
+	}
+	resolved, err := result.RowsAffected()
+	if err != nil {
+		return nil, fmt.Errorf("failed to resolve quarantined change: %w", err)
+	}
+
+	change, err := r.GetQuarantined(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if resolved == 0 {
+		return nil, fmt.Errorf("%w: change %d is already %s", model.ErrConflict, id, change.Status)
+	}
+
+	return change, nil
+}
@@ -0,0 +1,76 @@
+package sqlite
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/fraud"
+	"rating/internal/model"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestQuarantineRepo(t *testing.T) {
+	ctx := context.Background()
+
+	sqlDB := setupTestDB(t)
+
+	repo := NewUserRepo(sqlDB)
+	quarantine := NewQuarantineRepo(sqlDB)
+	detector := fraud.NewDetector(fraud.Config{JumpMultiple: 10, JumpMinDelta: 100, RatioThreshold: 1, RatioMinViewers: 1000000})
+	svc := service.NewUserService(repo, service.WithTxManager(NewTxManager(sqlDB, 3)), service.WithFraudCheck(detector, quarantine))
+
+	require.NoError(t, svc.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 10, Viewers: 1000000}))
+	likes, renamed := 10000, "renamed"
+	err := svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: &likes})
+	var held *model.QuarantinedError
+	require.ErrorAs(t, err, &held)
+	require.NoError(t, svc.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: &renamed}))
+
+	pending, err := svc.ListQuarantined(ctx, model.QuarantinePending, 0)
+	require.NoError(t, err)
+	require.Len(t, pending, 1)
+	require.Equal(t, "renamed", pending[0].Nickname)
+	require.Equal(t, likes, *pending[0].Change.Likes)
+	require.Equal(t, held.Change.Reasons, pending[0].Reasons)
+	require.Nil(t, pending[0].ReviewedAt)
+
+	approved, err := svc.ApproveQuarantined(ctx, held.Change.Id)
+	require.NoError(t, err)
+	require.Equal(t, model.QuarantineApproved, approved.Status)
+	require.NotNil(t, approved.ReviewedAt)
+
+	user, err := repo.GetUser(ctx, "renamed")
+	require.NoError(t, err)
+	require.Equal(t, likes, user.Likes)
+
+	_, err = svc.RejectQuarantined(ctx, held.Change.Id)
+	require.ErrorIs(t, err, model.ErrConflict)
+	_, err = svc.ApproveQuarantined(ctx, 42)
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	t.Run("failed approval stays pending", func(t *testing.T) {
+		likes := 200000
+		err := svc.ChangeData(ctx, "renamed", request.UpdateUserDTO{Likes: &likes})
+		require.ErrorAs(t, err, &held)
+
+		viewers := 150000
+		require.NoError(t, svc.ChangeData(ctx, "renamed", request.UpdateUserDTO{Viewers: &viewers}))
+
+		_, err = svc.ApproveQuarantined(ctx, held.Change.Id)
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+
+		still, err := quarantine.GetQuarantined(ctx, held.Change.Id)
+		require.NoError(t, err)
+		require.Equal(t, model.QuarantinePending, still.Status)
+	})
+
+	t.Run("deleted with the user", func(t *testing.T) {
+		require.NoError(t, svc.Delete(ctx, "renamed"))
+
+		changes, err := svc.ListQuarantined(ctx, "", 0)
+		require.NoError(t, err)
+		require.Empty(t, changes)
+	})
+}
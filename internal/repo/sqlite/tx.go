@@ -0,0 +1,87 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"rating/internal/service"
+	"time"
+
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+const retryDelay = 20 * time.Millisecond
+
+type querier interface {
+	ExecContext(ctx context.Context, query string, args ...any) (sql.Result, error)
+	QueryContext(ctx context.Context, query string, args ...any) (*sql.Rows, error)
+	QueryRowContext(ctx context.Context, query string, args ...any) *sql.Row
+}
+
+type txKey struct{}
+
+func (r *UserRepo) conn(ctx context.Context) querier {
+	if tx, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return tx
+	}
+
+	return r.db
+}
+
+// TxManager runs functions in a SQLite transaction. SQLite transactions are
+// always serializable, so only SQLITE_BUSY is retried.
+type TxManager struct {
+	db         *sql.DB
+	repo       *UserRepo
+	maxRetries int
+}
+
+func NewTxManager(db *sql.DB, maxRetries int) *TxManager {
+	return &TxManager{
+		db:         db,
+		repo:       NewUserRepo(db),
+		maxRetries: maxRetries,
+	}
+}
+
+func (m *TxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	if _, ok := ctx.Value(txKey{}).(*sql.Tx); ok {
+		return fn(ctx, m.repo)
+	}
+
+	var err error
+	for attempt := 0; attempt <= m.maxRetries; attempt++ {
+		if attempt > 0 {
+			select {
+			case <-ctx.Done():
+				return ctx.Err()
+			case <-time.After(retryDelay * time.Duration(attempt)):
+			}
+		}
+
+		err = m.run(ctx, fn)
+		if errorCode(err)&0xff != sqlite3.SQLITE_BUSY {
+			return err
+		}
+	}
+
+	return fmt.Errorf("transaction failed after %d retries: %w", m.maxRetries, err)
+}
+
+func (m *TxManager) run(ctx context.Context, fn func(ctx context.Context, store service.UserStore) error) error {
+	tx, err := m.db.BeginTx(ctx, nil)
+	if err != nil {
+		return fmt.Errorf("failed to begin transaction: %w", err)
+	}
+	defer tx.Rollback()
+
+	if err := fn(context.WithValue(ctx, txKey{}, tx), m.repo); err != nil {
+		return err
+	}
+
+	if err := tx.Commit(); err != nil {
+		return fmt.Errorf("failed to commit transaction: %w", err)
+	}
+
+	return nil
+}
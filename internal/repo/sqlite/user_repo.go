@@ -0,0 +1,224 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/tenant"
+	"strings"
+
+	"modernc.org/sqlite"
+	sqlite3 "modernc.org/sqlite/lib"
+)
+
+// leaderboardOrder ranks users; leaderboard snapshots use the same order.
+const leaderboardOrder = "rating DESC, id ASC"
+
+// categoryJoin keeps the members of the category whose tenant and slug are
+// bound to the first placeholders.
+const categoryJoin = " JOIN user_categories uc ON uc.user_id = users.id AND uc.category_id = (SELECT id FROM categories WHERE tenant_id = ? AND slug = ?)"
+
+type UserRepo struct {
+	db *sql.DB
+}
+
+func NewUserRepo(db *sql.DB) *UserRepo {
+	return &UserRepo{
+		db: db,
+	}
+}
+
+func errorCode(err error) int {
+	var sqliteErr *sqlite.Error
+	if errors.As(err, &sqliteErr) {
+		return sqliteErr.Code()
+	}
+
+	return 0
+}
+
+func (r *UserRepo) scanUser(rows *sql.Rows) ([]model.User, error) {
+	users := make([]model.User, 0)
+
+	for rows.Next() {
+		var user model.User
+		err := rows.Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating)
+		if err != nil {
+			return nil, fmt.Errorf("%w: failed to scan user data", err)
+		}
+		users = append(users, user)
+	}
+
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("rows iteration error: %w", err)
+	}
+
+	return users, nil
+}
+
+func (r *UserRepo) Create(ctx context.Context, user model.User) error {
+	query := "INSERT INTO users (tenant_id, name, nickname, nickname_key, likes, viewers) VALUES(?, ?, ?, ?, ?, ?)"
+
+	_, err := r.conn(ctx).ExecContext(ctx, query, tenant.ID(ctx), user.Name, user.NickName, model.NicknameKey(user.NickName), user.Likes, user.Viewers)
+	if err != nil {
+		switch errorCode(err) {
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, user.NickName)
+		case sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return fmt.Errorf("%w: likes can't be more than viewers or negative", model.ErrInvalidInput)
+		}
+		return fmt.Errorf("failed to create user: %w", err)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
+	query := "SELECT id, name, nickname, likes, viewers, rating FROM users"
+	countQuery := "SELECT COUNT(*) FROM users"
+	var args []any
+
+	if params.Category != "" {
+		query += categoryJoin
+		countQuery += categoryJoin
+		args = append(args, tenant.ID(ctx), params.Category)
+	}
+	query += " WHERE users.tenant_id = ?"
+	countQuery += " WHERE users.tenant_id = ?"
+	args = append(args, tenant.ID(ctx))
+
+	totalCount := model.UserCount{Total: -1}
+	if params.CountMode != request.CountNone {
+		// SQLite always counts exactly, there is no estimate or cache
+		if err := r.conn(ctx).QueryRowContext(ctx, countQuery, args...).Scan(&totalCount.Total); err != nil {
+			return nil, model.UserCount{Total: -1}, fmt.Errorf("failed to get total count users: %w", err)
+		}
+		totalCount.Exact = true
+	}
+
+	if params.Sort == "desc" {
+		query += " ORDER BY " + leaderboardOrder
+	} else if params.Sort == "asc" {
+		query += " ORDER BY rating ASC, id ASC"
+	} else {
+		query += " ORDER BY id ASC"
+	}
+
+	query += " LIMIT ? OFFSET ?"
+	args = append(args, params.Limit, params.Offset)
+
+	rows, err := r.conn(ctx).QueryContext(ctx, query, args...)
+	if err != nil {
+		return nil, model.UserCount{Total: -1}, fmt.Errorf("failed to get all users: %w", err)
+	}
+	defer rows.Close()
+
+	userList, err := r.scanUser(rows)
+
+	return userList, totalCount, err
+}
+
+func (r *UserRepo) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	query := "SELECT id, name, nickname, likes, viewers, rating FROM users WHERE tenant_id = ? AND nickname_key = ?"
+
+	var user model.User
+	err := r.conn(ctx).QueryRowContext(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname)).Scan(&user.Id, &user.Name, &user.NickName, &user.Likes, &user.Viewers, &user.Rating)
+	if err != nil {
+		if errors.Is(err, sql.ErrNoRows) {
+			return nil, fmt.Errorf("%w: user not found", model.ErrNotFound)
+		}
+		return nil, fmt.Errorf("failed to get user: %w", err)
+	}
+
+	return &user, nil
+}
+
+// GetUserForUpdate is GetUser: a SQLite write transaction locks the whole
+// database, a concurrent writer fails with SQLITE_BUSY and is retried.
+func (r *UserRepo) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	return r.GetUser(ctx, nickname)
+}
+
+func (r *UserRepo) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	var args []any
+	var sets []string
+
+	if dto.Name != nil {
+		sets = append(sets, "name = ?")
+		args = append(args, *dto.Name)
+	}
+
+	if dto.Likes != nil {
+		sets = append(sets, "likes = ?")
+		args = append(args, *dto.Likes)
+	}
+
+	if dto.Viewers != nil {
+		sets = append(sets, "viewers = ?")
+		args = append(args, *dto.Viewers)
+	}
+
+	if dto.Nickname != nil {
+		sets = append(sets, "nickname = ?", "nickname_key = ?")
+		args = append(args, *dto.Nickname, model.NicknameKey(*dto.Nickname))
+	}
+
+	args = append(args, tenant.ID(ctx), model.NicknameKey(nickname))
+
+	query := fmt.Sprintf("UPDATE users SET %s WHERE tenant_id = ? AND nickname_key = ?", strings.Join(sets, ", "))
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, args...)
+	if err != nil {
+		switch errorCode(err) {
+		case sqlite3.SQLITE_CONSTRAINT_CHECK:
+			return fmt.Errorf("%w: likes can't be more than viewers", model.ErrInvalidInput)
+		case sqlite3.SQLITE_CONSTRAINT_UNIQUE:
+			return fmt.Errorf("%w: nickname %s already exists", model.ErrAlreadyExists, *dto.Nickname)
+		}
+		return fmt.Errorf("failed to update data: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to update data: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+func (r *UserRepo) Delete(ctx context.Context, nickname string) error {
+	query := "DELETE FROM users WHERE tenant_id = ? AND nickname_key = ?"
+
+	result, err := r.conn(ctx).ExecContext(ctx, query, tenant.ID(ctx), model.NicknameKey(nickname))
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	affected, err := result.RowsAffected()
+	if err != nil {
+		return fmt.Errorf("failed to delete user: %w", err)
+	}
+
+	if affected == 0 {
+		return fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	return nil
+}
+
+// Count returns the number of users across all tenants.
+func (r *UserRepo) Count(ctx context.Context) (int, error) {
+	var totalCount int
+	if err := r.conn(ctx).QueryRowContext(ctx, "SELECT COUNT(*) FROM users").Scan(&totalCount); err != nil {
+		return -1, fmt.Errorf("failed to get total count users: %w", err)
+	}
+
+	return totalCount, nil
+}
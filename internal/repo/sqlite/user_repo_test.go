@@ -0,0 +1,34 @@
+package sqlite
+
+import (
+	"context"
+	"database/sql"
+	"path/filepath"
+	"rating/internal/db"
+	"rating/internal/migrate"
+	"rating/internal/repo/storetest"
+	"rating/internal/service"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func setupTestDB(t *testing.T) *sql.DB {
+	ctx := context.Background()
+
+	sqlDB, err := db.NewSQLite(ctx, filepath.Join(t.TempDir(), "rating.db"))
+	require.NoError(t, err)
+	t.Cleanup(func() { sqlDB.Close() })
+
+	runner, err := migrate.NewSQLiteRunner(sqlDB)
+	require.NoError(t, err)
+	require.NoError(t, runner.Up(ctx), "failed to execute migration")
+
+	return sqlDB
+}
+
+func TestUserRepo_Conformance(t *testing.T) {
+	storetest.Run(t, func(t *testing.T) service.UserStore {
+		return NewUserRepo(setupTestDB(t))
+	})
+}
@@ -0,0 +1,367 @@
+// Package storetest holds the conformance suite every service.UserStore
+// implementation must pass, so the backends behave identically.
+package storetest
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// NewStore must return an empty store for every call.
+type NewStore func(t *testing.T) service.UserStore
+
+func ptrString(s string) *string { return &s }
+func ptrInt(i int) *int          { return &i }
+
+func Run(t *testing.T, newStore NewStore) {
+	t.Run("Create", func(t *testing.T) { testCreate(t, newStore) })
+	t.Run("GetAll", func(t *testing.T) { testGetAll(t, newStore) })
+	t.Run("GetUser", func(t *testing.T) { testGetUser(t, newStore) })
+	t.Run("ChangeData", func(t *testing.T) { testChangeData(t, newStore) })
+	t.Run("Delete", func(t *testing.T) { testDelete(t, newStore) })
+	t.Run("Tenants", func(t *testing.T) { testTenants(t, newStore) })
+	t.Run("Concurrency", func(t *testing.T) { testConcurrency(t, newStore) })
+}
+
+func testContext(t *testing.T) context.Context {
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	t.Cleanup(cancel)
+	return ctx
+}
+
+func testCreate(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+
+	t.Run("success", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 50, 100)))
+
+		user, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Positive(t, user.Id)
+		require.Equal(t, "name", user.Name)
+		require.Equal(t, 50, user.Likes)
+		require.Equal(t, 100, user.Viewers)
+		require.Equal(t, 0.5, user.Rating)
+	})
+
+	t.Run("already exists", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 1, 1)))
+
+		err := store.Create(ctx, *model.NewUser("other", "nickname", 1, 1))
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+	})
+
+	t.Run("already exists in another case", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Create(ctx, *model.NewUser("name", "Nickname", 1, 1)))
+
+		err := store.Create(ctx, *model.NewUser("other", "NICKNAME", 1, 1))
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+	})
+
+	t.Run("likes > viewers", func(t *testing.T) {
+		store := newStore(t)
+		err := store.Create(ctx, *model.NewUser("name", "nickname", 2, 1))
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+	})
+
+	t.Run("negative counters", func(t *testing.T) {
+		store := newStore(t)
+		err := store.Create(ctx, *model.NewUser("name", "nickname", -1, 1))
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+	})
+
+	t.Run("zero viewers", func(t *testing.T) {
+		store := newStore(t)
+		require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 0, 0)))
+
+		user, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 0.0, user.Rating)
+	})
+}
+
+func testGetAll(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+	store := newStore(t)
+
+	seed := []model.User{
+		*model.NewUser("name1", "nickname1", 1, 3),
+		*model.NewUser("name2", "nickname2", 9, 10),
+		*model.NewUser("name3", "nickname3", 1, 2),
+		*model.NewUser("name4", "nickname4", 5, 10),
+		*model.NewUser("name5", "nickname5", 0, 0),
+	}
+	for _, u := range seed {
+		require.NoError(t, store.Create(ctx, u))
+	}
+
+	nicknames := func(users []model.User) []string {
+		result := make([]string, 0, len(users))
+		for _, u := range users {
+			result = append(result, u.NickName)
+		}
+		return result
+	}
+
+	tests := []struct {
+		name     string
+		params   request.PaginationQuery
+		expected []string
+	}{
+		{
+			name:     "default order by id",
+			params:   request.NewPaginationQuery(10, 0, ""),
+			expected: []string{"nickname1", "nickname2", "nickname3", "nickname4", "nickname5"},
+		},
+		{
+			name:     "rating desc with id tie-break",
+			params:   request.NewPaginationQuery(10, 0, "desc"),
+			expected: []string{"nickname2", "nickname3", "nickname4", "nickname1", "nickname5"},
+		},
+		{
+			name:     "rating asc with id tie-break",
+			params:   request.NewPaginationQuery(10, 0, "asc"),
+			expected: []string{"nickname5", "nickname1", "nickname3", "nickname4", "nickname2"},
+		},
+		{
+			name:     "paging",
+			params:   request.NewPaginationQuery(2, 2, "desc"),
+			expected: []string{"nickname4", "nickname1"},
+		},
+		{
+			name:     "offset past the end",
+			params:   request.NewPaginationQuery(2, 10, ""),
+			expected: []string{},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			users, total, err := store.GetAll(ctx, tt.params)
+			require.NoError(t, err)
+			require.Equal(t, model.UserCount{Total: len(seed), Exact: true}, total)
+			require.Equal(t, tt.expected, nicknames(users))
+		})
+	}
+
+	t.Run("count mode none", func(t *testing.T) {
+		params := request.NewPaginationQuery(2, 0, "")
+		params.CountMode = request.CountNone
+
+		users, total, err := store.GetAll(ctx, params)
+		require.NoError(t, err)
+		require.Equal(t, model.UserCount{Total: -1}, total)
+		require.Equal(t, []string{"nickname1", "nickname2"}, nicknames(users))
+	})
+
+	t.Run("rating values", func(t *testing.T) {
+		users, _, err := store.GetAll(ctx, request.NewPaginationQuery(10, 0, ""))
+		require.NoError(t, err)
+		require.Equal(t, []float64{0.333, 0.9, 0.5, 0.5, 0}, []float64{users[0].Rating, users[1].Rating, users[2].Rating, users[3].Rating, users[4].Rating})
+	})
+}
+
+func testGetUser(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+	store := newStore(t)
+
+	_, err := store.GetUser(ctx, "missing")
+	require.ErrorIs(t, err, model.ErrNotFound)
+	_, err = store.GetUserForUpdate(ctx, "missing")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 1, 2)))
+	user, err := store.GetUserForUpdate(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "name", user.Name)
+
+	// lookups fold the nickname like the uniqueness check does
+	for _, nickname := range []string{"NickName", "ＮＩＣＫＮＡＭＥ"} {
+		user, err = store.GetUser(ctx, nickname)
+		require.NoError(t, err)
+		require.Equal(t, "nickname", user.NickName)
+		_, err = store.GetUserForUpdate(ctx, nickname)
+		require.NoError(t, err)
+	}
+}
+
+func testChangeData(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+
+	setup := func(t *testing.T) service.UserStore {
+		store := newStore(t)
+		require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 10, 100)))
+		require.NoError(t, store.Create(ctx, *model.NewUser("other", "taken", 1, 1)))
+		return store
+	}
+
+	t.Run("partial update", func(t *testing.T) {
+		store := setup(t)
+		before, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+
+		require.NoError(t, store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(50)}))
+
+		user, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, before.Id, user.Id)
+		require.Equal(t, "name", user.Name)
+		require.Equal(t, 50, user.Likes)
+		require.Equal(t, 100, user.Viewers)
+		require.Equal(t, 0.5, user.Rating)
+	})
+
+	t.Run("rename", func(t *testing.T) {
+		store := setup(t)
+		require.NoError(t, store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed"), Name: ptrString("new")}))
+
+		_, err := store.GetUser(ctx, "nickname")
+		require.ErrorIs(t, err, model.ErrNotFound)
+
+		user, err := store.GetUser(ctx, "renamed")
+		require.NoError(t, err)
+		require.Equal(t, "new", user.Name)
+	})
+
+	t.Run("rename to taken nickname", func(t *testing.T) {
+		store := setup(t)
+		err := store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("taken")})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+		err = store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("Taken")})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+	})
+
+	t.Run("rename to another case", func(t *testing.T) {
+		store := setup(t)
+		require.NoError(t, store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("NickName")}))
+
+		user, err := store.GetUser(ctx, "NickName")
+		require.NoError(t, err)
+		require.Equal(t, "NickName", user.NickName)
+	})
+
+	t.Run("likes > viewers", func(t *testing.T) {
+		store := setup(t)
+		err := store.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(101)})
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+
+		user, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 10, user.Likes)
+	})
+
+	t.Run("by another case", func(t *testing.T) {
+		store := setup(t)
+		require.NoError(t, store.ChangeData(ctx, "NICKNAME", request.UpdateUserDTO{Likes: ptrInt(50)}))
+
+		user, err := store.GetUser(ctx, "nickname")
+		require.NoError(t, err)
+		require.Equal(t, 50, user.Likes)
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		store := setup(t)
+		err := store.ChangeData(ctx, "missing", request.UpdateUserDTO{Likes: ptrInt(1)})
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+}
+
+func testDelete(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+	store := newStore(t)
+	require.NoError(t, store.Create(ctx, *model.NewUser("name", "nickname", 1, 1)))
+
+	require.NoError(t, store.Delete(ctx, "NickName"))
+
+	_, err := store.GetUser(ctx, "nickname")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	err = store.Delete(ctx, "nickname")
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func testTenants(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+	acme := tenant.WithID(ctx, "acme")
+	store := newStore(t)
+
+	require.NoError(t, store.Create(ctx, *model.NewUser("default", "nickname", 1, 2)))
+	require.NoError(t, store.Create(acme, *model.NewUser("acme", "nickname", 2, 2)))
+	require.NoError(t, store.Create(acme, *model.NewUser("acme", "other", 1, 1)))
+
+	user, err := store.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "default", user.Name)
+
+	user, err = store.GetUser(acme, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "acme", user.Name)
+
+	_, err = store.GetUser(ctx, "other")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	users, total, err := store.GetAll(acme, request.PaginationQuery{Limit: 10, Sort: "desc"})
+	require.NoError(t, err)
+	require.Equal(t, 2, total.Total)
+	require.Len(t, users, 2)
+
+	err = store.ChangeData(acme, "other", request.UpdateUserDTO{Nickname: ptrString("nickname")})
+	require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+	require.NoError(t, store.Delete(acme, "nickname"))
+	user, err = store.GetUser(ctx, "nickname")
+	require.NoError(t, err)
+	require.Equal(t, "default", user.Name)
+
+	err = store.Delete(ctx, "other")
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
+
+func testConcurrency(t *testing.T, newStore NewStore) {
+	ctx := testContext(t)
+	store := newStore(t)
+
+	const workers = 8
+	var wg sync.WaitGroup
+	var created atomic.Int32
+
+	for i := range workers {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			if err := store.Create(ctx, *model.NewUser("name", fmt.Sprintf("user%d", i), 1, 2)); err != nil {
+				t.Errorf("create user%d: %v", i, err)
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			if err := store.Create(ctx, *model.NewUser("name", "contended", 1, 2)); err == nil {
+				created.Add(1)
+			} else if !errors.Is(err, model.ErrAlreadyExists) {
+				t.Errorf("create contended: %v", err)
+			}
+		}()
+	}
+	wg.Wait()
+
+	require.Equal(t, int32(1), created.Load())
+
+	_, total, err := store.GetAll(ctx, request.NewPaginationQuery(100, 0, ""))
+	require.NoError(t, err)
+	require.Equal(t, workers+1, total.Total)
+}
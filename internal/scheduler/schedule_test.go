@@ -0,0 +1,43 @@
+package scheduler
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParse(t *testing.T) {
+	base := time.Date(2026, 10, 19, 14, 37, 12, 0, time.UTC) // a Monday
+
+	tests := []struct {
+		spec string
+		next time.Time
+	}{
+		{"* * * * *", time.Date(2026, 10, 19, 14, 38, 0, 0, time.UTC)},
+		{"*/15 * * * *", time.Date(2026, 10, 19, 14, 45, 0, 0, time.UTC)},
+		{"0 * * * *", time.Date(2026, 10, 19, 15, 0, 0, 0, time.UTC)},
+		{"30 2 * * *", time.Date(2026, 10, 20, 2, 30, 0, 0, time.UTC)},
+		{"0 9-17/4 * * *", time.Date(2026, 10, 19, 17, 0, 0, 0, time.UTC)},
+		{"0 0 * * 0", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 * * 7", time.Date(2026, 10, 25, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1,15 * *", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"0 0 1 * 3", time.Date(2026, 10, 21, 0, 0, 0, 0, time.UTC)},
+		{"0 0 29 2 *", time.Date(2028, 2, 29, 0, 0, 0, 0, time.UTC)},
+		{"@daily", time.Date(2026, 10, 20, 0, 0, 0, 0, time.UTC)},
+		{"@monthly", time.Date(2026, 11, 1, 0, 0, 0, 0, time.UTC)},
+		{"@every 10m", time.Date(2026, 10, 19, 14, 40, 0, 0, time.UTC)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.spec, func(t *testing.T) {
+			schedule, err := Parse(tt.spec)
+			require.NoError(t, err)
+			require.Equal(t, tt.next, schedule.Next(base))
+		})
+	}
+
+	for _, spec := range []string{"", "* * * *", "60 * * * *", "* * 0 * *", "5-1 * * * *", "*/0 * * * *", "0 0 31 2 *", "@every 1ms", "@yearly"} {
+		_, err := Parse(spec)
+		require.Error(t, err, spec)
+	}
+}
@@ -0,0 +1,194 @@
+// Package scheduler runs maintenance jobs on cron-like schedules inside the
+// API process. With a Locker and a RunStore shared by all replicas each job
+// runs once per schedule slot, on whichever replica claims it first.
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"rating/internal/model"
+	"sync"
+	"time"
+)
+
+// recordTimeout bounds writing the outcome of a run cancelled by Stop.
+const recordTimeout = 5 * time.Second
+
+type Job struct {
+	Name     string
+	Schedule Schedule
+	Run      func(ctx context.Context) error
+}
+
+// Locker elects the replica that runs a job. acquired is false when another
+// replica holds the lock; unlock must be called once the job is done.
+type Locker interface {
+	TryLock(ctx context.Context, name string) (unlock func(), acquired bool, err error)
+}
+
+// RunStore records job runs. StartRun fails with model.ErrConflict when the
+// job already has a run for run.ScheduledAt.
+type RunStore interface {
+	StartRun(ctx context.Context, run model.JobRun) (*model.JobRun, error)
+	FinishRun(ctx context.Context, run model.JobRun) error
+}
+
+type Scheduler struct {
+	jobs     []Job
+	locker   Locker
+	runs     RunStore
+	instance string
+	logger   *slog.Logger
+	now      func() time.Time
+	cancel   context.CancelFunc
+	wg       sync.WaitGroup
+}
+
+type Option func(*Scheduler)
+
+func WithLocker(locker Locker) Option {
+	return func(s *Scheduler) {
+		s.locker = locker
+	}
+}
+
+func WithRunStore(runs RunStore) Option {
+	return func(s *Scheduler) {
+		s.runs = runs
+	}
+}
+
+// WithInstance names this replica in the run history.
+func WithInstance(instance string) Option {
+	return func(s *Scheduler) {
+		s.instance = instance
+	}
+}
+
+func New(log *slog.Logger, opts ...Option) *Scheduler {
+	s := &Scheduler{
+		logger: log,
+		now:    time.Now,
+	}
+	for _, opt := range opts {
+		opt(s)
+	}
+
+	return s
+}
+
+// Add registers a job. Jobs must be added before Start.
+func (s *Scheduler) Add(job Job) {
+	s.jobs = append(s.jobs, job)
+}
+
+func (s *Scheduler) Start(ctx context.Context) {
+	ctx, s.cancel = context.WithCancel(ctx)
+
+	for _, job := range s.jobs {
+		s.wg.Go(func() {
+			s.loop(ctx, job)
+		})
+	}
+}
+
+// Stop cancels running jobs and waits for them to return.
+func (s *Scheduler) Stop() {
+	if s.cancel == nil {
+		return
+	}
+	s.cancel()
+	s.wg.Wait()
+}
+
+func (s *Scheduler) loop(ctx context.Context, job Job) {
+	for {
+		next := job.Schedule.Next(s.now())
+		if next.IsZero() {
+			s.logger.WarnContext(ctx, "scheduler", slog.String("job", job.Name), slog.String("stopped", "schedule is never due"))
+			return
+		}
+
+		timer := time.NewTimer(next.Sub(s.now()))
+		select {
+		case <-ctx.Done():
+			timer.Stop()
+			return
+		case <-timer.C:
+		}
+
+		if err := s.RunJob(ctx, job, next); err != nil && ctx.Err() == nil {
+			s.logger.WarnContext(ctx, "scheduler", slog.String("job", job.Name), slog.Any("failed", err))
+		}
+	}
+}
+
+// RunJob runs job for the slot scheduledAt unless another replica holds its
+// lock or already ran that slot.
+func (s *Scheduler) RunJob(ctx context.Context, job Job, scheduledAt time.Time) error {
+	if s.locker != nil {
+		unlock, acquired, err := s.locker.TryLock(ctx, job.Name)
+		if err != nil {
+			return fmt.Errorf("failed to lock job: %w", err)
+		}
+		if !acquired {
+			s.logger.DebugContext(ctx, "scheduler", slog.String("job", job.Name), slog.String("skipped", "locked by another instance"))
+			return nil
+		}
+		defer unlock()
+	}
+
+	run := model.JobRun{
+		Job:         job.Name,
+		ScheduledAt: scheduledAt.UTC(),
+		StartedAt:   s.now().UTC(),
+		Status:      model.JobRunning,
+		Instance:    s.instance,
+	}
+	if s.runs != nil {
+		started, err := s.runs.StartRun(ctx, run)
+		if err != nil {
+			if errors.Is(err, model.ErrConflict) {
+				s.logger.DebugContext(ctx, "scheduler", slog.String("job", job.Name), slog.String("skipped", "already ran"))
+				return nil
+			}
+			return fmt.Errorf("failed to record job run: %w", err)
+		}
+		run = *started
+	}
+
+	err := s.run(ctx, job)
+	finished := s.now().UTC()
+	run.FinishedAt = &finished
+	switch {
+	case err == nil:
+		run.Status = model.JobSucceeded
+	case ctx.Err() != nil:
+		run.Status, run.Error = model.JobCancelled, err.Error()
+	default:
+		run.Status, run.Error = model.JobFailed, err.Error()
+	}
+	s.logger.InfoContext(ctx, "scheduler", slog.String("job", job.Name), slog.String("status", run.Status), slog.Duration("took", finished.Sub(run.StartedAt)))
+
+	if s.runs != nil {
+		recordCtx, cancel := context.WithTimeout(context.WithoutCancel(ctx), recordTimeout)
+		defer cancel()
+		if err := s.runs.FinishRun(recordCtx, run); err != nil {
+			return fmt.Errorf("failed to record job run: %w", err)
+		}
+	}
+
+	return err
+}
+
+func (s *Scheduler) run(ctx context.Context, job Job) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = fmt.Errorf("panic: %v", r)
+		}
+	}()
+
+	return job.Run(ctx)
+}
@@ -0,0 +1,135 @@
+package scheduler
+
+import (
+	"context"
+	"errors"
+	"io"
+	"log/slog"
+	"rating/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type fakeRuns struct {
+	mu   sync.Mutex
+	runs []model.JobRun
+}
+
+func (f *fakeRuns) StartRun(ctx context.Context, run model.JobRun) (*model.JobRun, error) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	for _, r := range f.runs {
+		if r.Job == run.Job && r.ScheduledAt.Equal(run.ScheduledAt) {
+			return nil, model.ErrConflict
+		}
+	}
+	run.Id = int64(len(f.runs) + 1)
+	f.runs = append(f.runs, run)
+	return &run, nil
+}
+
+func (f *fakeRuns) FinishRun(ctx context.Context, run model.JobRun) error {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	f.runs[run.Id-1] = run
+	return nil
+}
+
+func (f *fakeRuns) last() model.JobRun {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+
+	return f.runs[len(f.runs)-1]
+}
+
+type fakeLocker struct {
+	held bool
+}
+
+func (l *fakeLocker) TryLock(ctx context.Context, name string) (func(), bool, error) {
+	if l.held {
+		return nil, false, nil
+	}
+	l.held = true
+	return func() { l.held = false }, true, nil
+}
+
+func TestScheduler_RunJob(t *testing.T) {
+	ctx := context.Background()
+	log := slog.New(slog.NewTextHandler(io.Discard, nil))
+	slot := time.Date(2026, 10, 19, 0, 0, 0, 0, time.UTC)
+
+	t.Run("once per slot", func(t *testing.T) {
+		runs := &fakeRuns{}
+		s := New(log, WithRunStore(runs), WithInstance("a"))
+		var calls int
+		job := Job{Name: "job", Run: func(ctx context.Context) error { calls++; return nil }}
+
+		require.NoError(t, s.RunJob(ctx, job, slot))
+		require.NoError(t, s.RunJob(ctx, job, slot))
+		require.Equal(t, 1, calls)
+		require.Equal(t, model.JobSucceeded, runs.last().Status)
+		require.Equal(t, "a", runs.last().Instance)
+		require.NotNil(t, runs.last().FinishedAt)
+
+		require.NoError(t, s.RunJob(ctx, job, slot.Add(time.Hour)))
+		require.Equal(t, 2, calls)
+	})
+
+	t.Run("skipped while locked", func(t *testing.T) {
+		locker := &fakeLocker{held: true}
+		s := New(log, WithLocker(locker))
+		var calls int
+		job := Job{Name: "job", Run: func(ctx context.Context) error { calls++; return nil }}
+
+		require.NoError(t, s.RunJob(ctx, job, slot))
+		require.Zero(t, calls)
+
+		locker.held = false
+		require.NoError(t, s.RunJob(ctx, job, slot))
+		require.Equal(t, 1, calls)
+		require.False(t, locker.held)
+	})
+
+	t.Run("failure and panic are recorded", func(t *testing.T) {
+		runs := &fakeRuns{}
+		s := New(log, WithRunStore(runs))
+
+		err := s.RunJob(ctx, Job{Name: "fails", Run: func(ctx context.Context) error { return errors.New("boom") }}, slot)
+		require.EqualError(t, err, "boom")
+		require.Equal(t, model.JobFailed, runs.last().Status)
+		require.Equal(t, "boom", runs.last().Error)
+
+		err = s.RunJob(ctx, Job{Name: "panics", Run: func(ctx context.Context) error { panic("boom") }}, slot)
+		require.Error(t, err)
+		require.Equal(t, model.JobFailed, runs.last().Status)
+	})
+}
+
+func TestScheduler_StopCancelsRunningJobs(t *testing.T) {
+	runs := &fakeRuns{}
+	s := New(slog.New(slog.NewTextHandler(io.Discard, nil)), WithRunStore(runs))
+	started := make(chan struct{})
+	schedule, err := Parse("@every 1s")
+	require.NoError(t, err)
+	s.Add(Job{Name: "blocks", Schedule: schedule, Run: func(ctx context.Context) error {
+		close(started)
+		<-ctx.Done()
+		return ctx.Err()
+	}})
+
+	s.Start(context.Background())
+	select {
+	case <-started:
+	case <-time.After(3 * time.Second):
+		t.Fatal("job did not start")
+	}
+	s.Stop()
+
+	require.Equal(t, model.JobCancelled, runs.last().Status)
+}
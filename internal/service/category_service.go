@@ -0,0 +1,178 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"regexp"
+	"strings"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	maxCategoryNameLen      = 100
+	defaultLeaderboardLimit = 10
+	maxLeaderboardLimit     = 100
+)
+
+var categorySlug = regexp.MustCompile(`^[a-z0-9][a-z0-9-]{0,62}$`)
+
+type CategoryStore interface {
+	// CreateCategory fails with ErrAlreadyExists when the slug is taken.
+	CreateCategory(ctx context.Context, category model.Category) (*model.Category, error)
+	ListCategories(ctx context.Context) ([]model.Category, error)
+	GetCategory(ctx context.Context, slug string) (*model.Category, error)
+	UpdateCategory(ctx context.Context, slug, name string) (*model.Category, error)
+	DeleteCategory(ctx context.Context, slug string) error
+	// AddMember is a no-op when the user already belongs to the category.
+	AddMember(ctx context.Context, slug string, userId int64) error
+	RemoveMember(ctx context.Context, slug string, userId int64) error
+	// UserCategories returns the categories of a user with its rank in each.
+	UserCategories(ctx context.Context, userId int64) ([]model.CategoryRank, error)
+}
+
+type CategoryService struct {
+	store CategoryStore
+	users UserStore
+}
+
+func NewCategoryService(store CategoryStore, users UserStore) *CategoryService {
+	return &CategoryService{
+		store: store,
+		users: users,
+	}
+}
+
+func validateCategoryName(name string) error {
+	if strings.TrimSpace(name) == "" {
+		return fmt.Errorf("%w: name cannot be empty", model.ErrInvalidInput)
+	}
+	if len(name) > maxCategoryNameLen {
+		return fmt.Errorf("%w: name cannot be longer than %d characters", model.ErrInvalidInput, maxCategoryNameLen)
+	}
+
+	return nil
+}
+
+func (s *CategoryService) CreateCategory(ctx context.Context, dto request.CategoryDTO) (_ *model.Category, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.CreateCategory")
+	defer func() { endSpan(span, err) }()
+
+	if !categorySlug.MatchString(dto.Slug) {
+		return nil, fmt.Errorf("%w: slug must be 1-63 lowercase letters, digits or dashes", model.ErrInvalidInput)
+	}
+	if err := validateCategoryName(dto.Name); err != nil {
+		return nil, err
+	}
+
+	return s.store.CreateCategory(ctx, model.Category{Slug: dto.Slug, Name: dto.Name})
+}
+
+func (s *CategoryService) ListCategories(ctx context.Context) (_ []model.Category, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.ListCategories")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.ListCategories(ctx)
+}
+
+func (s *CategoryService) GetCategory(ctx context.Context, slug string) (_ *model.Category, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.GetCategory")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.GetCategory(ctx, slug)
+}
+
+func (s *CategoryService) UpdateCategory(ctx context.Context, slug string, dto request.UpdateCategoryDTO) (_ *model.Category, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.UpdateCategory")
+	defer func() { endSpan(span, err) }()
+
+	if dto.Name == nil {
+		return nil, fmt.Errorf("%w: name is required", model.ErrInvalidInput)
+	}
+	if err := validateCategoryName(*dto.Name); err != nil {
+		return nil, err
+	}
+
+	return s.store.UpdateCategory(ctx, slug, *dto.Name)
+}
+
+func (s *CategoryService) DeleteCategory(ctx context.Context, slug string) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.DeleteCategory")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.DeleteCategory(ctx, slug)
+}
+
+func (s *CategoryService) AddMember(ctx context.Context, slug, nickname string) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.AddMember")
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.users.GetUser(ctx, nickname)
+	if err != nil {
+		return err
+	}
+
+	return s.store.AddMember(ctx, slug, user.Id)
+}
+
+func (s *CategoryService) RemoveMember(ctx context.Context, slug, nickname string) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.RemoveMember")
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.users.GetUser(ctx, nickname)
+	if err != nil {
+		return err
+	}
+
+	return s.store.RemoveMember(ctx, slug, user.Id)
+}
+
+func (s *CategoryService) UserCategories(ctx context.Context, nickname string) (_ []model.CategoryRank, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.UserCategories")
+	defer func() { endSpan(span, err) }()
+
+	user, err := s.users.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.store.UserCategories(ctx, user.Id)
+}
+
+// Leaderboard ranks the members of a category by rating, the same way
+// GetAll sorts users in descending order.
+func (s *CategoryService) Leaderboard(ctx context.Context, slug string, limit, offset int) (_ []model.RankedUser, _ int, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "CategoryService.Leaderboard")
+	defer func() { endSpan(span, err) }()
+
+	if limit == 0 {
+		limit = defaultLeaderboardLimit
+	}
+	if limit < 1 || limit > maxLeaderboardLimit {
+		return nil, -1, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxLeaderboardLimit)
+	}
+	if offset < 0 {
+		return nil, -1, fmt.Errorf("%w: offset cannot be negative", model.ErrInvalidInput)
+	}
+
+	if _, err := s.store.GetCategory(ctx, slug); err != nil {
+		return nil, -1, err
+	}
+
+	params := request.NewPaginationQuery(limit, offset, "desc")
+	params.CountMode = request.CountExact
+	params.Category = slug
+	users, count, err := s.users.GetAll(ctx, params)
+	if err != nil {
+		return nil, -1, err
+	}
+
+	ranked := make([]model.RankedUser, 0, len(users))
+	for i, user := range users {
+		ranked = append(ranked, model.RankedUser{Rank: offset + i + 1, User: user})
+	}
+
+	return ranked, count.Total, nil
+}
@@ -0,0 +1,94 @@
+package service
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockCategoryStore struct {
+	CategoryStore
+	Created *model.Category
+	Exists  bool
+}
+
+func (m *MockCategoryStore) CreateCategory(ctx context.Context, category model.Category) (*model.Category, error) {
+	m.Created = &category
+	return &category, nil
+}
+
+func (m *MockCategoryStore) GetCategory(ctx context.Context, slug string) (*model.Category, error) {
+	if !m.Exists {
+		return nil, model.ErrNotFound
+	}
+	return &model.Category{Slug: slug}, nil
+}
+
+type recordingUserStore struct {
+	MockUserStore
+	Params request.PaginationQuery
+}
+
+func (s *recordingUserStore) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
+	s.Params = params
+	return s.MockUserStore.GetAll(ctx, params)
+}
+
+func TestCategoryService_CreateCategory(t *testing.T) {
+	tests := []struct {
+		name    string
+		dto     request.CategoryDTO
+		wantErr error
+	}{
+		{name: "valid", dto: request.CategoryDTO{Slug: "music", Name: "Music"}},
+		{name: "dashes and digits", dto: request.CategoryDTO{Slug: "eu-west-1", Name: "EU West"}},
+		{name: "empty slug", dto: request.CategoryDTO{Name: "Music"}, wantErr: model.ErrInvalidInput},
+		{name: "uppercase slug", dto: request.CategoryDTO{Slug: "Music", Name: "Music"}, wantErr: model.ErrInvalidInput},
+		{name: "leading dash", dto: request.CategoryDTO{Slug: "-music", Name: "Music"}, wantErr: model.ErrInvalidInput},
+		{name: "blank name", dto: request.CategoryDTO{Slug: "music", Name: "  "}, wantErr: model.ErrInvalidInput},
+		{name: "long name", dto: request.CategoryDTO{Slug: "music", Name: strings.Repeat("a", 101)}, wantErr: model.ErrInvalidInput},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			store := &MockCategoryStore{}
+			svc := NewCategoryService(store, &MockUserStore{})
+
+			_, err := svc.CreateCategory(context.Background(), tt.dto)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, store.Created)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tt.dto.Slug, store.Created.Slug)
+		})
+	}
+}
+
+func TestCategoryService_Leaderboard(t *testing.T) {
+	ctx := context.Background()
+	users := &recordingUserStore{MockUserStore: MockUserStore{
+		GetAllResult: []model.User{{NickName: "first"}, {NickName: "second"}},
+		GetAllTotal:  model.UserCount{Total: 12, Exact: true},
+	}}
+	store := &MockCategoryStore{Exists: true}
+	svc := NewCategoryService(store, users)
+
+	ranked, total, err := svc.Leaderboard(ctx, "music", 10, 10)
+	require.NoError(t, err)
+	require.Equal(t, 12, total)
+	require.Equal(t, []model.RankedUser{{Rank: 11, User: model.User{NickName: "first"}}, {Rank: 12, User: model.User{NickName: "second"}}}, ranked)
+	require.Equal(t, "music", users.Params.Category)
+	require.Equal(t, "desc", users.Params.Sort)
+
+	_, _, err = svc.Leaderboard(ctx, "music", 1000, 0)
+	require.ErrorIs(t, err, model.ErrInvalidInput)
+
+	store.Exists = false
+	_, _, err = svc.Leaderboard(ctx, "missing", 0, 0)
+	require.ErrorIs(t, err, model.ErrNotFound)
+}
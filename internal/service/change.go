@@ -0,0 +1,45 @@
+package service
+
+import (
+	"context"
+	"rating/internal/tenant"
+)
+
+const (
+	ChangeCreated = "created"
+	ChangeUpdated = "updated"
+	ChangeDeleted = "deleted"
+)
+
+// Change describes a committed write to a user of Tenant. OldNickname is set
+// when an update renamed the user.
+type Change struct {
+	Op          string
+	Tenant      string
+	Nickname    string
+	OldNickname string
+}
+
+// Nicknames returns every nickname affected by the change, both sides of a rename.
+func (c Change) Nicknames() []string {
+	if c.OldNickname != "" && c.OldNickname != c.Nickname {
+		return []string{c.OldNickname, c.Nickname}
+	}
+
+	return []string{c.Nickname}
+}
+
+// WithChangeObserver registers fn to run after every successful write made
+// through the service. It runs on the request goroutine and must not block.
+func WithChangeObserver(fn func(Change)) Option {
+	return func(u *UserService) {
+		u.observers = append(u.observers, fn)
+	}
+}
+
+func (u *UserService) notify(ctx context.Context, change Change) {
+	change.Tenant = tenant.ID(ctx)
+	for _, fn := range u.observers {
+		fn(change)
+	}
+}
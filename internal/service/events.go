@@ -0,0 +1,32 @@
+package service
+
+import (
+	"context"
+	"rating/internal/event"
+)
+
+// EventOutbox stores domain events. Append must join the transaction carried
+// by ctx so events commit or roll back together with the write.
+type EventOutbox interface {
+	Append(ctx context.Context, events ...event.Event) error
+}
+
+// WithEventOutbox makes every write run in a transaction that also records
+// its domain events in outbox.
+func WithEventOutbox(outbox EventOutbox) Option {
+	return func(u *UserService) {
+		u.events = outbox
+	}
+}
+
+// write runs fn in a transaction and appends the events it returns to the
+// outbox before the transaction commits.
+func (u *UserService) write(ctx context.Context, fn func(ctx context.Context, store UserStore) ([]event.Event, error)) error {
+	return u.tx.WithinTx(ctx, func(ctx context.Context, store UserStore) error {
+		events, err := fn(ctx, store)
+		if err != nil || len(events) == 0 {
+			return err
+		}
+		return u.events.Append(ctx, events...)
+	})
+}
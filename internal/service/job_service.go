@@ -0,0 +1,43 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/model"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultJobRunLimit = 50
+	maxJobRunLimit     = 500
+)
+
+type JobRunStore interface {
+	// ListRuns returns the newest runs first.
+	ListRuns(ctx context.Context, job string, limit int) ([]model.JobRun, error)
+}
+
+type JobService struct {
+	store JobRunStore
+}
+
+func NewJobService(store JobRunStore) *JobService {
+	return &JobService{
+		store: store,
+	}
+}
+
+func (s *JobService) ListRuns(ctx context.Context, job string, limit int) (_ []model.JobRun, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "JobService.ListRuns")
+	defer func() { endSpan(span, err) }()
+
+	if limit == 0 {
+		limit = defaultJobRunLimit
+	}
+	if limit < 1 || limit > maxJobRunLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxJobRunLimit)
+	}
+
+	return s.store.ListRuns(ctx, job, limit)
+}
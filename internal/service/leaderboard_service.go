@@ -0,0 +1,133 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultSnapshotLimit = 50
+	maxSnapshotLimit     = 500
+	defaultDiffLimit     = 100
+	maxDiffLimit         = 1000
+)
+
+type LeaderboardStore interface {
+	TakeSnapshot(ctx context.Context) (*model.LeaderboardSnapshot, error)
+	// ListSnapshots returns the newest snapshots first.
+	ListSnapshots(ctx context.Context, limit int) ([]model.LeaderboardSnapshot, error)
+	DiffSnapshots(ctx context.Context, from, to int64, limit int) ([]model.RankChange, error)
+	UserRank(ctx context.Context, userId, snapshotId int64) (*model.SnapshotRank, error)
+	PruneSnapshots(ctx context.Context, keep int) (int64, error)
+}
+
+type LeaderboardService struct {
+	store LeaderboardStore
+	users UserStore
+}
+
+func NewLeaderboardService(store LeaderboardStore, users UserStore) *LeaderboardService {
+	return &LeaderboardService{
+		store: store,
+		users: users,
+	}
+}
+
+func (s *LeaderboardService) ListSnapshots(ctx context.Context, limit int) (_ []model.LeaderboardSnapshot, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "LeaderboardService.ListSnapshots")
+	defer func() { endSpan(span, err) }()
+
+	if limit == 0 {
+		limit = defaultSnapshotLimit
+	}
+	if limit < 1 || limit > maxSnapshotLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxSnapshotLimit)
+	}
+
+	return s.store.ListSnapshots(ctx, limit)
+}
+
+// Diff compares two snapshots. Zero ids default to the latest snapshot for
+// to and the one before it for from.
+func (s *LeaderboardService) Diff(ctx context.Context, from, to int64, limit int) (_ []model.RankChange, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "LeaderboardService.Diff")
+	defer func() { endSpan(span, err) }()
+
+	if from < 0 || to < 0 {
+		return nil, fmt.Errorf("%w: snapshot ids cannot be negative", model.ErrInvalidInput)
+	}
+	if limit == 0 {
+		limit = defaultDiffLimit
+	}
+	if limit < 1 || limit > maxDiffLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxDiffLimit)
+	}
+
+	if from == 0 || to == 0 {
+		latest, err := s.store.ListSnapshots(ctx, 2)
+		if err != nil {
+			return nil, err
+		}
+		if len(latest) < 2 {
+			return nil, fmt.Errorf("%w: at least two leaderboard snapshots are needed", model.ErrNotFound)
+		}
+		if to == 0 {
+			to = latest[0].Id
+		}
+		if from == 0 {
+			from = latest[1].Id
+		}
+	}
+
+	return s.store.DiffSnapshots(ctx, from, to, limit)
+}
+
+// UserRank compares the rank of a user in the latest snapshot with the
+// previous one. Previous and Delta stay empty when there is no earlier
+// snapshot or the user was not in it.
+func (s *LeaderboardService) UserRank(ctx context.Context, nickname string) (_ *model.RankDelta, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "LeaderboardService.UserRank")
+	defer func() { endSpan(span, err) }()
+
+	if nickname == "" {
+		return nil, fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	}
+
+	user, err := s.users.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	latest, err := s.store.ListSnapshots(ctx, 2)
+	if err != nil {
+		return nil, err
+	}
+	if len(latest) == 0 {
+		return nil, fmt.Errorf("%w: no leaderboard snapshots yet", model.ErrNotFound)
+	}
+
+	current, err := s.store.UserRank(ctx, user.Id, latest[0].Id)
+	if err != nil {
+		return nil, err
+	}
+	delta := &model.RankDelta{Nickname: user.NickName, Current: *current}
+	if len(latest) < 2 {
+		return delta, nil
+	}
+
+	previous, err := s.store.UserRank(ctx, user.Id, latest[1].Id)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return delta, nil
+		}
+		return nil, err
+	}
+	moved := previous.Rank - current.Rank
+	delta.Previous, delta.Delta = previous, &moved
+
+	return delta, nil
+}
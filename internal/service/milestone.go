@@ -0,0 +1,91 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/event"
+	"rating/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// MilestoneStore records reached milestones per user. Record must join the
+// transaction carried by ctx and return only milestones the user had not
+// reached before.
+type MilestoneStore interface {
+	RecordMilestones(ctx context.Context, userId int64, milestones []model.Milestone) ([]model.Milestone, error)
+	ListMilestones(ctx context.Context, userId int64) ([]model.Milestone, error)
+}
+
+// WithMilestones evaluates rules after every create and update and records
+// the milestones a user reaches in store.
+func WithMilestones(store MilestoneStore, rules []model.MilestoneRule) Option {
+	return func(u *UserService) {
+		u.milestones = store
+		u.milestoneRules = rules
+	}
+}
+
+// recordMilestones stores the milestones user has reached and returns the
+// ones reached for the first time.
+func (u *UserService) recordMilestones(ctx context.Context, user model.User) ([]model.Milestone, error) {
+	var reached []model.Milestone
+	now := time.Now().UTC()
+	for _, rule := range u.milestoneRules {
+		if !rule.Reached(user) {
+			continue
+		}
+		reached = append(reached, model.Milestone{
+			Name:      rule.Name(),
+			Metric:    rule.Metric,
+			Threshold: rule.Threshold,
+			Value:     rule.Value(user),
+			ReachedAt: now,
+		})
+	}
+	if len(reached) == 0 {
+		return nil, nil
+	}
+
+	recorded, err := u.milestones.RecordMilestones(ctx, user.Id, reached)
+	if err != nil {
+		return nil, fmt.Errorf("failed to record milestones: %w", err)
+	}
+
+	return recorded, nil
+}
+
+// milestoneEvents announces the milestones user reached for the first time.
+func milestoneEvents(user model.User, reached []model.Milestone) ([]event.Event, error) {
+	events := make([]event.Event, 0, len(reached))
+	for _, milestone := range reached {
+		e, err := event.New(event.TypeMilestoneReached, user.NickName, event.MilestoneReached{Nickname: user.NickName, Milestone: milestone})
+		if err != nil {
+			return nil, err
+		}
+		events = append(events, e)
+	}
+
+	return events, nil
+}
+
+func (u *UserService) ListMilestones(ctx context.Context, nickname string) (_ []model.Milestone, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ListMilestones")
+	defer func() { endSpan(span, err) }()
+
+	if nickname == "" {
+		return nil, fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	}
+
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+
+	if u.milestones == nil {
+		return []model.Milestone{}, nil
+	}
+
+	return u.milestones.ListMilestones(ctx, user.Id)
+}
@@ -0,0 +1,105 @@
+package service
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"rating/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel"
+)
+
+// NicknameStore keeps the nicknames users were renamed from, the latest user
+// per nickname. Nicknames are matched by model.NicknameKey, so case and width
+// variants of a released nickname share its alias. RecordRename must join the
+// transaction carried by ctx. Aliases outlive their user, so the nicknames of
+// a deleted user still cool down.
+type NicknameStore interface {
+	RecordRename(ctx context.Context, userId int64, from string) error
+	GetAlias(ctx context.Context, nickname string) (*model.NicknameAlias, error)
+	ListAliases(ctx context.Context, userId int64) ([]model.NicknameAlias, error)
+}
+
+// WithNicknameHistory records renames in store so released nicknames keep
+// resolving to their user, and keeps other users from taking a released
+// nickname until cooldown has passed.
+func WithNicknameHistory(store NicknameStore, cooldown time.Duration) Option {
+	return func(u *UserService) {
+		u.nicknames = store
+		u.nicknameCooldown = cooldown
+	}
+}
+
+// WithNicknamePolicy validates and normalizes the nicknames users are created
+// with or renamed to.
+func WithNicknamePolicy(policy model.NicknamePolicy) Option {
+	return func(u *UserService) {
+		u.nicknamePolicy = policy
+	}
+}
+
+// checkNicknameFree fails when another user than userId released nickname
+// less than the cooldown ago. A user may always take back its own nickname,
+// nobody takes back the nickname of a deleted user early.
+func (u *UserService) checkNicknameFree(ctx context.Context, nickname string, userId int64) error {
+	alias, err := u.nicknames.GetAlias(ctx, nickname)
+	if err != nil {
+		if errors.Is(err, model.ErrNotFound) {
+			return nil
+		}
+		return err
+	}
+	if alias.UserId != 0 && alias.UserId == userId {
+		return nil
+	}
+	if available := alias.ReleasedAt.Add(u.nicknameCooldown); time.Now().Before(available) {
+		return fmt.Errorf("%w: nickname %s was released recently and is available from %s", model.ErrAlreadyExists, nickname, available.UTC().Format(time.RFC3339))
+	}
+
+	return nil
+}
+
+// ResolveNickname returns the current nickname of the user that was last
+// renamed from nickname.
+func (u *UserService) ResolveNickname(ctx context.Context, nickname string) (_ string, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ResolveNickname")
+	defer func() { endSpan(span, err) }()
+
+	if nickname == "" {
+		return "", fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	}
+	if u.nicknames == nil {
+		return "", fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	alias, err := u.nicknames.GetAlias(ctx, nickname)
+	if err != nil {
+		return "", err
+	}
+	if alias.Current == "" {
+		return "", fmt.Errorf("%w: user not found", model.ErrNotFound)
+	}
+
+	return alias.Current, nil
+}
+
+// ListNicknames returns the nicknames a user was renamed from, newest first.
+func (u *UserService) ListNicknames(ctx context.Context, nickname string) (_ []model.NicknameAlias, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ListNicknames")
+	defer func() { endSpan(span, err) }()
+
+	if nickname == "" {
+		return nil, fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	}
+
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return nil, err
+	}
+	if u.nicknames == nil {
+		return []model.NicknameAlias{}, nil
+	}
+
+	return u.nicknames.ListAliases(ctx, user.Id)
+}
@@ -0,0 +1,129 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	defaultQuarantineLimit = 50
+	maxQuarantineLimit     = 500
+)
+
+// FraudChecker returns why applying dto to user looks suspicious, or nothing.
+type FraudChecker interface {
+	Check(user model.User, dto request.UpdateUserDTO) []string
+}
+
+// QuarantineStore keeps updates held for review. Get and Resolve must join
+// the transaction carried by ctx.
+type QuarantineStore interface {
+	Quarantine(ctx context.Context, change model.QuarantinedChange) (*model.QuarantinedChange, error)
+	ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error)
+	GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error)
+	// ResolveQuarantined moves a pending change to status and fails with
+	// ErrConflict when it was already reviewed.
+	ResolveQuarantined(ctx context.Context, id int64, status string) (*model.QuarantinedChange, error)
+}
+
+// WithFraudCheck holds updates that checker flags in store instead of
+// applying them.
+func WithFraudCheck(checker FraudChecker, store QuarantineStore) Option {
+	return func(u *UserService) {
+		u.fraud = checker
+		u.quarantine = store
+	}
+}
+
+func (u *UserService) checkFraud(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	user, err := u.repo.GetUser(ctx, nickname)
+	if err != nil {
+		return err
+	}
+
+	reasons := u.fraud.Check(*user, dto)
+	if len(reasons) == 0 {
+		return nil
+	}
+
+	held, err := u.quarantine.Quarantine(ctx, model.QuarantinedChange{
+		UserId:   user.Id,
+		Nickname: user.NickName,
+		Change:   dto,
+		Reasons:  reasons,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to quarantine change: %w", err)
+	}
+
+	return &model.QuarantinedError{Change: *held}
+}
+
+func (u *UserService) ListQuarantined(ctx context.Context, status string, limit int) (_ []model.QuarantinedChange, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ListQuarantined")
+	defer func() { endSpan(span, err) }()
+
+	switch status {
+	case "", model.QuarantinePending, model.QuarantineApproved, model.QuarantineRejected:
+	default:
+		return nil, fmt.Errorf("%w: unknown quarantine status %q", model.ErrInvalidInput, status)
+	}
+
+	if limit == 0 {
+		limit = defaultQuarantineLimit
+	}
+	if limit < 1 || limit > maxQuarantineLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxQuarantineLimit)
+	}
+
+	return u.quarantine.ListQuarantined(ctx, status, limit)
+}
+
+// ApproveQuarantined applies a held change and marks it approved in one
+// transaction, skipping the fraud check.
+func (u *UserService) ApproveQuarantined(ctx context.Context, id int64) (_ *model.QuarantinedChange, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ApproveQuarantined")
+	defer func() { endSpan(span, err) }()
+
+	var approved *model.QuarantinedChange
+	var change Change
+	err = u.tx.WithinTx(ctx, func(ctx context.Context, store UserStore) error {
+		held, err := u.quarantine.GetQuarantined(ctx, id)
+		if err != nil {
+			return err
+		}
+		if held.Status != model.QuarantinePending {
+			return fmt.Errorf("%w: change %d is already %s", model.ErrConflict, id, held.Status)
+		}
+
+		if change, err = u.applyChange(ctx, held.Nickname, held.Change); err != nil {
+			return err
+		}
+
+		// resolving last rolls the change back if another review won the race
+		approved, err = u.quarantine.ResolveQuarantined(ctx, id, model.QuarantineApproved)
+		return err
+	})
+	if err != nil {
+		return nil, fmt.Errorf("failed to approve change: %w", err)
+	}
+	u.notify(ctx, change)
+
+	return approved, nil
+}
+
+func (u *UserService) RejectQuarantined(ctx context.Context, id int64) (_ *model.QuarantinedChange, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.RejectQuarantined")
+	defer func() { endSpan(span, err) }()
+
+	rejected, err := u.quarantine.ResolveQuarantined(ctx, id, model.QuarantineRejected)
+	if err != nil {
+		return nil, fmt.Errorf("failed to reject change: %w", err)
+	}
+
+	return rejected, nil
+}
@@ -0,0 +1,16 @@
+package service
+
+import "context"
+
+type TxManager interface {
+	WithinTx(ctx context.Context, fn func(ctx context.Context, store UserStore) error) error
+}
+
+// passthroughTx runs fn directly against the store, for stores without transactions.
+type passthroughTx struct {
+	store UserStore
+}
+
+func (p passthroughTx) WithinTx(ctx context.Context, fn func(ctx context.Context, store UserStore) error) error {
+	return fn(ctx, p.store)
+}
@@ -4,34 +4,84 @@ import (
 	"context"
 	"fmt"
 	"rating/internal/dto/request"
+	"rating/internal/event"
 	"rating/internal/model"
+	"time"
+
+	"go.opentelemetry.io/otel"
 )
 
+const tracerName = "rating/internal/service"
+
 type UserStore interface {
 	Create(ctx context.Context, user model.User) error
-	GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error)
+	GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error)
 	GetUser(ctx context.Context, nickname string) (*model.User, error)
+	// GetUserForUpdate reads the user and keeps it from changing until the
+	// transaction carried by ctx ends.
+	GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error)
 	ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error
 	Delete(ctx context.Context, nickname string) error
 }
 
+const defaultMaxPageSize = 100
+
 type UserService struct {
-	repo UserStore
+	repo        UserStore
+	tx          TxManager
+	maxPageSize int
+	observers   []func(Change)
+	events      EventOutbox
+
+	milestones     MilestoneStore
+	milestoneRules []model.MilestoneRule
+
+	fraud      FraudChecker
+	quarantine QuarantineStore
+
+	nicknames        NicknameStore
+	nicknameCooldown time.Duration
+	nicknamePolicy   model.NicknamePolicy
+}
+
+type Option func(*UserService)
+
+func WithMaxPageSize(size int) Option {
+	return func(u *UserService) {
+		u.maxPageSize = size
+	}
+}
+
+func WithTxManager(tx TxManager) Option {
+	return func(u *UserService) {
+		u.tx = tx
+	}
 }
 
-func NewUserService(repo UserStore) *UserService {
-	return &UserService{
-		repo: repo,
+func NewUserService(repo UserStore, opts ...Option) *UserService {
+	u := &UserService{
+		repo:        repo,
+		tx:          passthroughTx{store: repo},
+		maxPageSize: defaultMaxPageSize,
+	}
+	for _, opt := range opts {
+		opt(u)
 	}
+
+	return u
 }
 
-func (u *UserService) CreateUser(ctx context.Context, dto request.UserRequestDTO) error {
+func (u *UserService) CreateUser(ctx context.Context, dto request.UserRequestDTO) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.CreateUser")
+	defer func() { endSpan(span, err) }()
+
 	if dto.Name == "" {
 		return fmt.Errorf("%w: name cannot be empty", model.ErrInvalidInput)
 	}
 
-	if dto.Nickname == "" {
-		return fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	nickname, err := u.nicknamePolicy.Normalize(dto.Nickname)
+	if err != nil {
+		return err
 	}
 
 	if dto.Likes < 0 || dto.Viewers < 0 {
@@ -42,27 +92,82 @@ func (u *UserService) CreateUser(ctx context.Context, dto request.UserRequestDTO
 		return fmt.Errorf("%w: likes cannot be more then viewers", model.ErrInvalidInput)
 	}
 
-	user := model.NewUser(dto.Name, dto.Nickname, dto.Likes, dto.Viewers)
+	user := model.NewUser(dto.Name, nickname, dto.Likes, dto.Viewers)
 
-	if err := u.repo.Create(ctx, *user); err != nil {
+	trackMilestones := u.milestones != nil && len(u.milestoneRules) > 0
+	err = u.write(ctx, func(ctx context.Context, store UserStore) ([]event.Event, error) {
+		if u.nicknames != nil {
+			if err := u.checkNicknameFree(ctx, user.NickName, 0); err != nil {
+				return nil, err
+			}
+		}
+		if err := store.Create(ctx, *user); err != nil {
+			return nil, err
+		}
+		if u.events == nil && !trackMilestones {
+			return nil, nil
+		}
+
+		created, err := store.GetUser(ctx, user.NickName)
+		if err != nil {
+			return nil, err
+		}
+		var reached []model.Milestone
+		if trackMilestones {
+			if reached, err = u.recordMilestones(ctx, *created); err != nil {
+				return nil, err
+			}
+		}
+		if u.events == nil {
+			return nil, nil
+		}
+
+		events, err := event.NewUserCreated(*created)
+		if err != nil {
+			return nil, err
+		}
+		milestones, err := milestoneEvents(*created, reached)
+		if err != nil {
+			return nil, err
+		}
+		return append(events, milestones...), nil
+	})
+	if err != nil {
 		return fmt.Errorf("failed to create user: %w", err)
 	}
+	u.notify(ctx, Change{Op: ChangeCreated, Nickname: user.NickName})
 
 	return nil
 }
 
-func (u *UserService) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error) {
+func (u *UserService) GetAll(ctx context.Context, params request.PaginationQuery) (_ []model.User, _ model.UserCount, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.GetAll")
+	defer func() { endSpan(span, err) }()
+
 	if params.Sort != "" && params.Sort != "desc" && params.Sort != "asc" {
-		return nil, -1, fmt.Errorf("%w: invalid sort parameter", model.ErrInvalidSort)
+		return nil, model.UserCount{Total: -1}, fmt.Errorf("%w: invalid sort parameter", model.ErrInvalidSort)
 	}
 
 	if params.Limit < 1 || params.Offset < 0 {
-		return nil, -1, fmt.Errorf("%w: page or size cannot be negative or 0", model.ErrInvalidInput)
+		return nil, model.UserCount{Total: -1}, fmt.Errorf("%w: page or size cannot be negative or 0", model.ErrInvalidInput)
+	}
+
+	switch params.CountMode {
+	case "", request.CountExact, request.CountEstimated, request.CountCached, request.CountNone:
+	default:
+		return nil, model.UserCount{Total: -1}, fmt.Errorf("%w: invalid count parameter", model.ErrInvalidInput)
+	}
+
+	if params.Limit > u.maxPageSize {
+		return nil, model.UserCount{Total: -1}, fmt.Errorf("%w: size cannot be more than %d", model.ErrInvalidInput, u.maxPageSize)
 	}
 	return u.repo.GetAll(ctx, params)
 }
 
-func (u *UserService) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+func (u *UserService) GetUser(ctx context.Context, nickname string) (_ *model.User, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.GetUser")
+	defer func() { endSpan(span, err) }()
+
 	if nickname == "" {
 		return nil, fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
 	}
@@ -70,7 +175,10 @@ func (u *UserService) GetUser(ctx context.Context, nickname string) (*model.User
 	return u.repo.GetUser(ctx, nickname)
 }
 
-func (u *UserService) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+func (u *UserService) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.ChangeData")
+	defer func() { endSpan(span, err) }()
+
 	if nickname == "" {
 		return fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
 	}
@@ -87,8 +195,12 @@ func (u *UserService) ChangeData(ctx context.Context, nickname string, dto reque
 		return fmt.Errorf("%w: name cannot be empty", model.ErrInvalidInput)
 	}
 
-	if dto.Nickname != nil && *dto.Nickname == "" {
-		return fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
+	if dto.Nickname != nil {
+		renamed, err := u.nicknamePolicy.Normalize(*dto.Nickname)
+		if err != nil {
+			return err
+		}
+		dto.Nickname = &renamed
 	}
 
 	if dto.Viewers != nil && *dto.Viewers < 0 {
@@ -99,17 +211,127 @@ func (u *UserService) ChangeData(ctx context.Context, nickname string, dto reque
 		return fmt.Errorf("%w: likes cannot be more than viewers", model.ErrInvalidInput)
 	}
 
-	if err := u.repo.ChangeData(ctx, nickname, dto); err != nil {
+	if u.fraud != nil {
+		if err := u.checkFraud(ctx, nickname, dto); err != nil {
+			return err
+		}
+	}
+
+	change, err := u.applyChange(ctx, nickname, dto)
+	if err != nil {
 		return fmt.Errorf("failed to change data: %w", err)
 	}
+	u.notify(ctx, change)
 
 	return nil
 }
 
-func (u *UserService) Delete(ctx context.Context, nickname string) error {
+// applyChange writes dto together with its milestones, events and the
+// released nickname of a rename.
+func (u *UserService) applyChange(ctx context.Context, nickname string, dto request.UpdateUserDTO) (Change, error) {
+	trackMilestones := u.milestones != nil && len(u.milestoneRules) > 0
+	trackRename := u.nicknames != nil && dto.Nickname != nil && *dto.Nickname != nickname
+	err := u.write(ctx, func(ctx context.Context, store UserStore) ([]event.Event, error) {
+		if u.events == nil && !trackMilestones && !trackRename {
+			return nil, store.ChangeData(ctx, nickname, dto)
+		}
+
+		var before *model.User
+		if u.events != nil || trackRename {
+			var err error
+			if before, err = store.GetUserForUpdate(ctx, nickname); err != nil {
+				return nil, err
+			}
+		}
+		if trackRename {
+			if err := u.checkNicknameFree(ctx, *dto.Nickname, before.Id); err != nil {
+				return nil, err
+			}
+		}
+		if err := store.ChangeData(ctx, nickname, dto); err != nil {
+			return nil, err
+		}
+		if trackRename {
+			if err := u.nicknames.RecordRename(ctx, before.Id, before.NickName); err != nil {
+				return nil, fmt.Errorf("failed to record nickname change: %w", err)
+			}
+		}
+		current := nickname
+		if dto.Nickname != nil {
+			current = *dto.Nickname
+		}
+		after, err := store.GetUser(ctx, current)
+		if err != nil {
+			return nil, err
+		}
+
+		var reached []model.Milestone
+		if trackMilestones {
+			if reached, err = u.recordMilestones(ctx, *after); err != nil {
+				return nil, err
+			}
+		}
+		if u.events == nil {
+			return nil, nil
+		}
+
+		events, err := event.NewUserUpdated(*before, *after)
+		if err != nil {
+			return nil, err
+		}
+		milestones, err := milestoneEvents(*after, reached)
+		if err != nil {
+			return nil, err
+		}
+		return append(events, milestones...), nil
+	})
+	if err != nil {
+		return Change{}, err
+	}
+
+	change := Change{Op: ChangeUpdated, Nickname: nickname}
+	if dto.Nickname != nil && *dto.Nickname != nickname {
+		change.Nickname, change.OldNickname = *dto.Nickname, nickname
+	}
+
+	return change, nil
+}
+
+func (u *UserService) Delete(ctx context.Context, nickname string) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "UserService.Delete")
+	defer func() { endSpan(span, err) }()
+
 	if nickname == "" {
 		return fmt.Errorf("%w: nickname cannot be empty", model.ErrInvalidInput)
 	}
 
-	return u.repo.Delete(ctx, nickname)
+	err = u.write(ctx, func(ctx context.Context, store UserStore) ([]event.Event, error) {
+		if u.events == nil && u.nicknames == nil {
+			return nil, store.Delete(ctx, nickname)
+		}
+
+		deleted, err := store.GetUserForUpdate(ctx, nickname)
+		if err != nil {
+			return nil, err
+		}
+		// the nickname cools down like a renamed one, the alias loses its user with the delete
+		if u.nicknames != nil {
+			if err := u.nicknames.RecordRename(ctx, deleted.Id, deleted.NickName); err != nil {
+				return nil, fmt.Errorf("failed to release nickname: %w", err)
+			}
+		}
+		if err := store.Delete(ctx, nickname); err != nil {
+			return nil, err
+		}
+		if u.events == nil {
+			return nil, nil
+		}
+		return event.NewUserDeleted(*deleted)
+	})
+	if err != nil {
+		return err
+	}
+	u.notify(ctx, Change{Op: ChangeDeleted, Nickname: nickname})
+
+	return nil
 }
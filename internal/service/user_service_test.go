@@ -2,12 +2,21 @@ package service
 
 import (
 	"context"
+	"encoding/json"
 	"errors"
 	"rating/internal/dto/request"
+	"rating/internal/event"
 	"rating/internal/model"
+	"rating/internal/tenant"
+	"slices"
 	"testing"
+	"time"
 
 	"github.com/stretchr/testify/require"
+	"go.opentelemetry.io/otel"
+	"go.opentelemetry.io/otel/codes"
+	sdktrace "go.opentelemetry.io/otel/sdk/trace"
+	"go.opentelemetry.io/otel/sdk/trace/tracetest"
 )
 
 type MockUserStore struct {
@@ -15,7 +24,7 @@ type MockUserStore struct {
 	CreateErr error
 
 	GetAllErr    error
-	GetAllTotal  int
+	GetAllTotal  model.UserCount
 	GetAllResult []model.User
 
 	GetUserErr    error
@@ -29,7 +38,7 @@ func (m *MockUserStore) Create(ctx context.Context, user model.User) error {
 	return m.CreateErr
 }
 
-func (m *MockUserStore) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, int, error) {
+func (m *MockUserStore) GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error) {
 	return m.GetAllResult, m.GetAllTotal, m.GetAllErr
 }
 
@@ -37,6 +46,10 @@ func (m *MockUserStore) GetUser(ctx context.Context, nickname string) (*model.Us
 	return m.GetUserResult, m.GetUserErr
 }
 
+func (m *MockUserStore) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	return m.GetUserResult, m.GetUserErr
+}
+
 func (m *MockUserStore) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
 	return m.ChangeErr
 }
@@ -164,60 +177,80 @@ func TestUserService_GetAll(t *testing.T) {
 		params         request.PaginationQuery
 		mockErr        error
 		mockResult     []model.User
-		mockTotal      int
+		mockTotal      model.UserCount
 		expectedErr    error
 		expectedResult []model.User
-		expectedTotal  int
+		expectedTotal  model.UserCount
 	}{
 		{
 			name:           "success",
 			params:         request.NewPaginationQuery(5, 10, "asc"),
 			mockErr:        nil,
 			mockResult:     usersList,
-			mockTotal:      50,
+			mockTotal:      model.UserCount{Total: 50, Exact: true},
 			expectedErr:    nil,
 			expectedResult: usersList,
-			expectedTotal:  50,
+			expectedTotal:  model.UserCount{Total: 50, Exact: true},
 		},
 		{
 			name:           "unknown sort param",
 			params:         request.NewPaginationQuery(5, 10, "sdsds"),
 			mockErr:        nil,
 			mockResult:     nil,
-			mockTotal:      -1,
+			mockTotal:      model.UserCount{Total: -1},
 			expectedErr:    model.ErrInvalidSort,
 			expectedResult: nil,
-			expectedTotal:  -1,
+			expectedTotal:  model.UserCount{Total: -1},
 		},
 		{
 			name:           "negative limit",
 			params:         request.NewPaginationQuery(-5, 10, "asc"),
 			mockErr:        nil,
 			mockResult:     nil,
-			mockTotal:      -1,
+			mockTotal:      model.UserCount{Total: -1},
 			expectedErr:    model.ErrInvalidInput,
 			expectedResult: nil,
-			expectedTotal:  -1,
+			expectedTotal:  model.UserCount{Total: -1},
 		},
 		{
 			name:           "negative offset",
 			params:         request.NewPaginationQuery(5, -10, "asc"),
 			mockErr:        nil,
 			mockResult:     nil,
-			mockTotal:      -1,
+			mockTotal:      model.UserCount{Total: -1},
+			expectedErr:    model.ErrInvalidInput,
+			expectedResult: nil,
+			expectedTotal:  model.UserCount{Total: -1},
+		},
+		{
+			name:           "invalid count mode",
+			params:         request.PaginationQuery{Limit: 5, Offset: 0, CountMode: "approximate"},
+			mockErr:        nil,
+			mockResult:     nil,
+			mockTotal:      model.UserCount{Total: -1},
 			expectedErr:    model.ErrInvalidInput,
 			expectedResult: nil,
-			expectedTotal:  -1,
+			expectedTotal:  model.UserCount{Total: -1},
+		},
+		{
+			name:           "limit above max page size",
+			params:         request.NewPaginationQuery(defaultMaxPageSize+1, 0, "asc"),
+			mockErr:        nil,
+			mockResult:     nil,
+			mockTotal:      model.UserCount{Total: -1},
+			expectedErr:    model.ErrInvalidInput,
+			expectedResult: nil,
+			expectedTotal:  model.UserCount{Total: -1},
 		},
 		{
 			name:           "server error",
 			params:         request.NewPaginationQuery(5, 10, "asc"),
 			mockErr:        serverErr,
 			mockResult:     nil,
-			mockTotal:      -1,
+			mockTotal:      model.UserCount{Total: -1},
 			expectedErr:    serverErr,
 			expectedResult: nil,
-			expectedTotal:  -1,
+			expectedTotal:  model.UserCount{Total: -1},
 		},
 	}
 
@@ -290,6 +323,27 @@ func TestUserService_GetUser(t *testing.T) {
 	}
 }
 
+func TestUserService_SpanRecordsError(t *testing.T) {
+	recorder := tracetest.NewSpanRecorder()
+	otel.SetTracerProvider(sdktrace.NewTracerProvider(sdktrace.WithSpanProcessor(recorder)))
+
+	serverErr := errors.New("internal server error")
+	service := NewUserService(&MockUserStore{GetUserErr: serverErr})
+	_, err := service.GetUser(context.Background(), "nickname")
+	require.ErrorIs(t, err, serverErr)
+
+	service = NewUserService(&MockUserStore{GetUserResult: model.NewUser("name", "nickname", 50, 100)})
+	_, err = service.GetUser(context.Background(), "nickname")
+	require.NoError(t, err)
+
+	spans := recorder.Ended()
+	require.Len(t, spans, 2)
+	require.Equal(t, codes.Error, spans[0].Status().Code)
+	require.Equal(t, serverErr.Error(), spans[0].Status().Description)
+	require.Len(t, spans[0].Events(), 1)
+	require.Equal(t, codes.Unset, spans[1].Status().Code)
+}
+
 func ptrString(s string) *string { return &s }
 func ptrInt(i int) *int          { return &i }
 
@@ -441,6 +495,42 @@ func TestUserService_Change(t *testing.T) {
 	}
 }
 
+type MockTxManager struct {
+	Calls    int
+	Store    UserStore
+	BeginErr error
+}
+
+func (m *MockTxManager) WithinTx(ctx context.Context, fn func(ctx context.Context, store UserStore) error) error {
+	m.Calls++
+	if m.BeginErr != nil {
+		return m.BeginErr
+	}
+	return fn(ctx, m.Store)
+}
+
+func TestUserService_ChangeDataWithinTx(t *testing.T) {
+	beginErr := errors.New("failed to begin transaction")
+
+	t.Run("uses tx store", func(t *testing.T) {
+		txStore := &MockUserStore{ChangeErr: model.ErrNotFound}
+		tx := &MockTxManager{Store: txStore}
+
+		service := NewUserService(&MockUserStore{}, WithTxManager(tx))
+		err := service.ChangeData(context.Background(), "nickname", request.UpdateUserDTO{Likes: ptrInt(1)})
+		require.ErrorIs(t, err, model.ErrNotFound)
+		require.Equal(t, 1, tx.Calls)
+	})
+
+	t.Run("begin error", func(t *testing.T) {
+		tx := &MockTxManager{BeginErr: beginErr}
+
+		service := NewUserService(&MockUserStore{}, WithTxManager(tx))
+		err := service.ChangeData(context.Background(), "nickname", request.UpdateUserDTO{Likes: ptrInt(1)})
+		require.ErrorIs(t, err, beginErr)
+	})
+}
+
 func TestUserService_Delete(t *testing.T) {
 	serverErr := errors.New("server error")
 	tests := []struct {
@@ -486,3 +576,409 @@ func TestUserService_Delete(t *testing.T) {
 		})
 	}
 }
+
+func TestUserService_ChangeObserver(t *testing.T) {
+	ctx := context.Background()
+
+	var changes []Change
+	service := NewUserService(&MockUserStore{}, WithChangeObserver(func(c Change) { changes = append(changes, c) }))
+
+	require.NoError(t, service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 2}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(2)}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+	require.NoError(t, service.Delete(ctx, "renamed"))
+
+	require.Equal(t, []Change{
+		{Op: ChangeCreated, Tenant: tenant.Default, Nickname: "nickname"},
+		{Op: ChangeUpdated, Tenant: tenant.Default, Nickname: "nickname"},
+		{Op: ChangeUpdated, Tenant: tenant.Default, Nickname: "renamed", OldNickname: "nickname"},
+		{Op: ChangeDeleted, Tenant: tenant.Default, Nickname: "renamed"},
+	}, changes)
+
+	t.Run("failed writes are not observed", func(t *testing.T) {
+		changes = nil
+		service := NewUserService(&MockUserStore{DeleteErr: model.ErrNotFound}, WithChangeObserver(func(c Change) { changes = append(changes, c) }))
+
+		require.ErrorIs(t, service.Delete(ctx, "nickname"), model.ErrNotFound)
+		require.Empty(t, changes)
+	})
+}
+
+type MockEventOutbox struct {
+	Events    []event.Event
+	AppendErr error
+}
+
+func (m *MockEventOutbox) Append(ctx context.Context, events ...event.Event) error {
+	if m.AppendErr != nil {
+		return m.AppendErr
+	}
+	m.Events = append(m.Events, events...)
+	return nil
+}
+
+// mapStore keeps users in a map so events can be built from real before and after states.
+type mapStore struct {
+	UserStore
+	users map[string]model.User
+}
+
+func (s *mapStore) Create(ctx context.Context, user model.User) error {
+	if user.Viewers > 0 {
+		user.Rating = float64(user.Likes) / float64(user.Viewers)
+	}
+	s.users[user.NickName] = user
+	return nil
+}
+
+func (s *mapStore) GetUser(ctx context.Context, nickname string) (*model.User, error) {
+	user, ok := s.users[nickname]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	return &user, nil
+}
+
+func (s *mapStore) GetUserForUpdate(ctx context.Context, nickname string) (*model.User, error) {
+	return s.GetUser(ctx, nickname)
+}
+
+func (s *mapStore) ChangeData(ctx context.Context, nickname string, dto request.UpdateUserDTO) error {
+	user := s.users[nickname]
+	delete(s.users, nickname)
+	if dto.Name != nil {
+		user.Name = *dto.Name
+	}
+	if dto.Nickname != nil {
+		user.NickName = *dto.Nickname
+	}
+	if dto.Likes != nil {
+		user.Likes = *dto.Likes
+	}
+	if dto.Viewers != nil {
+		user.Viewers = *dto.Viewers
+	}
+	return s.Create(ctx, user)
+}
+
+func (s *mapStore) Delete(ctx context.Context, nickname string) error {
+	delete(s.users, nickname)
+	return nil
+}
+
+func TestUserService_Events(t *testing.T) {
+	ctx := context.Background()
+	outbox := &MockEventOutbox{}
+	tx := &MockTxManager{Store: &mapStore{users: make(map[string]model.User)}}
+	service := NewUserService(&MockUserStore{}, WithTxManager(tx), WithEventOutbox(outbox))
+
+	require.NoError(t, service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 2}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Name: ptrString("name")}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed"), Likes: ptrInt(2)}))
+	require.NoError(t, service.Delete(ctx, "renamed"))
+	require.Equal(t, 4, tx.Calls)
+
+	var types []string
+	for _, e := range outbox.Events {
+		types = append(types, e.Type)
+	}
+	require.Equal(t, []string{event.TypeUserCreated, event.TypeUserUpdated, event.TypeRatingChanged, event.TypeUserDeleted}, types)
+
+	var updated event.UserUpdated
+	require.NoError(t, json.Unmarshal(outbox.Events[1].Data, &updated))
+	require.Equal(t, "renamed", outbox.Events[1].Nickname)
+	require.Equal(t, map[string]event.FieldChange{
+		"nickname": {Old: "nickname", New: "renamed"},
+		"likes":    {Old: float64(1), New: float64(2)},
+	}, updated.Changes)
+
+	var ratingChanged event.RatingChanged
+	require.NoError(t, json.Unmarshal(outbox.Events[2].Data, &ratingChanged))
+	require.Equal(t, event.RatingChanged{Nickname: "renamed", OldRating: 0.5, NewRating: 1}, ratingChanged)
+
+	t.Run("append error fails the write", func(t *testing.T) {
+		appendErr := errors.New("outbox unavailable")
+		tx := &MockTxManager{Store: &mapStore{users: make(map[string]model.User)}}
+		service := NewUserService(&MockUserStore{}, WithTxManager(tx), WithEventOutbox(&MockEventOutbox{AppendErr: appendErr}))
+
+		err := service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "nickname", Likes: 1, Viewers: 2})
+		require.ErrorIs(t, err, appendErr)
+	})
+
+	t.Run("missing user records nothing", func(t *testing.T) {
+		outbox := &MockEventOutbox{}
+		tx := &MockTxManager{Store: &mapStore{users: make(map[string]model.User)}}
+		service := NewUserService(&MockUserStore{}, WithTxManager(tx), WithEventOutbox(outbox))
+
+		require.ErrorIs(t, service.Delete(ctx, "nickname"), model.ErrNotFound)
+		require.Empty(t, outbox.Events)
+	})
+}
+
+type MockMilestoneStore struct {
+	Reached map[int64][]model.Milestone
+}
+
+func (m *MockMilestoneStore) RecordMilestones(ctx context.Context, userId int64, milestones []model.Milestone) ([]model.Milestone, error) {
+	var recorded []model.Milestone
+	for _, milestone := range milestones {
+		if !slices.ContainsFunc(m.Reached[userId], func(r model.Milestone) bool { return r.Name == milestone.Name }) {
+			m.Reached[userId] = append(m.Reached[userId], milestone)
+			recorded = append(recorded, milestone)
+		}
+	}
+	return recorded, nil
+}
+
+func (m *MockMilestoneStore) ListMilestones(ctx context.Context, userId int64) ([]model.Milestone, error) {
+	return m.Reached[userId], nil
+}
+
+func TestUserService_Milestones(t *testing.T) {
+	ctx := context.Background()
+	rules := []model.MilestoneRule{{Metric: model.MetricViewers, Threshold: 1000}, {Metric: model.MetricRating, Threshold: 0.9}}
+	store := &mapStore{users: map[string]model.User{"nickname": {Id: 7, NickName: "nickname", Likes: 1, Viewers: 10, Rating: 0.1}}}
+	milestones := &MockMilestoneStore{Reached: make(map[int64][]model.Milestone)}
+	outbox := &MockEventOutbox{}
+	service := NewUserService(store, WithTxManager(&MockTxManager{Store: store}), WithMilestones(milestones, rules), WithEventOutbox(outbox))
+
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: ptrInt(1000)}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: ptrInt(1200)}))
+	require.NoError(t, service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Nickname: ptrString("renamed"), Likes: ptrInt(1100)}))
+
+	reached, err := service.ListMilestones(ctx, "renamed")
+	require.NoError(t, err)
+	require.Len(t, reached, 2)
+	require.Equal(t, "viewers>=1000", reached[0].Name)
+	require.Equal(t, float64(1000), reached[0].Value)
+	require.Equal(t, "rating>=0.9", reached[1].Name)
+
+	var fired []string
+	for _, e := range outbox.Events {
+		if e.Type == event.TypeMilestoneReached {
+			var payload event.MilestoneReached
+			require.NoError(t, json.Unmarshal(e.Data, &payload))
+			fired = append(fired, payload.Milestone.Name)
+		}
+	}
+	require.Equal(t, []string{"viewers>=1000", "rating>=0.9"}, fired)
+
+	t.Run("unknown user", func(t *testing.T) {
+		_, err := service.ListMilestones(ctx, "nickname")
+		require.ErrorIs(t, err, model.ErrNotFound)
+	})
+
+	t.Run("reached on create", func(t *testing.T) {
+		outbox.Events = nil
+		require.NoError(t, service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "created", Likes: 950, Viewers: 1000}))
+
+		reached, err := service.ListMilestones(ctx, "created")
+		require.NoError(t, err)
+		require.Len(t, reached, 2)
+
+		types := make([]string, 0, len(outbox.Events))
+		for _, e := range outbox.Events {
+			types = append(types, e.Type)
+		}
+		require.Equal(t, []string{event.TypeUserCreated, event.TypeMilestoneReached, event.TypeMilestoneReached}, types)
+	})
+}
+
+type flagAll struct{}
+
+func (flagAll) Check(user model.User, dto request.UpdateUserDTO) []string {
+	return []string{"suspicious"}
+}
+
+type MockQuarantineStore struct {
+	Changes []model.QuarantinedChange
+}
+
+func (m *MockQuarantineStore) Quarantine(ctx context.Context, change model.QuarantinedChange) (*model.QuarantinedChange, error) {
+	change.Id = int64(len(m.Changes) + 1)
+	change.Status = model.QuarantinePending
+	m.Changes = append(m.Changes, change)
+	return &change, nil
+}
+
+func (m *MockQuarantineStore) ListQuarantined(ctx context.Context, status string, limit int) ([]model.QuarantinedChange, error) {
+	return m.Changes, nil
+}
+
+func (m *MockQuarantineStore) GetQuarantined(ctx context.Context, id int64) (*model.QuarantinedChange, error) {
+	if id < 1 || int(id) > len(m.Changes) {
+		return nil, model.ErrNotFound
+	}
+	change := m.Changes[id-1]
+	return &change, nil
+}
+
+func (m *MockQuarantineStore) ResolveQuarantined(ctx context.Context, id int64, status string) (*model.QuarantinedChange, error) {
+	change, err := m.GetQuarantined(ctx, id)
+	if err != nil {
+		return nil, err
+	}
+	if change.Status != model.QuarantinePending {
+		return nil, model.ErrConflict
+	}
+	m.Changes[id-1].Status = status
+	return &m.Changes[id-1], nil
+}
+
+func TestUserService_FraudCheck(t *testing.T) {
+	ctx := context.Background()
+	store := &mapStore{users: map[string]model.User{"nickname": {Id: 7, NickName: "nickname", Likes: 1, Viewers: 10}}}
+	quarantine := &MockQuarantineStore{}
+	var changes []Change
+	service := NewUserService(store,
+		WithTxManager(&MockTxManager{Store: store}),
+		WithFraudCheck(flagAll{}, quarantine),
+		WithChangeObserver(func(c Change) { changes = append(changes, c) }),
+	)
+
+	err := service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Likes: ptrInt(10)})
+	var held *model.QuarantinedError
+	require.ErrorAs(t, err, &held)
+	require.ErrorIs(t, err, model.ErrQuarantined)
+	require.Equal(t, int64(7), held.Change.UserId)
+	require.Equal(t, []string{"suspicious"}, held.Change.Reasons)
+	require.Equal(t, 1, store.users["nickname"].Likes)
+	require.Empty(t, changes)
+
+	t.Run("approve applies the change", func(t *testing.T) {
+		approved, err := service.ApproveQuarantined(ctx, held.Change.Id)
+		require.NoError(t, err)
+		require.Equal(t, model.QuarantineApproved, approved.Status)
+		require.Equal(t, 10, store.users["nickname"].Likes)
+		require.Equal(t, []Change{{Op: ChangeUpdated, Tenant: tenant.Default, Nickname: "nickname"}}, changes)
+
+		_, err = service.ApproveQuarantined(ctx, held.Change.Id)
+		require.ErrorIs(t, err, model.ErrConflict)
+		_, err = service.RejectQuarantined(ctx, held.Change.Id)
+		require.ErrorIs(t, err, model.ErrConflict)
+	})
+
+	t.Run("reject leaves the user unchanged", func(t *testing.T) {
+		err := service.ChangeData(ctx, "nickname", request.UpdateUserDTO{Viewers: ptrInt(100)})
+		require.ErrorAs(t, err, &held)
+
+		rejected, err := service.RejectQuarantined(ctx, held.Change.Id)
+		require.NoError(t, err)
+		require.Equal(t, model.QuarantineRejected, rejected.Status)
+		require.Equal(t, 10, store.users["nickname"].Viewers)
+	})
+
+	t.Run("list validates input", func(t *testing.T) {
+		_, err := service.ListQuarantined(ctx, "unknown", 0)
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+		_, err = service.ListQuarantined(ctx, model.QuarantinePending, 1000)
+		require.ErrorIs(t, err, model.ErrInvalidInput)
+	})
+}
+
+type MockNicknameStore struct {
+	users   *mapStore
+	aliases map[string]model.NicknameAlias
+}
+
+func (m *MockNicknameStore) RecordRename(ctx context.Context, userId int64, from string) error {
+	m.aliases[from] = model.NicknameAlias{Nickname: from, UserId: userId, ReleasedAt: time.Now()}
+	return nil
+}
+
+func (m *MockNicknameStore) GetAlias(ctx context.Context, nickname string) (*model.NicknameAlias, error) {
+	alias, ok := m.aliases[nickname]
+	if !ok {
+		return nil, model.ErrNotFound
+	}
+	for _, user := range m.users.users {
+		if user.Id == alias.UserId {
+			alias.Current = user.NickName
+		}
+	}
+	return &alias, nil
+}
+
+func (m *MockNicknameStore) ListAliases(ctx context.Context, userId int64) ([]model.NicknameAlias, error) {
+	var aliases []model.NicknameAlias
+	for _, alias := range m.aliases {
+		if alias.UserId == userId {
+			aliases = append(aliases, alias)
+		}
+	}
+	return aliases, nil
+}
+
+func TestUserService_NicknameHistory(t *testing.T) {
+	ctx := context.Background()
+	store := &mapStore{users: map[string]model.User{
+		"first":  {Id: 1, NickName: "first", Likes: 1, Viewers: 2},
+		"second": {Id: 2, NickName: "second", Likes: 1, Viewers: 2},
+	}}
+	nicknames := &MockNicknameStore{users: store, aliases: make(map[string]model.NicknameAlias)}
+	service := NewUserService(store, WithTxManager(&MockTxManager{Store: store}), WithNicknameHistory(nicknames, time.Hour))
+
+	require.NoError(t, service.ChangeData(ctx, "first", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+
+	current, err := service.ResolveNickname(ctx, "first")
+	require.NoError(t, err)
+	require.Equal(t, "renamed", current)
+
+	history, err := service.ListNicknames(ctx, "renamed")
+	require.NoError(t, err)
+	require.Len(t, history, 1)
+	require.Equal(t, "first", history[0].Nickname)
+
+	_, err = service.ResolveNickname(ctx, "missing")
+	require.ErrorIs(t, err, model.ErrNotFound)
+
+	t.Run("released nicknames cool down", func(t *testing.T) {
+		err := service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: "first", Likes: 1, Viewers: 2})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+
+		err = service.ChangeData(ctx, "second", request.UpdateUserDTO{Nickname: ptrString("first")})
+		require.ErrorIs(t, err, model.ErrAlreadyExists)
+		require.Contains(t, store.users, "second")
+	})
+
+	t.Run("users take back their own nickname", func(t *testing.T) {
+		require.NoError(t, service.ChangeData(ctx, "renamed", request.UpdateUserDTO{Nickname: ptrString("first")}))
+		require.Equal(t, int64(1), store.users["first"].Id)
+	})
+
+	t.Run("after the cooldown", func(t *testing.T) {
+		alias := nicknames.aliases["renamed"]
+		alias.ReleasedAt = time.Now().Add(-2 * time.Hour)
+		nicknames.aliases["renamed"] = alias
+
+		require.NoError(t, service.ChangeData(ctx, "second", request.UpdateUserDTO{Nickname: ptrString("renamed")}))
+		require.Equal(t, int64(2), store.users["renamed"].Id)
+	})
+}
+
+func TestUserService_NicknamePolicy(t *testing.T) {
+	ctx := context.Background()
+	policy, err := model.NewNicknamePolicy(3, 8, `\p{L}\p{N}_`, []string{"Admin"})
+	require.NoError(t, err)
+	store := &mapStore{users: map[string]model.User{
+		"first": {Id: 1, NickName: "first", Likes: 1, Viewers: 2},
+	}}
+	service := NewUserService(store, WithNicknamePolicy(policy))
+
+	require.NoError(t, service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: " ｃａｆé_1 ", Likes: 1, Viewers: 2}))
+	require.Contains(t, store.users, "café_1")
+
+	require.NoError(t, service.ChangeData(ctx, "first", request.UpdateUserDTO{Nickname: ptrString("Ünïcode ")}))
+	require.Contains(t, store.users, "Ünïcode")
+
+	for _, nickname := range []string{"", "ab", "much_too_long", "a/b/c", "émoji😀", "ADMIN"} {
+		err := service.CreateUser(ctx, request.UserRequestDTO{Name: "name", Nickname: nickname, Likes: 1, Viewers: 2})
+		require.ErrorIs(t, err, model.ErrInvalidInput, nickname)
+
+		err = service.ChangeData(ctx, "café_1", request.UpdateUserDTO{Nickname: &nickname})
+		require.ErrorIs(t, err, model.ErrInvalidInput, nickname)
+	}
+
+	require.Equal(t, model.NicknameKey("ＡＬＩＣＥ"), model.NicknameKey("alice"))
+	require.Equal(t, model.NicknameKey("Straße"), model.NicknameKey("STRASSE"))
+}
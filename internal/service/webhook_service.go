@@ -0,0 +1,125 @@
+package service
+
+import (
+	"context"
+	"fmt"
+	"net/url"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/webhook"
+	"slices"
+
+	"go.opentelemetry.io/otel"
+)
+
+const (
+	minWebhookSecretLen  = 16
+	defaultDeliveryLimit = 50
+	maxDeliveryLimit     = 500
+)
+
+type WebhookStore interface {
+	CreateSubscription(ctx context.Context, sub model.WebhookSubscription) (*model.WebhookSubscription, error)
+	ListSubscriptions(ctx context.Context) ([]model.WebhookSubscription, error)
+	DeleteSubscription(ctx context.Context, id int64) error
+	ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) ([]model.WebhookDelivery, error)
+	ReplayDelivery(ctx context.Context, id int64) (*model.WebhookDelivery, error)
+}
+
+type WebhookService struct {
+	store               WebhookStore
+	allowPrivateTargets bool
+}
+
+// NewWebhookService creates the service. Unless allowPrivateTargets is set,
+// subscriptions cannot point at loopback, link-local or private addresses.
+func NewWebhookService(store WebhookStore, allowPrivateTargets bool) *WebhookService {
+	return &WebhookService{
+		store:               store,
+		allowPrivateTargets: allowPrivateTargets,
+	}
+}
+
+func (s *WebhookService) CreateSubscription(ctx context.Context, dto request.WebhookSubscriptionDTO) (_ *model.WebhookSubscription, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "WebhookService.CreateSubscription")
+	defer func() { endSpan(span, err) }()
+
+	u, err := url.Parse(dto.URL)
+	if err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+		return nil, fmt.Errorf("%w: url must be an absolute http or https url", model.ErrInvalidInput)
+	}
+	if !s.allowPrivateTargets {
+		if err := webhook.CheckTarget(u); err != nil {
+			return nil, fmt.Errorf("%w: url cannot point at a loopback, link-local or private address", model.ErrInvalidInput)
+		}
+	}
+
+	if len(dto.EventTypes) == 0 {
+		return nil, fmt.Errorf("%w: event_types cannot be empty", model.ErrInvalidInput)
+	}
+	for _, eventType := range dto.EventTypes {
+		switch eventType {
+		case model.WebhookUserCreated, model.WebhookUserDeleted, model.WebhookRatingCrossed:
+		default:
+			return nil, fmt.Errorf("%w: unknown event type %q", model.ErrInvalidInput, eventType)
+		}
+	}
+
+	if len(dto.Secret) < minWebhookSecretLen {
+		return nil, fmt.Errorf("%w: secret must be at least %d characters", model.ErrInvalidInput, minWebhookSecretLen)
+	}
+
+	if slices.Contains(dto.EventTypes, model.WebhookRatingCrossed) {
+		if dto.RatingThreshold == nil || *dto.RatingThreshold < 0 || *dto.RatingThreshold > 1 {
+			return nil, fmt.Errorf("%w: %s requires a rating_threshold between 0 and 1", model.ErrInvalidInput, model.WebhookRatingCrossed)
+		}
+	}
+
+	return s.store.CreateSubscription(ctx, model.WebhookSubscription{
+		URL:             dto.URL,
+		EventTypes:      slices.Compact(slices.Sorted(slices.Values(dto.EventTypes))),
+		Secret:          dto.Secret,
+		RatingThreshold: dto.RatingThreshold,
+	})
+}
+
+func (s *WebhookService) ListSubscriptions(ctx context.Context) (_ []model.WebhookSubscription, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "WebhookService.ListSubscriptions")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.ListSubscriptions(ctx)
+}
+
+func (s *WebhookService) DeleteSubscription(ctx context.Context, id int64) (err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "WebhookService.DeleteSubscription")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.DeleteSubscription(ctx, id)
+}
+
+func (s *WebhookService) ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) (_ []model.WebhookDelivery, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "WebhookService.ListDeliveries")
+	defer func() { endSpan(span, err) }()
+
+	switch status {
+	case "", model.DeliveryPending, model.DeliveryDelivered, model.DeliveryDead:
+	default:
+		return nil, fmt.Errorf("%w: status must be one of pending, delivered, dead", model.ErrInvalidInput)
+	}
+
+	if limit == 0 {
+		limit = defaultDeliveryLimit
+	}
+	if limit < 1 || limit > maxDeliveryLimit {
+		return nil, fmt.Errorf("%w: limit must be between 1 and %d", model.ErrInvalidInput, maxDeliveryLimit)
+	}
+
+	return s.store.ListDeliveries(ctx, subscriptionId, status, limit)
+}
+
+func (s *WebhookService) ReplayDelivery(ctx context.Context, id int64) (_ *model.WebhookDelivery, err error) {
+	ctx, span := otel.Tracer(tracerName).Start(ctx, "WebhookService.ReplayDelivery")
+	defer func() { endSpan(span, err) }()
+
+	return s.store.ReplayDelivery(ctx, id)
+}
@@ -0,0 +1,108 @@
+package service
+
+import (
+	"context"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+type MockWebhookStore struct {
+	WebhookStore
+	Created *model.WebhookSubscription
+	Limit   int
+}
+
+func (m *MockWebhookStore) CreateSubscription(ctx context.Context, sub model.WebhookSubscription) (*model.WebhookSubscription, error) {
+	m.Created = &sub
+	return &sub, nil
+}
+
+func (m *MockWebhookStore) ListDeliveries(ctx context.Context, subscriptionId int64, status string, limit int) ([]model.WebhookDelivery, error) {
+	m.Limit = limit
+	return nil, nil
+}
+
+func ptrFloat(f float64) *float64 { return &f }
+
+func TestWebhookService_CreateSubscription(t *testing.T) {
+	valid := request.WebhookSubscriptionDTO{
+		URL:        "https://partner.example.com/hooks",
+		EventTypes: []string{model.WebhookUserDeleted, model.WebhookUserCreated, model.WebhookUserDeleted},
+		Secret:     "0123456789abcdef",
+	}
+
+	tests := []struct {
+		name    string
+		modify  func(dto *request.WebhookSubscriptionDTO)
+		wantErr error
+	}{
+		{name: "valid", modify: func(dto *request.WebhookSubscriptionDTO) {}},
+		{name: "relative url", modify: func(dto *request.WebhookSubscriptionDTO) { dto.URL = "/hooks" }, wantErr: model.ErrInvalidInput},
+		{name: "unsupported scheme", modify: func(dto *request.WebhookSubscriptionDTO) { dto.URL = "ftp://example.com" }, wantErr: model.ErrInvalidInput},
+		{name: "loopback url", modify: func(dto *request.WebhookSubscriptionDTO) { dto.URL = "http://127.0.0.1:8080/hooks" }, wantErr: model.ErrInvalidInput},
+		{name: "metadata url", modify: func(dto *request.WebhookSubscriptionDTO) { dto.URL = "http://169.254.169.254/latest" }, wantErr: model.ErrInvalidInput},
+		{name: "no event types", modify: func(dto *request.WebhookSubscriptionDTO) { dto.EventTypes = nil }, wantErr: model.ErrInvalidInput},
+		{name: "unknown event type", modify: func(dto *request.WebhookSubscriptionDTO) { dto.EventTypes = []string{"user.renamed"} }, wantErr: model.ErrInvalidInput},
+		{name: "short secret", modify: func(dto *request.WebhookSubscriptionDTO) { dto.Secret = "short" }, wantErr: model.ErrInvalidInput},
+		{
+			name:    "rating crossed without threshold",
+			modify:  func(dto *request.WebhookSubscriptionDTO) { dto.EventTypes = []string{model.WebhookRatingCrossed} },
+			wantErr: model.ErrInvalidInput,
+		},
+		{
+			name: "rating crossed with threshold",
+			modify: func(dto *request.WebhookSubscriptionDTO) {
+				dto.EventTypes = []string{model.WebhookRatingCrossed}
+				dto.RatingThreshold = ptrFloat(0.8)
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dto := valid
+			tt.modify(&dto)
+
+			store := &MockWebhookStore{}
+			_, err := NewWebhookService(store, false).CreateSubscription(context.Background(), dto)
+			if tt.wantErr != nil {
+				require.ErrorIs(t, err, tt.wantErr)
+				require.Nil(t, store.Created)
+				return
+			}
+			require.NoError(t, err)
+		})
+	}
+
+	t.Run("private targets allowed", func(t *testing.T) {
+		dto := valid
+		dto.URL = "http://localhost:9000/hooks"
+		_, err := NewWebhookService(&MockWebhookStore{}, true).CreateSubscription(context.Background(), dto)
+		require.NoError(t, err)
+	})
+
+	t.Run("event types are deduplicated", func(t *testing.T) {
+		store := &MockWebhookStore{}
+		_, err := NewWebhookService(store, false).CreateSubscription(context.Background(), valid)
+		require.NoError(t, err)
+		require.Equal(t, []string{model.WebhookUserCreated, model.WebhookUserDeleted}, store.Created.EventTypes)
+	})
+}
+
+func TestWebhookService_ListDeliveries(t *testing.T) {
+	store := &MockWebhookStore{}
+	service := NewWebhookService(store, false)
+
+	_, err := service.ListDeliveries(context.Background(), 1, "", 0)
+	require.NoError(t, err)
+	require.Equal(t, defaultDeliveryLimit, store.Limit)
+
+	_, err = service.ListDeliveries(context.Background(), 1, "failed", 10)
+	require.ErrorIs(t, err, model.ErrInvalidInput)
+
+	_, err = service.ListDeliveries(context.Background(), 1, model.DeliveryDead, maxDeliveryLimit+1)
+	require.ErrorIs(t, err, model.ErrInvalidInput)
+}
@@ -0,0 +1,251 @@
+// Package stream fans user change events out to long-lived subscribers such
+// as Server-Sent Events connections.
+package stream
+
+import (
+	"encoding/json"
+	"fmt"
+	"maps"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+)
+
+const (
+	EventCreated     = service.ChangeCreated
+	EventUpdated     = service.ChangeUpdated
+	EventDeleted     = service.ChangeDeleted
+	EventLeaderboard = "leaderboard"
+	// EventReset tells a subscriber that events were lost and it has to
+	// refetch the state it derived from the stream.
+	EventReset = "reset"
+)
+
+type Event struct {
+	ID   string
+	Type string
+	Data json.RawMessage
+}
+
+// Broker assigns ids to published events, keeps the most recent ones for
+// resuming subscribers and delivers new ones to every subscription. Each
+// tenant has a topic of its own: subscribers only see the events, ids and
+// replay buffer of their tenant. A topic is dropped once its last subscriber
+// left and its newest event is older than replayTTL; clients resuming from it
+// then get a reset.
+type Broker struct {
+	mu           sync.Mutex
+	epoch        string
+	topics       map[string]*topic
+	replaySize   int
+	replayTTL    time.Duration
+	clientBuffer int
+	closed       bool
+	now          func() time.Time
+}
+
+type topic struct {
+	seq       uint64
+	replay    []Event
+	published time.Time
+	subs      map[*Subscription]struct{}
+}
+
+func NewBroker(replaySize, clientBuffer int, replayTTL time.Duration) *Broker {
+	return &Broker{
+		epoch:        strconv.FormatInt(time.Now().UnixNano(), 36),
+		topics:       make(map[string]*topic),
+		replaySize:   replaySize,
+		replayTTL:    replayTTL,
+		clientBuffer: clientBuffer,
+		now:          time.Now,
+	}
+}
+
+// Subscription receives events on C. C is closed when the subscriber fell
+// behind by more than the client buffer or the broker was closed.
+type Subscription struct {
+	C        <-chan Event
+	ch       chan Event
+	broker   *Broker
+	tenantId string
+	topic    *topic
+	lagged   bool
+}
+
+// Lagged reports whether the subscription was dropped for falling behind.
+// Only valid after C was closed.
+func (s *Subscription) Lagged() bool {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	return s.lagged
+}
+
+func (s *Subscription) Close() {
+	s.broker.mu.Lock()
+	defer s.broker.mu.Unlock()
+
+	s.topic.drop(s)
+	b := s.broker
+	if b.topics[s.tenantId] == s.topic && b.expired(s.topic) {
+		delete(b.topics, s.tenantId)
+	}
+}
+
+// Tenants returns the tenants with subscribers or replayable events.
+func (b *Broker) Tenants() []string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+	return slices.Sorted(maps.Keys(b.topics))
+}
+
+func (b *Broker) Publish(tenantId, eventType string, data any) error {
+	payload, err := json.Marshal(data)
+	if err != nil {
+		return fmt.Errorf("failed to marshal %s event: %w", eventType, err)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if b.closed {
+		return nil
+	}
+
+	t := b.topic(tenantId)
+	t.seq++
+	t.published = b.now()
+	event := Event{
+		ID:   b.epoch + "-" + strconv.FormatUint(t.seq, 10),
+		Type: eventType,
+		Data: payload,
+	}
+
+	if len(t.replay) == b.replaySize && b.replaySize > 0 {
+		copy(t.replay, t.replay[1:])
+		t.replay = t.replay[:len(t.replay)-1]
+	}
+	if b.replaySize > 0 {
+		t.replay = append(t.replay, event)
+	}
+
+	for sub := range t.subs {
+		select {
+		case sub.ch <- event:
+		default:
+			sub.lagged = true
+			t.drop(sub)
+		}
+	}
+
+	return nil
+}
+
+// Subscribe registers a subscription to the events of tenantId. With a
+// lastEventID it first receives every buffered event after that id, or a
+// reset event when the id is unknown or already fell out of the replay buffer.
+func (b *Broker) Subscribe(tenantId, lastEventID string) (*Subscription, error) {
+	if !tenant.Valid(tenantId) {
+		return nil, fmt.Errorf("%w: %q", tenant.ErrInvalid, tenantId)
+	}
+
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.expire()
+	t := b.topic(tenantId)
+	var backlog []Event
+	if lastEventID != "" {
+		var ok bool
+		backlog, ok = b.since(t, lastEventID)
+		if !ok {
+			backlog = []Event{{ID: b.epoch + "-" + strconv.FormatUint(t.seq, 10), Type: EventReset, Data: json.RawMessage("{}")}}
+		}
+	}
+
+	ch := make(chan Event, b.clientBuffer+len(backlog))
+	for _, event := range backlog {
+		ch <- event
+	}
+
+	sub := &Subscription{C: ch, ch: ch, broker: b, tenantId: tenantId, topic: t}
+	if b.closed {
+		close(ch)
+		return sub, nil
+	}
+	t.subs[sub] = struct{}{}
+
+	return sub, nil
+}
+
+// Close ends every subscription, so streaming handlers return on shutdown.
+func (b *Broker) Close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for _, t := range b.topics {
+		for sub := range t.subs {
+			t.drop(sub)
+		}
+	}
+}
+
+func (b *Broker) topic(tenantId string) *topic {
+	t, ok := b.topics[tenantId]
+	if !ok {
+		t = &topic{subs: make(map[*Subscription]struct{})}
+		b.topics[tenantId] = t
+	}
+
+	return t
+}
+
+// expire drops the topics that expired.
+func (b *Broker) expire() {
+	maps.DeleteFunc(b.topics, func(_ string, t *topic) bool {
+		return b.expired(t)
+	})
+}
+
+// expired reports whether t has no subscribers and nothing left to replay.
+func (b *Broker) expired(t *topic) bool {
+	if len(t.subs) > 0 {
+		return false
+	}
+
+	return len(t.replay) == 0 || b.now().Sub(t.published) >= b.replayTTL
+}
+
+func (t *topic) drop(sub *Subscription) {
+	if _, ok := t.subs[sub]; !ok {
+		return
+	}
+	delete(t.subs, sub)
+	close(sub.ch)
+}
+
+func (b *Broker) since(t *topic, id string) ([]Event, bool) {
+	epoch, seqStr, ok := strings.Cut(id, "-")
+	if !ok || epoch != b.epoch {
+		return nil, false
+	}
+	seq, err := strconv.ParseUint(seqStr, 10, 64)
+	if err != nil || seq > t.seq {
+		return nil, false
+	}
+
+	oldest := t.seq - uint64(len(t.replay))
+	if seq < oldest {
+		return nil, false
+	}
+
+	return append([]Event(nil), t.replay[len(t.replay)-int(t.seq-seq):]...), true
+}
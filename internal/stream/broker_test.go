@@ -0,0 +1,218 @@
+package stream
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"rating/internal/model"
+	"rating/internal/repo/memory"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func receive(t *testing.T, sub *Subscription) Event {
+	t.Helper()
+	select {
+	case event, ok := <-sub.C:
+		require.True(t, ok, "subscription closed")
+		return event
+	case <-time.After(time.Second):
+		t.Fatal("no event received")
+		return Event{}
+	}
+}
+
+func subscribe(t *testing.T, b *Broker, tenantId, lastEventID string) *Subscription {
+	t.Helper()
+	sub, err := b.Subscribe(tenantId, lastEventID)
+	require.NoError(t, err)
+	return sub
+}
+
+func TestBroker_Publish(t *testing.T) {
+	b := NewBroker(10, 10, time.Minute)
+	sub := subscribe(t, b, tenant.Default, "")
+	defer sub.Close()
+
+	require.NoError(t, b.Publish(tenant.Default, EventCreated, UserEvent{Nickname: "nickname"}))
+
+	event := receive(t, sub)
+	require.Equal(t, EventCreated, event.Type)
+	require.JSONEq(t, `{"nickname":"nickname"}`, string(event.Data))
+}
+
+func TestBroker_Resume(t *testing.T) {
+	b := NewBroker(3, 10, time.Minute)
+
+	var ids []string
+	sub := subscribe(t, b, tenant.Default, "")
+	for range 5 {
+		require.NoError(t, b.Publish(tenant.Default, EventDeleted, struct{}{}))
+		ids = append(ids, receive(t, sub).ID)
+	}
+	sub.Close()
+
+	t.Run("replays events after the last id", func(t *testing.T) {
+		sub := subscribe(t, b, tenant.Default, ids[2])
+		defer sub.Close()
+
+		require.Equal(t, ids[3], receive(t, sub).ID)
+		require.Equal(t, ids[4], receive(t, sub).ID)
+	})
+
+	t.Run("up to date", func(t *testing.T) {
+		sub := subscribe(t, b, tenant.Default, ids[4])
+		defer sub.Close()
+
+		require.NoError(t, b.Publish(tenant.Default, EventDeleted, struct{}{}))
+		require.Equal(t, EventDeleted, receive(t, sub).Type)
+	})
+
+	for name, id := range map[string]string{
+		"evicted from the replay buffer": ids[0],
+		"other instance":                 "other-1",
+		"malformed":                      "nope",
+	} {
+		t.Run(name, func(t *testing.T) {
+			sub := subscribe(t, b, tenant.Default, id)
+			defer sub.Close()
+
+			require.Equal(t, EventReset, receive(t, sub).Type)
+		})
+	}
+}
+
+func TestBroker_Tenants(t *testing.T) {
+	b := NewBroker(10, 10, time.Minute)
+	sub := subscribe(t, b, tenant.Default, "")
+	defer sub.Close()
+	acme := subscribe(t, b, "acme", "")
+	defer acme.Close()
+
+	require.NoError(t, b.Publish("acme", EventDeleted, UserEvent{Nickname: "partner"}))
+	require.NoError(t, b.Publish(tenant.Default, EventDeleted, UserEvent{Nickname: "nickname"}))
+
+	require.JSONEq(t, `{"nickname":"partner"}`, string(receive(t, acme).Data))
+	require.JSONEq(t, `{"nickname":"nickname"}`, string(receive(t, sub).Data))
+	require.Equal(t, []string{"acme", tenant.Default}, b.Tenants())
+
+	// replay stays within the tenant
+	resumed := subscribe(t, b, "acme", b.epoch+"-0")
+	defer resumed.Close()
+	require.JSONEq(t, `{"nickname":"partner"}`, string(receive(t, resumed).Data))
+	select {
+	case event := <-resumed.C:
+		t.Fatalf("unexpected event %s", event.Data)
+	default:
+	}
+}
+
+func TestBroker_DropsIdleTopics(t *testing.T) {
+	now := time.Now()
+	b := NewBroker(10, 10, time.Minute)
+	b.now = func() time.Time { return now }
+
+	_, err := b.Subscribe("Not A Tenant", "")
+	require.ErrorIs(t, err, tenant.ErrInvalid)
+	require.Empty(t, b.Tenants())
+
+	// nothing to replay: the topic goes with its last subscriber
+	subscribe(t, b, "idle", "").Close()
+	require.Empty(t, b.Tenants())
+
+	sub := subscribe(t, b, "acme", "")
+	require.NoError(t, b.Publish("acme", EventDeleted, UserEvent{Nickname: "partner"}))
+	id := receive(t, sub).ID
+	sub.Close()
+	require.Equal(t, []string{"acme"}, b.Tenants())
+
+	// the replay buffer outlives the subscriber until it expires
+	now = now.Add(time.Minute)
+	require.Empty(t, b.Tenants())
+	resumed := subscribe(t, b, "acme", id)
+	defer resumed.Close()
+	require.Equal(t, EventReset, receive(t, resumed).Type)
+}
+
+func TestBroker_SlowSubscriberIsDropped(t *testing.T) {
+	b := NewBroker(10, 2, time.Minute)
+	slow := subscribe(t, b, tenant.Default, "")
+	fast := subscribe(t, b, tenant.Default, "")
+	defer fast.Close()
+
+	for range 3 {
+		require.NoError(t, b.Publish(tenant.Default, EventDeleted, struct{}{}))
+		receive(t, fast)
+	}
+
+	receive(t, slow)
+	receive(t, slow)
+	_, ok := <-slow.C
+	require.False(t, ok)
+	require.True(t, slow.Lagged())
+}
+
+func TestBroker_Close(t *testing.T) {
+	b := NewBroker(10, 10, time.Minute)
+	sub := subscribe(t, b, tenant.Default, "")
+
+	b.Close()
+
+	_, ok := <-sub.C
+	require.False(t, ok)
+	require.False(t, sub.Lagged())
+}
+
+func TestFeed(t *testing.T) {
+	ctx := context.Background()
+	repo := memory.NewUserRepo()
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "first", 1, 2)))
+
+	b := NewBroker(10, 10, time.Minute)
+	sub := subscribe(t, b, tenant.Default, "")
+	defer sub.Close()
+
+	feed := NewFeed(b, repo, 2, slog.New(slog.NewTextHandler(io.Discard, nil)))
+	feed.Start(ctx)
+	defer feed.Stop()
+
+	require.NoError(t, repo.Create(ctx, *model.NewUser("name", "second", 2, 2)))
+	feed.HandleChange(service.Change{Op: service.ChangeCreated, Tenant: tenant.Default, Nickname: "second"})
+
+	event := receive(t, sub)
+	require.Equal(t, EventCreated, event.Type)
+	var created UserEvent
+	require.NoError(t, json.Unmarshal(event.Data, &created))
+	require.Equal(t, "second", created.User.NickName)
+
+	event = receive(t, sub)
+	require.Equal(t, EventLeaderboard, event.Type)
+	require.JSONEq(t, `{"top":[{"rank":1,"nickname":"second","rating":1},{"rank":2,"nickname":"first","rating":0.5}]}`, string(event.Data))
+
+	// other tenants get events and a leaderboard of their own
+	acme := subscribe(t, b, "acme", "")
+	defer acme.Close()
+	require.NoError(t, repo.Create(tenant.WithID(ctx, "acme"), *model.NewUser("name", "partner", 1, 4)))
+	feed.HandleChange(service.Change{Op: service.ChangeCreated, Tenant: "acme", Nickname: "partner"})
+
+	event = receive(t, acme)
+	require.Equal(t, EventCreated, event.Type)
+	require.NoError(t, json.Unmarshal(event.Data, &created))
+	require.Equal(t, "partner", created.User.NickName)
+	event = receive(t, acme)
+	require.Equal(t, EventLeaderboard, event.Type)
+	require.JSONEq(t, `{"top":[{"rank":1,"nickname":"partner","rating":0.25}]}`, string(event.Data))
+
+	require.NoError(t, repo.Delete(ctx, "first"))
+	feed.HandleChange(service.Change{Op: service.ChangeDeleted, Tenant: tenant.Default, Nickname: "first"})
+
+	event = receive(t, sub)
+	require.Equal(t, EventDeleted, event.Type)
+	require.JSONEq(t, `{"nickname":"first"}`, string(event.Data))
+	require.Equal(t, EventLeaderboard, receive(t, sub).Type)
+}
@@ -0,0 +1,188 @@
+package stream
+
+import (
+	"context"
+	"log/slog"
+	"rating/internal/dto/request"
+	"rating/internal/model"
+	"rating/internal/service"
+	"rating/internal/tenant"
+	"slices"
+	"time"
+)
+
+const (
+	feedQueueSize = 1024
+	lookupTimeout = 5 * time.Second
+)
+
+type UserReader interface {
+	GetUser(ctx context.Context, nickname string) (*model.User, error)
+	GetAll(ctx context.Context, params request.PaginationQuery) ([]model.User, model.UserCount, error)
+}
+
+type UserEvent struct {
+	Nickname    string      `json:"nickname"`
+	OldNickname string      `json:"old_nickname,omitempty"`
+	User        *model.User `json:"user,omitempty"`
+}
+
+type Rank struct {
+	Rank     int     `json:"rank"`
+	Nickname string  `json:"nickname"`
+	Rating   float64 `json:"rating"`
+}
+
+type LeaderboardEvent struct {
+	Top []Rank `json:"top"`
+}
+
+// Feed turns service changes into stream events of their tenant. Changes are
+// queued and resolved on a background goroutine so the writer is never
+// blocked; after each batch the top N of every tenant in it is recomputed and
+// published when the ranking changed.
+type Feed struct {
+	broker  *Broker
+	users   UserReader
+	topN    int
+	logger  *slog.Logger
+	changes chan service.Change
+	resync  chan struct{}
+	top     map[string][]Rank
+	cancel  context.CancelFunc
+	done    chan struct{}
+}
+
+func NewFeed(broker *Broker, users UserReader, topN int, log *slog.Logger) *Feed {
+	return &Feed{
+		broker:  broker,
+		users:   users,
+		topN:    topN,
+		logger:  log,
+		changes: make(chan service.Change, feedQueueSize),
+		resync:  make(chan struct{}, 1),
+		top:     make(map[string][]Rank),
+	}
+}
+
+// HandleChange queues a change. When the queue is full the change is dropped
+// and subscribers are told to reset.
+func (f *Feed) HandleChange(change service.Change) {
+	select {
+	case f.changes <- change:
+	default:
+		f.Resync()
+	}
+}
+
+// Resync tells subscribers that changes were missed.
+func (f *Feed) Resync() {
+	select {
+	case f.resync <- struct{}{}:
+	default:
+	}
+}
+
+func (f *Feed) Start(ctx context.Context) {
+	ctx, f.cancel = context.WithCancel(ctx)
+	f.done = make(chan struct{})
+	f.top[tenant.Default] = f.loadTop(ctx, tenant.Default)
+
+	go func() {
+		defer close(f.done)
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-f.resync:
+				// the dropped change could belong to any tenant
+				for _, id := range f.broker.Tenants() {
+					f.publish(id, EventReset, struct{}{})
+					f.refreshTop(ctx, id)
+				}
+			case change := <-f.changes:
+				touched := map[string]struct{}{}
+				f.handle(ctx, change)
+				touched[change.Tenant] = struct{}{}
+				for drained := false; !drained; {
+					select {
+					case change := <-f.changes:
+						f.handle(ctx, change)
+						touched[change.Tenant] = struct{}{}
+					default:
+						drained = true
+					}
+				}
+				for id := range touched {
+					f.refreshTop(ctx, id)
+				}
+			}
+		}
+	}()
+}
+
+func (f *Feed) Stop() {
+	if f.cancel == nil {
+		return
+	}
+	f.cancel()
+	<-f.done
+}
+
+func (f *Feed) handle(ctx context.Context, change service.Change) {
+	event := UserEvent{Nickname: change.Nickname, OldNickname: change.OldNickname}
+
+	if change.Op != service.ChangeDeleted {
+		lookupCtx, cancel := context.WithTimeout(tenant.WithID(ctx, change.Tenant), lookupTimeout)
+		user, err := f.users.GetUser(lookupCtx, change.Nickname)
+		cancel()
+		if err != nil {
+			f.logger.WarnContext(ctx, "stream feed", slog.Any("failed to load changed user", err), slog.String("nickname", change.Nickname))
+			return
+		}
+		event.User = user
+	}
+
+	f.publish(change.Tenant, change.Op, event)
+}
+
+func (f *Feed) refreshTop(ctx context.Context, tenantId string) {
+	top := f.loadTop(ctx, tenantId)
+	if top == nil || slices.Equal(top, f.top[tenantId]) {
+		return
+	}
+	f.top[tenantId] = top
+
+	f.publish(tenantId, EventLeaderboard, LeaderboardEvent{Top: top})
+}
+
+func (f *Feed) loadTop(ctx context.Context, tenantId string) []Rank {
+	if f.topN < 1 {
+		return nil
+	}
+
+	ctx, cancel := context.WithTimeout(tenant.WithID(ctx, tenantId), lookupTimeout)
+	defer cancel()
+
+	params := request.NewPaginationQuery(f.topN, 0, "desc")
+	params.CountMode = request.CountNone
+	users, _, err := f.users.GetAll(ctx, params)
+	if err != nil {
+		f.logger.WarnContext(ctx, "stream feed", slog.Any("failed to load leaderboard", err), slog.String("tenant", tenantId))
+		return nil
+	}
+
+	top := make([]Rank, 0, len(users))
+	for i, u := range users {
+		top = append(top, Rank{Rank: i + 1, Nickname: u.NickName, Rating: u.Rating})
+	}
+
+	return top
+}
+
+func (f *Feed) publish(tenantId, eventType string, data any) {
+	if err := f.broker.Publish(tenantId, eventType, data); err != nil {
+		f.logger.Error("stream feed", slog.Any("failed to publish event", err))
+	}
+}
@@ -0,0 +1,103 @@
+// Package tenant carries the partner workspace a request acts on. Users,
+// categories and leaderboards are scoped to it; work that names no tenant,
+// such as the API without tenancy configured, acts on Default.
+package tenant
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"regexp"
+)
+
+// Default owns the users created before tenants existed and every request
+// that does not name a tenant.
+const Default = "default"
+
+// APIKeyHeader carries the API key a tenant is resolved from.
+const APIKeyHeader = "X-API-Key"
+
+var idPattern = regexp.MustCompile(`^[a-z0-9][a-z0-9_-]{0,62}$`)
+
+var (
+	ErrUnauthorized = errors.New("unauthorized")
+	ErrInvalid      = errors.New("invalid tenant")
+)
+
+// Valid reports whether id can name a tenant: 1-63 lowercase letters, digits,
+// dashes or underscores.
+func Valid(id string) bool {
+	return idPattern.MatchString(id)
+}
+
+type key struct{}
+
+func WithID(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, key{}, id)
+}
+
+// FromContext returns the tenant of ctx and whether one was set.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(key{}).(string)
+	return id, ok
+}
+
+// ID returns the tenant of ctx, Default when none was set.
+func ID(ctx context.Context) string {
+	if id, ok := FromContext(ctx); ok {
+		return id
+	}
+
+	return Default
+}
+
+type allKey struct{}
+
+// WithAll marks ctx as working across every tenant, for background work such
+// as snapshots and counts. Row-level security binds no tenant for it.
+func WithAll(ctx context.Context) context.Context {
+	return context.WithValue(ctx, allKey{}, true)
+}
+
+// All reports whether ctx was marked by WithAll.
+func All(ctx context.Context) bool {
+	all, _ := ctx.Value(allKey{}).(bool)
+	return all
+}
+
+// Resolver finds the tenant of a request. With API keys configured the key
+// alone decides and is required; otherwise the tenant is read from header.
+type Resolver struct {
+	keys   map[string]string
+	header string
+}
+
+func NewResolver(keys map[string]string, header string) *Resolver {
+	return &Resolver{
+		keys:   keys,
+		header: header,
+	}
+}
+
+// Resolve returns an empty id when the request names no tenant, which then
+// acts on Default.
+func (r *Resolver) Resolve(req *http.Request) (string, error) {
+	if len(r.keys) > 0 {
+		id, ok := r.keys[req.Header.Get(APIKeyHeader)]
+		if !ok {
+			return "", fmt.Errorf("%w: missing or unknown %s", ErrUnauthorized, APIKeyHeader)
+		}
+		return id, nil
+	}
+
+	if r.header == "" {
+		return "", nil
+	}
+	id := req.Header.Get(r.header)
+	if id != "" && !Valid(id) {
+		return "", fmt.Errorf("%w: %s must be 1-63 lowercase letters, digits, dashes or underscores", ErrInvalid, r.header)
+	}
+
+	return id, nil
+}
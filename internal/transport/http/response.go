@@ -1,15 +1,16 @@
 package response
 
 import (
+	"context"
 	"encoding/json"
 	"log/slog"
 	"net/http"
 )
 
-func ResponseJSON(log *slog.Logger, w http.ResponseWriter, status int, data any) {
+func ResponseJSON(ctx context.Context, log *slog.Logger, w http.ResponseWriter, status int, data any) {
 	b, err := json.Marshal(data)
 	if err != nil {
-		log.Error("response http", slog.Any("failed to marshal", err))
+		log.ErrorContext(ctx, "response http", slog.Any("failed to marshal", err))
 		w.Header().Set("Content-Type", "application/json")
 		w.WriteHeader(http.StatusInternalServerError)
 
@@ -22,6 +23,6 @@ func ResponseJSON(log *slog.Logger, w http.ResponseWriter, status int, data any)
 	w.Write(b)
 }
 
-func ResponseErr(log *slog.Logger, w http.ResponseWriter, status int, message string) {
-	ResponseJSON(log, w, status, map[string]string{"error": message})
+func ResponseErr(ctx context.Context, log *slog.Logger, w http.ResponseWriter, status int, message string) {
+	ResponseJSON(ctx, log, w, status, map[string]string{"error": message})
 }
@@ -0,0 +1,233 @@
+package webhook
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"math/rand/v2"
+	"net/http"
+	"rating/internal/model"
+	"strconv"
+	"sync"
+	"time"
+)
+
+const maxErrorBody = 512
+
+const pruneInterval = time.Hour
+
+type Store interface {
+	// FanOut turns up to limit unprocessed outbox events into deliveries for
+	// the subscriptions match selects and returns how many events it processed.
+	FanOut(ctx context.Context, limit int, match func(model.WebhookSubscription, model.OutboxEvent) (string, bool)) (int, error)
+	// ClaimDeliveries returns up to limit due deliveries with their attempt
+	// counted and hides them from other claimers for lease.
+	ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.PendingDelivery, error)
+	MarkDelivered(ctx context.Context, id int64) error
+	MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time, dead bool) error
+	// Prune deletes outbox events processed before the given time whose
+	// deliveries are all finished, together with those deliveries.
+	Prune(ctx context.Context, before time.Time) (int64, error)
+}
+
+type DispatcherConfig struct {
+	PollInterval time.Duration
+	BatchSize    int
+	Workers      int
+	MaxAttempts  int
+	Timeout      time.Duration
+	BackoffBase  time.Duration
+	BackoffMax   time.Duration
+	Retention    time.Duration
+
+	// AllowPrivateTargets lets deliveries reach loopback, link-local and
+	// private addresses, for receivers inside the same network.
+	AllowPrivateTargets bool
+}
+
+// Dispatcher polls the outbox and delivers due webhooks. Several instances
+// can run side by side, the store hands every delivery to one of them.
+type Dispatcher struct {
+	store     Store
+	client    *http.Client
+	cfg       DispatcherConfig
+	logger    *slog.Logger
+	now       func() time.Time
+	lastPrune time.Time
+	cancel    context.CancelFunc
+	done      chan struct{}
+}
+
+func NewDispatcher(store Store, cfg DispatcherConfig, log *slog.Logger) *Dispatcher {
+	return &Dispatcher{
+		store:  store,
+		client: newClient(cfg),
+		cfg:    cfg,
+		logger: log,
+		now:    time.Now,
+	}
+}
+
+// newClient builds the delivery client. Redirects are not followed, a
+// receiver could otherwise bounce a delivery to any address.
+func newClient(cfg DispatcherConfig) *http.Client {
+	transport := http.DefaultTransport.(*http.Transport).Clone()
+	if !cfg.AllowPrivateTargets {
+		transport.DialContext = publicDialer().DialContext
+	}
+
+	return &http.Client{
+		Transport: transport,
+		Timeout:   cfg.Timeout,
+		CheckRedirect: func(*http.Request, []*http.Request) error {
+			return http.ErrUseLastResponse
+		},
+	}
+}
+
+func (d *Dispatcher) Start(ctx context.Context) {
+	ctx, d.cancel = context.WithCancel(ctx)
+	d.done = make(chan struct{})
+
+	go func() {
+		defer close(d.done)
+		ticker := time.NewTicker(d.cfg.PollInterval)
+		defer ticker.Stop()
+
+		for {
+			if err := d.RunOnce(ctx); err != nil && ctx.Err() == nil {
+				d.logger.WarnContext(ctx, "webhook dispatcher", slog.Any("run failed", err))
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+		}
+	}()
+}
+
+func (d *Dispatcher) Stop() {
+	if d.cancel == nil {
+		return
+	}
+	d.cancel()
+	<-d.done
+}
+
+// RunOnce fans out every pending outbox event, sends one batch of due
+// deliveries and prunes old processed events.
+func (d *Dispatcher) RunOnce(ctx context.Context) error {
+	for {
+		processed, err := d.store.FanOut(ctx, d.cfg.BatchSize, Match)
+		if err != nil {
+			return err
+		}
+		if processed < d.cfg.BatchSize {
+			break
+		}
+	}
+
+	deliveries, err := d.store.ClaimDeliveries(ctx, d.cfg.BatchSize, 2*d.cfg.Timeout)
+	if err != nil {
+		return err
+	}
+
+	sem := make(chan struct{}, max(d.cfg.Workers, 1))
+	var wg sync.WaitGroup
+	for _, delivery := range deliveries {
+		sem <- struct{}{}
+		wg.Add(1)
+		go func() {
+			defer func() {
+				<-sem
+				wg.Done()
+			}()
+			d.deliver(ctx, delivery)
+		}()
+	}
+	wg.Wait()
+
+	if d.cfg.Retention > 0 && d.now().Sub(d.lastPrune) > pruneInterval {
+		pruned, err := d.store.Prune(ctx, d.now().Add(-d.cfg.Retention))
+		if err != nil {
+			return err
+		}
+		d.lastPrune = d.now()
+		if pruned > 0 {
+			d.logger.InfoContext(ctx, "webhook dispatcher", slog.Int64("pruned processed events", pruned))
+		}
+	}
+
+	return nil
+}
+
+func (d *Dispatcher) deliver(ctx context.Context, delivery model.PendingDelivery) {
+	err := d.send(ctx, delivery)
+	if err == nil {
+		if err := d.store.MarkDelivered(ctx, delivery.Id); err != nil {
+			d.logger.ErrorContext(ctx, "webhook dispatcher", slog.Any("failed to mark delivery delivered", err), slog.Int64("delivery_id", delivery.Id))
+		}
+		return
+	}
+
+	dead := delivery.Attempts >= d.cfg.MaxAttempts
+	retryAt := d.now().Add(d.backoff(delivery.Attempts))
+	if dead {
+		d.logger.WarnContext(ctx, "webhook dispatcher", slog.String("delivery dead-lettered", err.Error()), slog.Int64("delivery_id", delivery.Id), slog.Int("attempts", delivery.Attempts))
+	}
+
+	if err := d.store.MarkFailed(ctx, delivery.Id, err.Error(), retryAt, dead); err != nil {
+		d.logger.ErrorContext(ctx, "webhook dispatcher", slog.Any("failed to mark delivery failed", err), slog.Int64("delivery_id", delivery.Id))
+	}
+}
+
+func (d *Dispatcher) send(ctx context.Context, delivery model.PendingDelivery) error {
+	body, err := json.Marshal(Payload{
+		Id:        delivery.Id,
+		Event:     delivery.EventType,
+		CreatedAt: delivery.CreatedAt,
+		Data:      delivery.Payload,
+	})
+	if err != nil {
+		return fmt.Errorf("failed to marshal payload: %w", err)
+	}
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodPost, delivery.URL, bytes.NewReader(body))
+	if err != nil {
+		return fmt.Errorf("failed to build request: %w", err)
+	}
+	now := d.now()
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set(HeaderEvent, delivery.EventType)
+	req.Header.Set(HeaderDelivery, strconv.FormatInt(delivery.Id, 10))
+	req.Header.Set(HeaderTimestamp, strconv.FormatInt(now.Unix(), 10))
+	req.Header.Set(HeaderSignature, Sign(delivery.Secret, now, body))
+
+	resp, err := d.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode > 299 {
+		snippet, _ := io.ReadAll(io.LimitReader(resp.Body, maxErrorBody))
+		return fmt.Errorf("receiver responded %s: %s", resp.Status, bytes.TrimSpace(snippet))
+	}
+
+	return nil
+}
+
+// backoff doubles from BackoffBase per attempt up to BackoffMax, with jitter.
+func (d *Dispatcher) backoff(attempts int) time.Duration {
+	delay := d.cfg.BackoffMax
+	if attempts < 20 {
+		delay = min(d.cfg.BackoffBase<<max(attempts-1, 0), d.cfg.BackoffMax)
+	}
+
+	return delay/2 + rand.N(delay/2+1)
+}
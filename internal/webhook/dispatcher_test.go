@@ -0,0 +1,257 @@
+package webhook
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"rating/internal/model"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+type failure struct {
+	reason  string
+	retryAt time.Time
+	dead    bool
+}
+
+type fakeStore struct {
+	mu        sync.Mutex
+	pending   []model.PendingDelivery
+	delivered []int64
+	failed    map[int64]failure
+	pruned    []time.Time
+}
+
+func (s *fakeStore) FanOut(ctx context.Context, limit int, match func(model.WebhookSubscription, model.OutboxEvent) (string, bool)) (int, error) {
+	return 0, nil
+}
+
+func (s *fakeStore) ClaimDeliveries(ctx context.Context, limit int, lease time.Duration) ([]model.PendingDelivery, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	claimed := s.pending
+	s.pending = nil
+	for i := range claimed {
+		claimed[i].Attempts++
+	}
+	return claimed, nil
+}
+
+func (s *fakeStore) MarkDelivered(ctx context.Context, id int64) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.delivered = append(s.delivered, id)
+	return nil
+}
+
+func (s *fakeStore) MarkFailed(ctx context.Context, id int64, reason string, retryAt time.Time, dead bool) error {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.failed[id] = failure{reason: reason, retryAt: retryAt, dead: dead}
+	return nil
+}
+
+func (s *fakeStore) Prune(ctx context.Context, before time.Time) (int64, error) {
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	s.pruned = append(s.pruned, before)
+	return 0, nil
+}
+
+func newTestDispatcher(store Store) *Dispatcher {
+	return NewDispatcher(store, DispatcherConfig{
+		PollInterval: time.Second,
+		BatchSize:    10,
+		Workers:      2,
+		MaxAttempts:  3,
+		Timeout:      time.Second,
+		BackoffBase:  time.Second,
+		BackoffMax:   time.Minute,
+
+		AllowPrivateTargets: true,
+	}, slog.New(slog.NewTextHandler(io.Discard, nil)))
+}
+
+func TestDispatcher_RunOnce(t *testing.T) {
+	const secret = "0123456789abcdef"
+
+	var received []Payload
+	var mu sync.Mutex
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		body, err := io.ReadAll(r.Body)
+		require.NoError(t, err)
+
+		if !Verify(secret, r.Header.Get(HeaderTimestamp), r.Header.Get(HeaderSignature), body) {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+
+		var payload Payload
+		require.NoError(t, json.Unmarshal(body, &payload))
+		require.Equal(t, payload.Event, r.Header.Get(HeaderEvent))
+
+		mu.Lock()
+		received = append(received, payload)
+		mu.Unlock()
+	}))
+	defer receiver.Close()
+
+	failing := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		http.Error(w, "down for maintenance", http.StatusServiceUnavailable)
+	}))
+	defer failing.Close()
+
+	store := &fakeStore{
+		failed: make(map[int64]failure),
+		pending: []model.PendingDelivery{
+			{Id: 1, EventType: model.WebhookUserCreated, URL: receiver.URL, Secret: secret, Payload: json.RawMessage(`{"nickname":"nickname"}`)},
+			{Id: 2, EventType: model.WebhookUserCreated, URL: receiver.URL, Secret: "wrong secret 123", Payload: json.RawMessage(`{}`)},
+			{Id: 3, EventType: model.WebhookUserDeleted, URL: failing.URL, Secret: secret, Payload: json.RawMessage(`{}`), Attempts: 2},
+		},
+	}
+	d := newTestDispatcher(store)
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	require.NoError(t, d.RunOnce(context.Background()))
+
+	require.Equal(t, []int64{1}, store.delivered)
+	require.Len(t, received, 1)
+	require.Equal(t, int64(1), received[0].Id)
+	require.JSONEq(t, `{"nickname":"nickname"}`, string(received[0].Data))
+
+	require.False(t, store.failed[2].dead)
+	require.Contains(t, store.failed[2].reason, "401")
+	require.WithinRange(t, store.failed[2].retryAt, now.Add(500*time.Millisecond), now.Add(time.Second))
+
+	require.True(t, store.failed[3].dead)
+	require.Contains(t, store.failed[3].reason, "down for maintenance")
+}
+
+func TestDispatcher_Targets(t *testing.T) {
+	var hits int
+	receiver := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		hits++
+	}))
+	defer receiver.Close()
+	redirect := httptest.NewServer(http.RedirectHandler(receiver.URL, http.StatusTemporaryRedirect))
+	defer redirect.Close()
+
+	t.Run("private address", func(t *testing.T) {
+		store := &fakeStore{failed: make(map[int64]failure), pending: []model.PendingDelivery{{Id: 1, URL: receiver.URL}}}
+		d := newTestDispatcher(store)
+		d.client = newClient(DispatcherConfig{Timeout: time.Second})
+
+		require.NoError(t, d.RunOnce(context.Background()))
+		require.Contains(t, store.failed[1].reason, ErrPrivateTarget.Error())
+		require.Zero(t, hits)
+	})
+
+	t.Run("redirect", func(t *testing.T) {
+		store := &fakeStore{failed: make(map[int64]failure), pending: []model.PendingDelivery{{Id: 1, URL: redirect.URL}}}
+
+		require.NoError(t, newTestDispatcher(store).RunOnce(context.Background()))
+		require.Contains(t, store.failed[1].reason, "307")
+		require.Zero(t, hits)
+	})
+}
+
+func TestCheckTarget(t *testing.T) {
+	tests := []struct {
+		url     string
+		private bool
+	}{
+		{url: "https://partner.example.com/hooks"},
+		{url: "https://93.184.216.34/hooks"},
+		{url: "http://localhost:8080/hooks", private: true},
+		{url: "http://api.localhost/hooks", private: true},
+		{url: "http://127.0.0.1/hooks", private: true},
+		{url: "http://10.0.0.5/hooks", private: true},
+		{url: "http://169.254.169.254/latest/meta-data", private: true},
+		{url: "http://[::1]/hooks", private: true},
+		{url: "http://[::ffff:192.168.1.1]/hooks", private: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.url, func(t *testing.T) {
+			u, err := url.Parse(tt.url)
+			require.NoError(t, err)
+			if tt.private {
+				require.ErrorIs(t, CheckTarget(u), ErrPrivateTarget)
+				return
+			}
+			require.NoError(t, CheckTarget(u))
+		})
+	}
+}
+
+func TestDispatcher_Prune(t *testing.T) {
+	store := &fakeStore{}
+	d := newTestDispatcher(store)
+	d.cfg.Retention = 24 * time.Hour
+	now := time.Date(2026, 1, 1, 0, 0, 0, 0, time.UTC)
+	d.now = func() time.Time { return now }
+
+	require.NoError(t, d.RunOnce(context.Background()))
+	require.NoError(t, d.RunOnce(context.Background()))
+	require.Equal(t, []time.Time{now.Add(-24 * time.Hour)}, store.pruned)
+
+	now = now.Add(2 * pruneInterval)
+	require.NoError(t, d.RunOnce(context.Background()))
+	require.Len(t, store.pruned, 2)
+}
+
+func TestDispatcher_Backoff(t *testing.T) {
+	d := newTestDispatcher(&fakeStore{})
+
+	for attempts, want := range map[int]time.Duration{1: time.Second, 3: 4 * time.Second, 10: time.Minute, 100: time.Minute} {
+		got := d.backoff(attempts)
+		require.GreaterOrEqual(t, got, want/2)
+		require.LessOrEqual(t, got, want)
+	}
+}
+
+func TestMatch(t *testing.T) {
+	threshold := 0.5
+	sub := model.WebhookSubscription{EventTypes: []string{model.WebhookUserCreated, model.WebhookRatingCrossed}, RatingThreshold: &threshold}
+
+	ratingChange := func(oldRating, newRating float64) model.OutboxEvent {
+		payload, err := json.Marshal(model.RatingChange{User: model.User{Rating: newRating}, OldRating: oldRating})
+		require.NoError(t, err)
+		return model.OutboxEvent{Type: model.WebhookRatingChanged, Payload: payload}
+	}
+
+	tests := []struct {
+		name      string
+		event     model.OutboxEvent
+		wantType  string
+		wantMatch bool
+	}{
+		{name: "subscribed event", event: model.OutboxEvent{Type: model.WebhookUserCreated}, wantType: model.WebhookUserCreated, wantMatch: true},
+		{name: "other event", event: model.OutboxEvent{Type: model.WebhookUserDeleted}, wantType: model.WebhookUserDeleted},
+		{name: "crossed upwards", event: ratingChange(0.4, 0.5), wantType: model.WebhookRatingCrossed, wantMatch: true},
+		{name: "crossed downwards", event: ratingChange(0.6, 0.1), wantType: model.WebhookRatingCrossed, wantMatch: true},
+		{name: "stayed above", event: ratingChange(0.6, 0.9), wantType: model.WebhookRatingCrossed},
+		{name: "stayed below", event: ratingChange(0.1, 0.4), wantType: model.WebhookRatingCrossed},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			eventType, ok := Match(sub, tt.event)
+			require.Equal(t, tt.wantMatch, ok)
+			require.Equal(t, tt.wantType, eventType)
+		})
+	}
+}
@@ -0,0 +1,62 @@
+package webhook
+
+import (
+	"errors"
+	"fmt"
+	"net"
+	"net/netip"
+	"net/url"
+	"strings"
+	"syscall"
+	"time"
+)
+
+// ErrPrivateTarget is returned for receivers on loopback, link-local or
+// private addresses, which would let a subscriber reach internal services.
+var ErrPrivateTarget = errors.New("webhook target is not a public address")
+
+// privateAddr reports whether addr is not publicly routable.
+func privateAddr(addr netip.Addr) bool {
+	addr = addr.Unmap()
+	return addr.IsLoopback() || addr.IsPrivate() || addr.IsUnspecified() ||
+		addr.IsLinkLocalUnicast() || addr.IsLinkLocalMulticast() ||
+		addr.IsInterfaceLocalMulticast() || addr.IsMulticast()
+}
+
+// CheckTarget rejects receiver URLs that name a private address or
+// localhost. Other host names are checked again once resolved, when the
+// dispatcher dials them.
+func CheckTarget(u *url.URL) error {
+	host := strings.ToLower(u.Hostname())
+	if host == "localhost" || strings.HasSuffix(host, ".localhost") {
+		return ErrPrivateTarget
+	}
+	if addr, err := netip.ParseAddr(host); err == nil && privateAddr(addr) {
+		return ErrPrivateTarget
+	}
+
+	return nil
+}
+
+// publicDialer refuses connections to private addresses after resolution,
+// so a public name that resolves to an internal host is caught as well.
+func publicDialer() *net.Dialer {
+	return &net.Dialer{
+		Timeout:   30 * time.Second,
+		KeepAlive: 30 * time.Second,
+		Control: func(network, address string, _ syscall.RawConn) error {
+			host, _, err := net.SplitHostPort(address)
+			if err != nil {
+				return err
+			}
+			addr, err := netip.ParseAddr(host)
+			if err != nil {
+				return err
+			}
+			if privateAddr(addr) {
+				return fmt.Errorf("%w: %s", ErrPrivateTarget, addr)
+			}
+			return nil
+		},
+	}
+}
@@ -0,0 +1,73 @@
+// Package webhook fans outbox events out to webhook subscriptions and
+// delivers them with signed, retried HTTP requests.
+package webhook
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"rating/internal/model"
+	"slices"
+	"strconv"
+	"time"
+)
+
+const (
+	HeaderEvent     = "X-Webhook-Event"
+	HeaderDelivery  = "X-Webhook-Delivery"
+	HeaderTimestamp = "X-Webhook-Timestamp"
+	HeaderSignature = "X-Webhook-Signature"
+)
+
+// Match reports which event, if any, sub receives for an outbox event.
+func Match(sub model.WebhookSubscription, event model.OutboxEvent) (string, bool) {
+	switch event.Type {
+	case model.WebhookUserCreated, model.WebhookUserDeleted:
+		return event.Type, slices.Contains(sub.EventTypes, event.Type)
+	case model.WebhookRatingChanged:
+		if sub.RatingThreshold == nil || !slices.Contains(sub.EventTypes, model.WebhookRatingCrossed) {
+			return "", false
+		}
+
+		var change model.RatingChange
+		if err := json.Unmarshal(event.Payload, &change); err != nil {
+			return "", false
+		}
+		threshold := *sub.RatingThreshold
+		crossed := (change.OldRating < threshold) != (change.User.Rating < threshold)
+
+		return model.WebhookRatingCrossed, crossed
+	default:
+		return "", false
+	}
+}
+
+// Sign returns the signature header value for body sent at timestamp:
+// "sha256=" and the hex HMAC-SHA256 of "<unix timestamp>.<body>".
+func Sign(secret string, timestamp time.Time, body []byte) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(strconv.FormatInt(timestamp.Unix(), 10)))
+	mac.Write([]byte("."))
+	mac.Write(body)
+
+	return "sha256=" + hex.EncodeToString(mac.Sum(nil))
+}
+
+// Verify checks a signature the way a receiver would.
+func Verify(secret, timestamp, signature string, body []byte) bool {
+	unix, err := strconv.ParseInt(timestamp, 10, 64)
+	if err != nil {
+		return false
+	}
+
+	return hmac.Equal([]byte(signature), []byte(Sign(secret, time.Unix(unix, 0), body)))
+}
+
+// Payload is the body of every webhook request.
+type Payload struct {
+	Id        int64           `json:"id"`
+	Event     string          `json:"event"`
+	CreatedAt time.Time       `json:"created_at"`
+	Data      json.RawMessage `json:"data"`
+}
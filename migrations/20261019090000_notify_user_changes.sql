@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE FUNCTION notify_user_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object(
+        'op', TG_OP,
+        'nickname', CASE WHEN TG_OP = 'DELETE' THEN OLD.nickname ELSE NEW.nickname END,
+        'old_nickname', CASE WHEN TG_OP = 'UPDATE' THEN OLD.nickname END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION notify_user_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER users_notify_change ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP FUNCTION notify_user_change();
+-- +goose StatementEnd
@@ -0,0 +1,23 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE job_runs (
+    id BIGSERIAL PRIMARY KEY,
+    job TEXT NOT NULL,
+    scheduled_at TIMESTAMPTZ NOT NULL,
+    started_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    finished_at TIMESTAMPTZ,
+    status TEXT NOT NULL DEFAULT 'running' CHECK (status IN ('running', 'succeeded', 'failed', 'cancelled')),
+    error TEXT NOT NULL DEFAULT '',
+    instance TEXT NOT NULL,
+    UNIQUE (job, scheduled_at)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX job_runs_started_at ON job_runs (started_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE job_runs;
+-- +goose StatementEnd
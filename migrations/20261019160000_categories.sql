@@ -0,0 +1,30 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE categories (
+    id BIGSERIAL PRIMARY KEY,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMPTZ NOT NULL DEFAULT now()
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE user_categories (
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    category_id BIGINT NOT NULL REFERENCES categories (id) ON DELETE CASCADE,
+    PRIMARY KEY (user_id, category_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX user_categories_category ON user_categories (category_id, user_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE user_categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE categories;
+-- +goose StatementEnd
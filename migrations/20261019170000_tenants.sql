@@ -0,0 +1,165 @@
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT users_nickname_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ADD CONSTRAINT users_tenant_nickname_key UNIQUE (tenant_id, nickname);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories DROP CONSTRAINT categories_slug_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories ADD CONSTRAINT categories_tenant_slug_key UNIQUE (tenant_id, slug);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX leaderboard_entries_tenant ON leaderboard_entries (snapshot_id, tenant_id);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE event_outbox ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhook_subscriptions ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhook_outbox ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_user_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object(
+        'op', TG_OP,
+        'tenant', CASE WHEN TG_OP = 'DELETE' THEN OLD.tenant_id ELSE NEW.tenant_id END,
+        'nickname', CASE WHEN TG_OP = 'DELETE' THEN OLD.nickname ELSE NEW.nickname END,
+        'old_nickname', CASE WHEN TG_OP = 'UPDATE' THEN OLD.nickname END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- Sessions that bind no tenant, such as background jobs or roles that own the
+-- tables, see every row; DB_ROW_LEVEL_SECURITY binds the request tenant.
+-- +goose StatementBegin
+ALTER TABLE users ENABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY users_tenant_isolation ON users
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories ENABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY categories_tenant_isolation ON categories
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries ENABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY categories_tenant_isolation ON categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY users_tenant_isolation ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DISABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE OR REPLACE FUNCTION notify_user_change() RETURNS trigger AS $$
+BEGIN
+    PERFORM pg_notify('user_changes', json_build_object(
+        'op', TG_OP,
+        'nickname', CASE WHEN TG_OP = 'DELETE' THEN OLD.nickname ELSE NEW.nickname END,
+        'old_nickname', CASE WHEN TG_OP = 'UPDATE' THEN OLD.nickname END
+    )::text);
+    RETURN NULL;
+END;
+$$ LANGUAGE plpgsql;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhook_outbox DROP COLUMN tenant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE webhook_subscriptions DROP COLUMN tenant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE event_outbox DROP COLUMN tenant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries DROP COLUMN tenant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories DROP CONSTRAINT categories_tenant_slug_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories ADD CONSTRAINT categories_slug_key UNIQUE (slug);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories DROP COLUMN tenant_id;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP CONSTRAINT users_tenant_nickname_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users ADD CONSTRAINT users_nickname_key UNIQUE (nickname);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN tenant_id;
+-- +goose StatementEnd
@@ -0,0 +1,28 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE nickname_aliases (
+    tenant_id TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    user_id BIGINT NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    released_at TIMESTAMPTZ NOT NULL DEFAULT now(),
+    PRIMARY KEY (tenant_id, nickname)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX nickname_aliases_user ON nickname_aliases (user_id, released_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ENABLE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY nickname_aliases_tenant_isolation ON nickname_aliases
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE nickname_aliases;
+-- +goose StatementEnd
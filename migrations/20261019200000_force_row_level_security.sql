@@ -0,0 +1,37 @@
+-- The service role runs the migrations and so owns the tables, which exempts
+-- it from their policies unless they are forced. Requests bind their tenant,
+-- only work marked as cross-tenant binds '' and sees every row.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases NO FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE leaderboard_entries NO FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE categories NO FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users NO FORCE ROW LEVEL SECURITY;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- Deleting a user keeps the nicknames it released, without a user, so they
+-- still cool down but no longer redirect.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ALTER COLUMN user_id DROP NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases DROP CONSTRAINT nickname_aliases_user_id_fkey;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ADD CONSTRAINT nickname_aliases_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE SET NULL;
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DELETE FROM nickname_aliases WHERE user_id IS NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases DROP CONSTRAINT nickname_aliases_user_id_fkey;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ADD CONSTRAINT nickname_aliases_user_id_fkey
+    FOREIGN KEY (user_id) REFERENCES users (id) ON DELETE CASCADE;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ALTER COLUMN user_id SET NOT NULL;
+-- +goose StatementEnd
@@ -0,0 +1,34 @@
+-- Updates that leave a row as it was no longer notify, so they do not flush
+-- caches or reset streams.
+
+-- +goose Up
+-- +goose StatementBegin
+DROP TRIGGER users_notify_change ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_notify_change
+    AFTER INSERT OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION notify_user_change();
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_notify_update
+    AFTER UPDATE ON users
+    FOR EACH ROW WHEN (OLD.* IS DISTINCT FROM NEW.*) EXECUTE FUNCTION notify_user_change();
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TRIGGER users_notify_update ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TRIGGER users_notify_change ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TRIGGER users_notify_change
+    AFTER INSERT OR UPDATE OR DELETE ON users
+    FOR EACH ROW EXECUTE FUNCTION notify_user_change();
+-- +goose StatementEnd
@@ -0,0 +1,76 @@
+-- A session that binds no tenant no longer sees every row, it sees none.
+-- Cross-tenant work has to say so explicitly through app.all_tenants.
+
+-- +goose Up
+-- +goose StatementBegin
+DROP POLICY users_tenant_isolation ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY users_tenant_isolation ON users
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY categories_tenant_isolation ON categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY categories_tenant_isolation ON categories
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY nickname_aliases_tenant_isolation ON nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY nickname_aliases_tenant_isolation ON nickname_aliases
+    USING (tenant_id = current_setting('app.tenant_id', true) OR current_setting('app.all_tenants', true) = 'on');
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP POLICY nickname_aliases_tenant_isolation ON nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY nickname_aliases_tenant_isolation ON nickname_aliases
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY leaderboard_entries_tenant_isolation ON leaderboard_entries
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY categories_tenant_isolation ON categories;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY categories_tenant_isolation ON categories
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP POLICY users_tenant_isolation ON users;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE POLICY users_tenant_isolation ON users
+    USING (COALESCE(current_setting('app.tenant_id', true), '') IN ('', tenant_id));
+-- +goose StatementEnd
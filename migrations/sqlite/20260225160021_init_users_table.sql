@@ -0,0 +1,24 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE users (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nickname TEXT NOT NULL UNIQUE,
+    likes INTEGER NOT NULL DEFAULT 0 CHECK (likes >= 0),
+    viewers INTEGER NOT NULL DEFAULT 0 CHECK (viewers >= 0),
+
+    rating REAL GENERATED ALWAYS AS (
+        CASE WHEN viewers > 0
+             THEN ROUND(CAST(likes AS REAL) / viewers, 3)
+             ELSE 0
+        END
+    ) STORED,
+
+    CONSTRAINT likes_lte_viewers CHECK (likes <= viewers)
+);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE users;
+-- +goose StatementEnd
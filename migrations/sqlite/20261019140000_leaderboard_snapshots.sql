@@ -0,0 +1,32 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE leaderboard_snapshots (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    taken_at TIMESTAMP NOT NULL,
+    users INTEGER NOT NULL DEFAULT 0
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE TABLE leaderboard_entries (
+    snapshot_id INTEGER NOT NULL REFERENCES leaderboard_snapshots (id) ON DELETE CASCADE,
+    user_id INTEGER NOT NULL,
+    nickname TEXT NOT NULL,
+    rating REAL NOT NULL,
+    rank INTEGER NOT NULL,
+    PRIMARY KEY (snapshot_id, user_id)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX leaderboard_entries_user ON leaderboard_entries (user_id, snapshot_id);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE leaderboard_entries;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE leaderboard_snapshots;
+-- +goose StatementEnd
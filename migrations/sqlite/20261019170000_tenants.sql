@@ -0,0 +1,118 @@
+-- +goose NO TRANSACTION
+-- SQLite cannot drop the UNIQUE constraints in place, users and categories are
+-- rebuilt with foreign keys off so the tables referencing them are untouched.
+
+-- +goose Up
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+-- +goose StatementBegin
+CREATE TABLE users_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    likes INTEGER NOT NULL DEFAULT 0 CHECK (likes >= 0),
+    viewers INTEGER NOT NULL DEFAULT 0 CHECK (viewers >= 0),
+
+    rating REAL GENERATED ALWAYS AS (
+        CASE WHEN viewers > 0
+             THEN ROUND(CAST(likes AS REAL) / viewers, 3)
+             ELSE 0
+        END
+    ) STORED,
+
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+
+    CONSTRAINT likes_lte_viewers CHECK (likes <= viewers),
+    UNIQUE (tenant_id, nickname)
+);
+-- +goose StatementEnd
+
+INSERT INTO users_new (id, name, nickname, likes, viewers) SELECT id, name, nickname, likes, viewers FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE users_new RENAME TO users;
+
+-- +goose StatementBegin
+CREATE TABLE categories_new (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL,
+    tenant_id TEXT NOT NULL DEFAULT 'default',
+    UNIQUE (tenant_id, slug)
+);
+-- +goose StatementEnd
+
+INSERT INTO categories_new (id, slug, name, created_at) SELECT id, slug, name, created_at FROM categories;
+
+DROP TABLE categories;
+
+ALTER TABLE categories_new RENAME TO categories;
+
+ALTER TABLE leaderboard_entries ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+CREATE INDEX leaderboard_entries_tenant ON leaderboard_entries (snapshot_id, tenant_id);
+
+ALTER TABLE event_outbox ADD COLUMN tenant_id TEXT NOT NULL DEFAULT 'default';
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
+
+-- +goose Down
+PRAGMA foreign_keys = OFF;
+
+BEGIN;
+
+DROP INDEX leaderboard_entries_tenant;
+
+ALTER TABLE event_outbox DROP COLUMN tenant_id;
+
+ALTER TABLE leaderboard_entries DROP COLUMN tenant_id;
+
+-- +goose StatementBegin
+CREATE TABLE categories_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    slug TEXT NOT NULL UNIQUE,
+    name TEXT NOT NULL,
+    created_at TIMESTAMP NOT NULL
+);
+-- +goose StatementEnd
+
+INSERT INTO categories_old (id, slug, name, created_at) SELECT id, slug, name, created_at FROM categories;
+
+DROP TABLE categories;
+
+ALTER TABLE categories_old RENAME TO categories;
+
+-- +goose StatementBegin
+CREATE TABLE users_old (
+    id INTEGER PRIMARY KEY AUTOINCREMENT,
+    name TEXT NOT NULL,
+    nickname TEXT NOT NULL UNIQUE,
+    likes INTEGER NOT NULL DEFAULT 0 CHECK (likes >= 0),
+    viewers INTEGER NOT NULL DEFAULT 0 CHECK (viewers >= 0),
+
+    rating REAL GENERATED ALWAYS AS (
+        CASE WHEN viewers > 0
+             THEN ROUND(CAST(likes AS REAL) / viewers, 3)
+             ELSE 0
+        END
+    ) STORED,
+
+    CONSTRAINT likes_lte_viewers CHECK (likes <= viewers)
+);
+-- +goose StatementEnd
+
+INSERT INTO users_old (id, name, nickname, likes, viewers) SELECT id, name, nickname, likes, viewers FROM users;
+
+DROP TABLE users;
+
+ALTER TABLE users_old RENAME TO users;
+
+COMMIT;
+
+PRAGMA foreign_keys = ON;
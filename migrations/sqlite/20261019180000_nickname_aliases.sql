@@ -0,0 +1,19 @@
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE nickname_aliases (
+    tenant_id TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    released_at TIMESTAMP NOT NULL,
+    PRIMARY KEY (tenant_id, nickname)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX nickname_aliases_user ON nickname_aliases (user_id, released_at);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+DROP TABLE nickname_aliases;
+-- +goose StatementEnd
@@ -0,0 +1,22 @@
+-- nickname_key holds the NFKC case folded nickname written by the service,
+-- for users and for the nicknames they released. Existing rows are backfilled
+-- and the keys made unique by the Go migration that follows, SQL has no
+-- equivalent of the folding.
+
+-- +goose Up
+-- +goose StatementBegin
+ALTER TABLE users ADD COLUMN nickname_key TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases ADD COLUMN nickname_key TEXT NOT NULL DEFAULT '';
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases DROP COLUMN nickname_key;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE users DROP COLUMN nickname_key;
+-- +goose StatementEnd
@@ -0,0 +1,69 @@
+-- Deleting a user keeps the nicknames it released, without a user, so they
+-- still cool down but no longer redirect. SQLite cannot alter a foreign key,
+-- the table is rebuilt.
+
+-- +goose Up
+-- +goose StatementBegin
+CREATE TABLE nickname_aliases_new (
+    tenant_id TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    user_id INTEGER REFERENCES users (id) ON DELETE SET NULL,
+    released_at TIMESTAMP NOT NULL,
+    nickname_key TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, nickname)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO nickname_aliases_new (tenant_id, nickname, user_id, released_at, nickname_key)
+    SELECT tenant_id, nickname, user_id, released_at, nickname_key FROM nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases_new RENAME TO nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX nickname_aliases_user ON nickname_aliases (user_id, released_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX nickname_aliases_tenant_nickname_key ON nickname_aliases (tenant_id, nickname_key);
+-- +goose StatementEnd
+
+-- +goose Down
+-- +goose StatementBegin
+CREATE TABLE nickname_aliases_old (
+    tenant_id TEXT NOT NULL,
+    nickname TEXT NOT NULL,
+    user_id INTEGER NOT NULL REFERENCES users (id) ON DELETE CASCADE,
+    released_at TIMESTAMP NOT NULL,
+    nickname_key TEXT NOT NULL DEFAULT '',
+    PRIMARY KEY (tenant_id, nickname)
+);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+INSERT INTO nickname_aliases_old (tenant_id, nickname, user_id, released_at, nickname_key)
+    SELECT tenant_id, nickname, user_id, released_at, nickname_key FROM nickname_aliases WHERE user_id IS NOT NULL;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+DROP TABLE nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+ALTER TABLE nickname_aliases_old RENAME TO nickname_aliases;
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE INDEX nickname_aliases_user ON nickname_aliases (user_id, released_at);
+-- +goose StatementEnd
+
+-- +goose StatementBegin
+CREATE UNIQUE INDEX nickname_aliases_tenant_nickname_key ON nickname_aliases (tenant_id, nickname_key);
+-- +goose StatementEnd